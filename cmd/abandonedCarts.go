@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	abandonedCartsCmd.Flags().Duration("since", 0, "how long a cart must go unmodified to be abandoned (default $CART_ABANDONED_CART_IDLE)")
+	rootCmd.AddCommand(abandonedCartsCmd)
+}
+
+var abandonedCartsCmd = &cobra.Command{
+	Use:   "abandoned-carts",
+	Short: "abandoned-carts records an AbandonedCart event for each cart idle beyond a threshold that has not been reported yet",
+	Run: func(cmd *cobra.Command, args []string) {
+		var v = viper.New()
+		v.AutomaticEnv()
+		v.SetEnvPrefix(service.EnvVarPrefix)
+
+		var svc = service.New(
+			service.ViperDefaults(v),
+			service.WithDB(),
+			service.WithZap(),
+		)
+		defer svc.Close()
+
+		since, err := cmd.Flags().GetDuration("since")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if since == 0 {
+			since = v.GetDuration(service.EnvVarAbandonedCartIdle)
+		}
+
+		reported, err := svc.DetectAbandonedCarts(context.Background(), since)
+		for _, c := range reported {
+			fmt.Printf("userId=%d\tmodifiedAt=%s\titemCount=%d\n", c.UserId, c.ModifiedAt.Format(time.RFC3339), c.ItemCount)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Reported %d abandoned carts idle since %s\n", len(reported), since)
+	},
+}
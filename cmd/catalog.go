@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tjper/shoppingcart-server/service"
+	"github.com/tjper/shoppingcart-server/service/catalog"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	catalogImportCmd.Flags().String("file", "", "the CSV or JSON Lines file to import, or - for stdin")
+	catalogImportCmd.Flags().String("format", "", "the format of the file, csv or jsonl (default by the file's extension)")
+	catalogImportCmd.Flags().Bool("dry-run", false, "report the changes the import would make without making them")
+	catalogImportCmd.MarkFlagRequired("file")
+
+	catalogExportCmd.Flags().String("file", "-", "the file to export to, or - for stdout")
+	catalogExportCmd.Flags().String("format", "", "the format of the file, csv or jsonl (default by the file's extension, or csv)")
+
+	catalogCmd.AddCommand(catalogImportCmd, catalogExportCmd)
+	rootCmd.AddCommand(catalogCmd)
+}
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "catalog imports and exports items in bulk, as CSV or JSON Lines",
+}
+
+var catalogImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import creates or updates the items of a file, and prints a report of the changes as JSON",
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			name, _   = cmd.Flags().GetString("file")
+			format, _ = cmd.Flags().GetString("format")
+			dryRun, _ = cmd.Flags().GetBool("dry-run")
+		)
+		if format == "" {
+			format = catalog.FormatOf(name)
+		}
+
+		var file io.Reader = os.Stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			file = f
+		}
+		reader, err := catalog.NewReader(file, format)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		var svc = newCatalogService()
+		defer svc.Close()
+
+		report, err := svc.ImportCatalog(context.Background(), reader, dryRun)
+		var e = json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		if err := e.Encode(report); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if report.Failed > 0 {
+			os.Exit(2)
+		}
+	},
+}
+
+var catalogExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export writes every item to a file",
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			name, _   = cmd.Flags().GetString("file")
+			format, _ = cmd.Flags().GetString("format")
+		)
+		if format == "" {
+			format = catalog.FormatOf(name)
+		}
+		if format == "" {
+			format = catalog.CSV
+		}
+
+		var file io.Writer = os.Stdout
+		if name != "-" {
+			f, err := os.Create(name)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			file = f
+		}
+		writer, err := catalog.NewWriter(file, format)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		var svc = newCatalogService()
+		defer svc.Close()
+
+		n, err := svc.ExportCatalog(context.Background(), writer)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d items\n", n)
+	},
+}
+
+// newCatalogService returns a Service configured by the environment to
+// import and export items.
+func newCatalogService() *service.Service {
+	var v = viper.New()
+	v.AutomaticEnv()
+	v.SetEnvPrefix(service.EnvVarPrefix)
+
+	return service.New(
+		service.ViperDefaults(v),
+		service.WithDB(),
+		service.WithZap(),
+	)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/tjper/shoppingcart-server/service"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	openapiCmd.Flags().StringP("out", "o", "openapi.json", "file the OpenAPI document is written to")
+	rootCmd.AddCommand(openapiCmd)
+}
+
+var openapiCmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "openapi writes the OpenAPI document describing the shoppingcart http api to a file",
+	Run: func(cmd *cobra.Command, args []string) {
+		out, err := cmd.Flags().GetString("out")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		var svc = service.New(service.ViperDefaults(viper.New()))
+		service.WithRouters(svc.Routes())(svc)
+
+		doc, err := svc.OpenAPI()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := ioutil.WriteFile(out, append(b, '\n'), 0644); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote OpenAPI document to %s\n", out)
+	},
+}
@@ -27,14 +27,20 @@ var serveCmd = &cobra.Command{
 			service.ViperDefaults(v),
 			service.WithDB(),
 			service.WithZap(),
+			service.WithGRPC(),
+			service.WithHub(),
+			service.WithOutbox(),
+			service.WithWebhooks(),
+			service.WithBlobStore(),
 		)
-		service.WithRouters(
-			svc.CartRoutes,
-			svc.ItemRoutes,
-		)(svc)
+		service.WithRouters(svc.Routes())(svc)
 
 		defer svc.Close()
 
+		go svc.ListenAndServeGRPC()
+		go svc.RelayOutbox()
+		go svc.DispatchWebhooks()
+		go svc.WatchAbandonedCarts()
 		svc.ListenAndServe()
 	},
 }
@@ -0,0 +1,9 @@
+-- cart_member grants users other than a cart's owner access to the cart. A
+-- cart is identified by the user id of its owner.
+CREATE TABLE IF NOT EXISTS cart_member (
+  cart_id INT NOT NULL,
+  user_id INT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (cart_id, user_id),
+  INDEX cart_member_user_id (user_id)
+);
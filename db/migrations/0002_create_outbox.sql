@@ -0,0 +1,15 @@
+-- outbox holds the domain events recorded in the same transaction as the
+-- changes they describe, until they are delivered to every sink by the
+-- outbox relay. Timestamps are UTC.
+CREATE TABLE IF NOT EXISTS outbox (
+  id BIGINT NOT NULL AUTO_INCREMENT,
+  type VARCHAR(64) NOT NULL,
+  aggregate_id INT NOT NULL,
+  payload TEXT NOT NULL,
+  created_at DATETIME(6) NOT NULL,
+  delivered_at DATETIME(6) NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT NULL,
+  PRIMARY KEY (id),
+  INDEX outbox_delivered_at (delivered_at, id)
+);
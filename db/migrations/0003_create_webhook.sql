@@ -0,0 +1,31 @@
+-- webhook holds partner subscriptions to domain events. An empty event_types
+-- subscribes to every event type. Timestamps are UTC.
+CREATE TABLE IF NOT EXISTS webhook (
+  id INT NOT NULL AUTO_INCREMENT,
+  url VARCHAR(2048) NOT NULL,
+  secret VARCHAR(128) NOT NULL,
+  event_types VARCHAR(1024) NOT NULL DEFAULT '',
+  created_at DATETIME(6) NOT NULL,
+  PRIMARY KEY (id)
+);
+
+-- webhook_delivery holds each delivery of an outbox event to a webhook, and
+-- its outcome. Deliveries that exhaust their attempts have a status of dead.
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+  id BIGINT NOT NULL AUTO_INCREMENT,
+  webhook_id INT NOT NULL,
+  event_id BIGINT NOT NULL,
+  event_type VARCHAR(64) NOT NULL,
+  payload TEXT NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at DATETIME(6) NOT NULL,
+  last_status_code INT NULL,
+  last_error TEXT NULL,
+  created_at DATETIME(6) NOT NULL,
+  delivered_at DATETIME(6) NULL,
+  PRIMARY KEY (id),
+  UNIQUE INDEX webhook_delivery_event (webhook_id, event_id),
+  INDEX webhook_delivery_due (status, next_attempt_at),
+  FOREIGN KEY (webhook_id) REFERENCES webhook (id) ON DELETE CASCADE
+);
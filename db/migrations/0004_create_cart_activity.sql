@@ -0,0 +1,16 @@
+-- cart_activity tracks when each user's cart was last modified, and when an
+-- AbandonedCart event was last recorded for it. A cart's current idle period
+-- has been reported if abandoned_at is at or after modified_at. Timestamps
+-- are UTC.
+CREATE TABLE IF NOT EXISTS cart_activity (
+  user_id INT NOT NULL,
+  modified_at DATETIME(6) NOT NULL,
+  abandoned_at DATETIME(6) NULL,
+  PRIMARY KEY (user_id),
+  INDEX cart_activity_modified (modified_at)
+);
+
+-- Carts that predate cart_activity are treated as modified now.
+INSERT IGNORE INTO cart_activity (user_id, modified_at)
+SELECT DISTINCT user_id, UTC_TIMESTAMP(6)
+FROM cart;
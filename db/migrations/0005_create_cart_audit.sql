@@ -0,0 +1,19 @@
+-- cart_audit is the append-only trail of cart mutations. cart_id is the user
+-- id of the cart's owner; actor_user_id is the user that made the change, if
+-- known. Timestamps are UTC.
+CREATE TABLE IF NOT EXISTS cart_audit (
+  id BIGINT NOT NULL AUTO_INCREMENT,
+  cart_id INT NOT NULL,
+  cart_item_id INT NULL,
+  item_id INT NULL,
+  action VARCHAR(16) NOT NULL,
+  old_count INT NULL,
+  new_count INT NULL,
+  actor_user_id INT NULL,
+  source VARCHAR(16) NOT NULL,
+  remote_addr VARCHAR(64) NOT NULL DEFAULT '',
+  request_id VARCHAR(128) NOT NULL DEFAULT '',
+  created_at DATETIME(6) NOT NULL,
+  PRIMARY KEY (id),
+  INDEX cart_audit_cart (cart_id, created_at)
+);
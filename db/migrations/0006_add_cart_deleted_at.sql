@@ -0,0 +1,5 @@
+-- deleted_at marks a cart line deleted, leaving a tombstone that may be
+-- restored until the undo window passes and it is purged. Timestamps are UTC.
+ALTER TABLE cart
+  ADD COLUMN deleted_at DATETIME(6) NULL,
+  ADD INDEX cart_deleted (deleted_at);
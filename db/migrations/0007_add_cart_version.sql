@@ -0,0 +1,8 @@
+-- version counts the modifications of a cart line, and of a cart, for
+-- optimistic concurrency control. A cart's version restarts when it is purged
+-- and next modified, so cart ETags also reflect cart_activity.modified_at.
+ALTER TABLE cart
+  ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE cart_activity
+  ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,17 @@
+-- idempotency_key holds the response to the first request made with each
+-- Idempotency-Key, scoped by the user making it (0 if unidentified), so
+-- that retries are answered with it. status_code is NULL while the first
+-- request is in progress. Keys may be reclaimed once expires_at passes.
+-- Timestamps are UTC.
+CREATE TABLE IF NOT EXISTS idempotency_key (
+  scope INT NOT NULL,
+  idem_key VARCHAR(255) NOT NULL,
+  request_hash CHAR(64) NOT NULL,
+  status_code INT NULL,
+  header TEXT NULL,
+  body MEDIUMBLOB NULL,
+  created_at DATETIME(6) NOT NULL,
+  expires_at DATETIME(6) NOT NULL,
+  PRIMARY KEY (scope, idem_key),
+  INDEX idempotency_key_expires (expires_at)
+);
@@ -0,0 +1,7 @@
+-- Quantity limits of an item's cart lines. A line's count must be at least
+-- min_per_order, at most max_per_order, and a multiple of step, such as the
+-- size of a multipack. Zero imposes no limit.
+ALTER TABLE item
+  ADD COLUMN min_per_order INT NOT NULL DEFAULT 0,
+  ADD COLUMN max_per_order INT NOT NULL DEFAULT 0,
+  ADD COLUMN step INT NOT NULL DEFAULT 0;
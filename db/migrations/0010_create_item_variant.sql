@@ -0,0 +1,19 @@
+-- item_variant holds the purchasable options of an item, such as its sizes
+-- and colors, each identified by a SKU. A variant's price overrides its
+-- item's price unless it is NULL. stock is the number of units available.
+CREATE TABLE IF NOT EXISTS item_variant (
+  id INT NOT NULL AUTO_INCREMENT,
+  item_id INT NOT NULL,
+  sku VARCHAR(64) NOT NULL,
+  attributes JSON NOT NULL,
+  price DECIMAL(13, 2) NULL,
+  stock INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (id),
+  UNIQUE INDEX item_variant_sku (sku),
+  INDEX item_variant_item_id (item_id)
+);
+
+-- variant_id is the variant of a cart line's item, or NULL if the item has no
+-- variants. A cart holds at most one line per item and variant.
+ALTER TABLE cart
+  ADD COLUMN variant_id INT NULL;
@@ -0,0 +1,21 @@
+-- category is a node of the catalog's taxonomy. Categories without a parent
+-- are roots; siblings are ordered by sort_order, then name.
+CREATE TABLE IF NOT EXISTS category (
+  id INT NOT NULL AUTO_INCREMENT,
+  parent_id INT NULL,
+  slug VARCHAR(64) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  sort_order INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (id),
+  UNIQUE INDEX category_slug (slug),
+  INDEX category_parent_id (parent_id)
+);
+
+-- item_category assigns items to categories. An item may belong to many
+-- categories.
+CREATE TABLE IF NOT EXISTS item_category (
+  item_id INT NOT NULL,
+  category_id INT NOT NULL,
+  PRIMARY KEY (item_id, category_id),
+  INDEX item_category_category_id (category_id, item_id)
+);
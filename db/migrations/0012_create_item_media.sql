@@ -0,0 +1,18 @@
+-- item_media holds the images of items. blob_key identifies the original
+-- image in the blob store, and thumbnails lists the scaled copies generated
+-- from it as JSON objects of size, width, height and key. An item's media
+-- are ordered by sort_order, then id.
+CREATE TABLE IF NOT EXISTS item_media (
+  id INT NOT NULL AUTO_INCREMENT,
+  item_id INT NOT NULL,
+  blob_key VARCHAR(255) NOT NULL,
+  content_type VARCHAR(64) NOT NULL,
+  width INT NOT NULL,
+  height INT NOT NULL,
+  alt_text VARCHAR(255) NOT NULL DEFAULT '',
+  sort_order INT NOT NULL DEFAULT 0,
+  thumbnails JSON NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX item_media_item_id (item_id, sort_order)
+);
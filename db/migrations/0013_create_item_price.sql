@@ -0,0 +1,38 @@
+-- item_price holds the scheduled prices of items. A scheduled price is in
+-- effect from valid_from (inclusive) until valid_to (exclusive), or
+-- indefinitely if valid_to is NULL. Of the scheduled prices in effect, the one
+-- that took effect last applies; while none is in effect, item.price does. A
+-- variant's price overrides its item's price, scheduled or not. Timestamps are
+-- UTC.
+CREATE TABLE IF NOT EXISTS item_price (
+  id INT NOT NULL AUTO_INCREMENT,
+  item_id INT NOT NULL,
+  price DECIMAL(13, 2) NOT NULL,
+  valid_from DATETIME(6) NOT NULL,
+  valid_to DATETIME(6) NULL,
+  created_at DATETIME(6) NOT NULL,
+  PRIMARY KEY (id),
+  INDEX item_price_item_id (item_id, valid_from)
+);
+
+-- item_base_price is the append-only log of changes to item.price, each in
+-- effect from valid_from until the next change.
+CREATE TABLE IF NOT EXISTS item_base_price (
+  id INT NOT NULL AUTO_INCREMENT,
+  item_id INT NOT NULL,
+  price DECIMAL(13, 2) NOT NULL,
+  valid_from DATETIME(6) NOT NULL,
+  PRIMARY KEY (id),
+  INDEX item_base_price_item_id (item_id, valid_from)
+);
+
+-- The log begins with the prices of the items at the time of migration.
+INSERT INTO item_base_price (item_id, price, valid_from)
+SELECT id, price, UTC_TIMESTAMP(6)
+FROM item;
+
+-- price is the unit price of a cart line after the mutation, so that
+-- reconstructed carts keep the prices that applied at the time. It is NULL
+-- for mutations that leave no line, and those recorded before prices were.
+ALTER TABLE cart_audit
+  ADD COLUMN price DECIMAL(13, 2) NULL;
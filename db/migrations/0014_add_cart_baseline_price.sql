@@ -0,0 +1,25 @@
+-- baseline_price is the unit price of a cart line when it was added, or when
+-- the price changes of its cart were last acknowledged. A line whose price in
+-- effect differs from it is flagged. Lines of carts at the time of migration
+-- take the price in effect then.
+ALTER TABLE cart
+  ADD COLUMN baseline_price DECIMAL(13, 2) NULL;
+
+UPDATE cart
+JOIN item
+  ON item.id = cart.item_id
+LEFT JOIN item_variant
+  ON item_variant.id = cart.variant_id
+SET cart.baseline_price = COALESCE(
+  item_variant.price,
+  (
+    SELECT item_price.price
+    FROM item_price
+    WHERE item_price.item_id = item.id
+          AND item_price.valid_from <= UTC_TIMESTAMP(6)
+          AND (item_price.valid_to IS NULL OR item_price.valid_to > UTC_TIMESTAMP(6))
+    ORDER BY item_price.valid_from DESC, item_price.id DESC
+    LIMIT 1
+  ),
+  item.price
+);
@@ -0,0 +1,5 @@
+-- guest marks the carts of guests, which expire after the guest cart TTL
+-- rather than the user cart TTL. A cart is marked when its owner modifies it;
+-- carts that predate the column are treated as user carts.
+ALTER TABLE cart_activity
+  ADD COLUMN guest BOOLEAN NOT NULL DEFAULT FALSE;
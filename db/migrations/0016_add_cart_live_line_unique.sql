@@ -0,0 +1,25 @@
+-- live_variant_id is the variant of a cart line that is not deleted, or 0 if
+-- its item has no variants, and is NULL for tombstones. Unique indexes admit
+-- any number of NULLs, so cart_live_line holds a cart to one live line per
+-- item and variant while tombstones of it remain restorable.
+ALTER TABLE cart
+  ADD COLUMN live_variant_id INT AS (IF(deleted_at IS NULL, COALESCE(variant_id, 0), NULL)) VIRTUAL;
+
+-- Duplicate live lines, left by concurrent additions, are deleted but for the
+-- first of each, so that the index can be added.
+UPDATE cart
+JOIN (
+  SELECT user_id, item_id, live_variant_id, MIN(id) AS first_id
+  FROM cart
+  WHERE live_variant_id IS NOT NULL
+  GROUP BY user_id, item_id, live_variant_id
+  HAVING COUNT(*) > 1
+) AS duplicate
+  ON duplicate.user_id = cart.user_id
+     AND duplicate.item_id = cart.item_id
+     AND duplicate.live_variant_id = cart.live_variant_id
+SET cart.deleted_at = UTC_TIMESTAMP(6)
+WHERE cart.id <> duplicate.first_id;
+
+ALTER TABLE cart
+  ADD UNIQUE INDEX cart_live_line (user_id, item_id, live_variant_id);
@@ -0,0 +1,6 @@
+-- dead_at is when an outbox event was dead-lettered after exhausting its
+-- delivery attempts, or NULL if it was not. Dead events are no longer
+-- relayed, so that they do not hold back the events recorded after them.
+-- Timestamps are UTC.
+ALTER TABLE outbox
+  ADD COLUMN dead_at DATETIME(6) NULL;
@@ -13,7 +13,10 @@ require (
 	github.com/go-chi/chi v4.0.2+incompatible
 	github.com/go-chi/cors v1.0.0
 	github.com/go-sql-driver/mysql v1.4.1
+	github.com/golang/protobuf v1.3.2
 	github.com/gorilla/mux v1.7.3 // indirect
+	github.com/gorilla/websocket v1.4.1
+	github.com/graphql-go/graphql v0.8.1
 	github.com/opencontainers/go-digest v1.0.0-rc1 // indirect
 	github.com/opencontainers/image-spec v1.0.1 // indirect
 	github.com/pkg/errors v0.8.1
@@ -24,6 +27,8 @@ require (
 	github.com/tjper/testing v0.0.0-20190615185608-b70c8eaa3056
 	go.uber.org/zap v1.10.0
 	golang.org/x/net v0.0.0-20190620200207-3b0461eec859 // indirect
+	google.golang.org/genproto v0.0.0-20190819201941-24fa4b261c55
+	google.golang.org/grpc v1.26.0
 	gotest.tools v2.2.0+incompatible // indirect
 )
 
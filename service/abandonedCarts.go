@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/outbox"
+
+	"go.uber.org/zap"
+)
+
+// DetectAbandonedCarts records an AbandonedCart event in the outbox for each
+// non-empty cart that has not been modified for idle, and whose current idle
+// period has not been reported yet. The carts reported are returned.
+func (svc *Service) DetectAbandonedCarts(ctx context.Context, idle time.Duration) ([]cart.IdleCart, error) {
+	var (
+		before    = time.Now().Add(-idle)
+		batchSize = svc.Viper.GetInt(EnvVarAbandonedCartBatchSize)
+		reported  = make([]cart.IdleCart, 0)
+		after     int
+	)
+	for {
+		carts, err := cart.IdleCarts(ctx, svc.DB, before, after, batchSize, true)
+		if err != nil {
+			return reported, err
+		}
+
+		for _, c := range carts {
+			ok, err := svc.reportAbandonedCart(ctx, c)
+			if err != nil {
+				return reported, err
+			}
+			if ok {
+				reported = append(reported, c)
+			}
+		}
+
+		if len(carts) < batchSize {
+			return reported, nil
+		}
+		after = carts[len(carts)-1].UserId
+	}
+}
+
+// reportAbandonedCart records an AbandonedCart event for c, unless its idle
+// period was reported concurrently or it was modified since being read, in
+// which case false is returned.
+func (svc *Service) reportAbandonedCart(ctx context.Context, c cart.IdleCart) (bool, error) {
+	var reported bool
+	err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		reported, err = cart.MarkAbandoned(ctx, tx, c.UserId, c.ModifiedAt)
+		if err != nil || !reported {
+			return err
+		}
+
+		cartItems, err := cart.CartItems(ctx, tx, c.UserId)
+		if err != nil {
+			return err
+		}
+		return outbox.Insert(ctx, tx, cart.EventAbandonedCart, c.UserId, cart.AbandonedCartEvent{
+			UserId:     c.UserId,
+			ModifiedAt: c.ModifiedAt,
+			CartItems:  cartItems,
+		})
+	})
+	return reported, err
+}
+
+// WatchAbandonedCarts detects abandoned carts at the interval specified in
+// viper until the process is signaled to stop.
+func (svc *Service) WatchAbandonedCarts() {
+	var (
+		ctx    = signalContext()
+		idle   = svc.Viper.GetDuration(EnvVarAbandonedCartIdle)
+		ticker = time.NewTicker(svc.Viper.GetDuration(EnvVarAbandonedCartInterval))
+	)
+	defer ticker.Stop()
+
+	for {
+		reported, err := svc.DetectAbandonedCarts(ctx, idle)
+		if err != nil {
+			svc.Zap.Error(err.Error())
+		}
+		if len(reported) > 0 {
+			svc.Zap.Info("abandoned carts reported", zap.Int("count", len(reported)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
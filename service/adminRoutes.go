@@ -0,0 +1,252 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/item"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultAbandonedCartsLimit and maxAbandonedCartsLimit bound the number
+	// of abandoned carts listed per request.
+	defaultAbandonedCartsLimit = 50
+	maxAbandonedCartsLimit     = 500
+
+	// defaultCartHistoryLimit and maxCartHistoryLimit bound the number of
+	// audit entries listed per request.
+	defaultCartHistoryLimit = 100
+	maxCartHistoryLimit     = 1000
+)
+
+// AdminRoutes defines the REST endpoints used by internal teams to inspect
+// carts across users. They are restricted to admins.
+func (svc *Service) AdminRoutes(r chi.Router) {
+	r.Use(svc.requireAdmin)
+	r.Get("/admin/carts/abandoned", svc.GetAbandonedCartsHandler())
+	r.Get("/admin/cart/{userId}/history", svc.GetCartHistoryHandler())
+	r.Get("/admin/cart/{userId}/snapshot", svc.GetCartSnapshotHandler())
+}
+
+// GetAbandonedCartsHandler retrieves the non-empty carts that have not been
+// modified for some time.
+func (svc *Service) GetAbandonedCartsHandler() http.HandlerFunc {
+	type Response struct {
+		Carts []cart.IdleCart `json:"carts"`
+
+		// NextAfter is the after query parameter that retrieves the next
+		// page, if there may be one.
+		NextAfter int `json:"nextAfter,omitempty"`
+	}
+	return describe(operation{
+		Id:       "GetAbandonedCartsHandler",
+		Summary:  "Retrieve non-empty carts not modified for the since query parameter, a duration such as 24h, ordered by user id. Pages are retrieved with the after and limit query parameters.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		var idle = svc.Viper.GetDuration(EnvVarAbandonedCartIdle)
+		if param := r.URL.Query().Get("since"); param != "" {
+			var err error
+			idle, err = time.ParseDuration(param)
+			if err != nil || idle <= 0 {
+				svc.HandleError(w, errors.Wrapf(errMalformed, "failed to GetAbandonedCarts\tsince=%s", param))
+				return
+			}
+		}
+		var after int
+		if param := r.URL.Query().Get("after"); param != "" {
+			var err error
+			after, err = strconv.Atoi(param)
+			if err != nil || after < 0 {
+				svc.HandleError(w, errors.Wrapf(errMalformed, "failed to GetAbandonedCarts\tafter=%s", param))
+				return
+			}
+		}
+		limit, err := queryLimit(r, defaultAbandonedCartsLimit, maxAbandonedCartsLimit)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		carts, err := cart.IdleCarts(ctx, svc.DB, time.Now().Add(-idle), after, limit, false)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Carts: carts,
+		}
+		if len(carts) == limit {
+			resp.NextAfter = carts[len(carts)-1].UserId
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// GetCartHistoryHandler retrieves the audit trail of a cart.
+func (svc *Service) GetCartHistoryHandler() http.HandlerFunc {
+	type Response struct {
+		Entries []cart.AuditEntry `json:"entries"`
+
+		// NextAfter is the after query parameter that retrieves the next
+		// page, if there may be one.
+		NextAfter int64 `json:"nextAfter,omitempty"`
+	}
+	return describe(operation{
+		Id:       "GetCartHistoryHandler",
+		Summary:  "Retrieve the audit trail of a cart, oldest first, optionally limited to the RFC 3339 from (inclusive) and to (exclusive) query parameters. Pages are retrieved with the after and limit query parameters. Remote addresses are masked to their /24 (IPv4) or /48 (IPv6) network.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		from, err := queryTime(r, "from")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		to, err := queryTime(r, "to")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		var after int64
+		if param := r.URL.Query().Get("after"); param != "" {
+			after, err = strconv.ParseInt(param, 10, 64)
+			if err != nil || after < 0 {
+				svc.HandleError(w, errors.Wrapf(errMalformed, "failed to GetCartHistory\tafter=%s", param))
+				return
+			}
+		}
+		limit, err := queryLimit(r, defaultCartHistoryLimit, maxCartHistoryLimit)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		entries, err := cart.AuditHistory(ctx, svc.DB, userId, from, to, after, limit)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		for n := range entries {
+			entries[n].RemoteAddr = maskAddr(entries[n].RemoteAddr)
+		}
+
+		var resp = Response{
+			Entries: entries,
+		}
+		if len(entries) == limit {
+			resp.NextAfter = entries[len(entries)-1].Id
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// GetCartSnapshotHandler reconstructs a cart as it was at a point in time.
+func (svc *Service) GetCartSnapshotHandler() http.HandlerFunc {
+	type (
+		Line struct {
+			cart.AuditLine
+
+			// UnitPrice is the price of the line's item in effect at the
+			// snapshot's time, or nil if the item no longer exists or has
+			// variants, whose prices may override it and are not recorded
+			// over time.
+			UnitPrice *float64 `json:"unitPrice,omitempty"`
+		}
+		Response struct {
+			At    time.Time `json:"at"`
+			Lines []Line    `json:"lines"`
+		}
+	)
+	return describe(operation{
+		Id:       "GetCartSnapshotHandler",
+		Summary:  "Reconstruct the lines of a cart at the RFC 3339 at query parameter, or now if absent, by replaying its audit trail. Each line keeps the price recorded when it was last changed, and its unitPrice is the price of its item in effect at that time, resolved as GET /items/{id}/price resolves it. Lines of items with variants, whose prices are not recorded over time, have no unitPrice. A snapshot's unit prices do not change however prices change later.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		at, err := queryTime(r, "at")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if at.IsZero() {
+			at = time.Now()
+		}
+
+		// The entries created at or before at are replayed.
+		var (
+			entries []cart.AuditEntry
+			after   int64
+			to      = at.Add(time.Microsecond)
+		)
+		for {
+			page, err := cart.AuditHistory(ctx, svc.DB, userId, time.Time{}, to, after, maxCartHistoryLimit)
+			if err != nil {
+				svc.HandleError(w, err)
+				return
+			}
+			entries = append(entries, page...)
+			if len(page) < maxCartHistoryLimit {
+				break
+			}
+			after = page[len(page)-1].Id
+		}
+
+		var (
+			lines   = cart.Reconstruct(entries)
+			itemIds = make([]int, 0, len(lines))
+		)
+		for _, l := range lines {
+			itemIds = append(itemIds, l.ItemId)
+		}
+		prices, err := item.PricesAt(ctx, svc.DB, itemIds, at)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		variants, err := item.ItemVariants(ctx, svc.DB, itemIds)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			At:    at.UTC(),
+			Lines: make([]Line, 0, len(lines)),
+		}
+		for _, l := range lines {
+			var line = Line{AuditLine: l}
+			if p, ok := prices[l.ItemId]; ok && len(variants[l.ItemId]) == 0 {
+				line.UnitPrice = &p.Price
+			}
+			resp.Lines = append(resp.Lines, line)
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
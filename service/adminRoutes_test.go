@@ -0,0 +1,78 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminRoutesMalformed(t *testing.T) {
+	tests := []struct {
+		Name string
+		Path string
+	}{
+		{Name: "since not a duration", Path: "/v2/admin/carts/abandoned?since=yesterday"},
+		{Name: "since negative", Path: "/v2/admin/carts/abandoned?since=-1h"},
+		{Name: "after non-numeric", Path: "/v2/admin/carts/abandoned?after=abc"},
+		{Name: "after negative", Path: "/v2/admin/carts/abandoned?after=-1"},
+		{Name: "limit zero", Path: "/v2/admin/carts/abandoned?limit=0"},
+		{Name: "history malformed user", Path: "/v1/admin/cart/abc/history"},
+		{Name: "history malformed from", Path: "/v1/admin/cart/1/history?from=yesterday"},
+		{Name: "history malformed to", Path: "/v1/admin/cart/1/history?to=2020-01-02"},
+		{Name: "history malformed after", Path: "/v1/admin/cart/1/history?after=abc"},
+		{Name: "history malformed limit", Path: "/v1/admin/cart/1/history?limit=-5"},
+		{Name: "snapshot malformed at", Path: "/v2/admin/cart/1/snapshot?at=noon"},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = asAdmin(httptest.NewRequest(http.MethodGet, test.Path, nil))
+			)
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestAdminRoutesForbidden(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Path   string
+		Roles  string
+		UserId string
+		Status int
+	}{
+		{Name: "abandoned anonymous", Path: "/v1/admin/carts/abandoned", Status: http.StatusUnauthorized},
+		{Name: "abandoned customer", Path: "/v2/admin/carts/abandoned", UserId: "7", Status: http.StatusForbidden},
+		{Name: "abandoned other role", Path: "/v1/admin/carts/abandoned", UserId: "7", Roles: "support", Status: http.StatusForbidden},
+		{Name: "history customer", Path: "/v1/admin/cart/7/history", UserId: "7", Status: http.StatusForbidden},
+		{Name: "snapshot customer", Path: "/v2/admin/cart/7/snapshot", UserId: "7", Status: http.StatusForbidden},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(http.MethodGet, test.Path, nil)
+			)
+			if test.UserId != "" {
+				r.Header.Set(headerUserId, test.UserId)
+			}
+			if test.Roles != "" {
+				r.Header.Set(headerUserRoles, test.Roles)
+			}
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+		})
+	}
+}
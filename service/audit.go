@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+
+	"github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Sources of cart mutations recorded in the audit trail.
+const (
+	sourceHTTP   = "http"
+	sourceGRPC   = "grpc"
+	sourceSystem = "system"
+)
+
+// actor describes who made a request, for the audit trail.
+type actor struct {
+	UserId int
+
+	// Guest reports whether the user is a guest; see roleGuest.
+	Guest bool
+
+	Source     string
+	RemoteAddr string
+	RequestId  string
+}
+
+type actorKey struct{}
+
+// withActor returns a copy of ctx carrying a.
+func withActor(ctx context.Context, a actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// actorFrom returns the actor carried by ctx. Mutations made outside of a
+// request, such as purges, are attributed to the system.
+func actorFrom(ctx context.Context) actor {
+	if a, ok := ctx.Value(actorKey{}).(actor); ok {
+		return a
+	}
+	return actor{Source: sourceSystem}
+}
+
+// actorMiddleware attributes requests to the user identified by
+// requestUserId, if any, and whether they are a guest, the remote address set
+// by middleware.RealIP, and the request id set by middleware.RequestID.
+func actorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId, _ := requestUserId(r)
+		var a = actor{
+			UserId:     userId,
+			Guest:      hasRole(r.Header.Get(headerUserRoles), roleGuest),
+			Source:     sourceHTTP,
+			RemoteAddr: remoteHost(r.RemoteAddr),
+			RequestId:  middleware.GetReqID(r.Context()),
+		}
+		next.ServeHTTP(w, r.WithContext(withActor(r.Context(), a)))
+	})
+}
+
+// grpcActorInterceptor attributes gRPC calls to the user identified by the
+// x-user-id metadata, if any, and whether they are a guest per the
+// x-user-roles metadata, the peer address, and the x-request-id metadata.
+func grpcActorInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	var a = actor{Source: sourceGRPC}
+	if p, ok := peer.FromContext(ctx); ok {
+		a.RemoteAddr = remoteHost(p.Addr.String())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-user-id"); len(values) > 0 {
+			a.UserId, _ = strconv.Atoi(values[0])
+		}
+		if values := md.Get("x-user-roles"); len(values) > 0 {
+			a.Guest = hasRole(values[0], roleGuest)
+		}
+		if values := md.Get("x-request-id"); len(values) > 0 {
+			a.RequestId = values[0]
+		}
+	}
+	return handler(withActor(ctx, a), req)
+}
+
+// remoteHost strips the port from addr, if any.
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// maskAddr truncates addr, an IP address, to its network so that the audit
+// trail can be reported without identifying the client: IPv4 addresses to
+// their /24 and IPv6 addresses to their /48. Anything else is masked
+// entirely.
+func maskAddr(addr string) string {
+	var ip = net.ParseIP(addr)
+	if ip == nil {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// recordAudit appends e to the audit trail within tx, attributed to the
+// actor carried by ctx.
+func recordAudit(ctx context.Context, tx cart.Execer, e cart.AuditEntry) error {
+	var a = actorFrom(ctx)
+	e.ActorUserId = a.UserId
+	e.Source = a.Source
+	e.RemoteAddr = a.RemoteAddr
+	e.RequestId = a.RequestId
+	return cart.InsertAudit(ctx, tx, e)
+}
+
+// auditCount returns a pointer to n, for the counts of a cart.AuditEntry.
+func auditCount(n int) *int {
+	return &n
+}
+
+// auditPrice returns the unit price of cartItem, for an AuditEntry.
+func auditPrice(cartItem *cart.CartItem) *float64 {
+	var price = cartItem.Item.Price
+	return &price
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestActorMiddleware(t *testing.T) {
+	var actual actor
+	var h = middleware.RequestID(middleware.RealIP(actorMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actual = actorFrom(r.Context())
+	}))))
+
+	var r = httptest.NewRequest(http.MethodPost, "/cart/item", nil)
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	r.Header.Set(headerUserId, "3")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	require.Equal(t, 3, actual.UserId)
+	require.False(t, actual.Guest)
+	require.Equal(t, sourceHTTP, actual.Source)
+	require.Equal(t, "203.0.113.7", actual.RemoteAddr)
+	require.NotEmpty(t, actual.RequestId)
+
+	// Anonymous requests are attributed to no user.
+	r = httptest.NewRequest(http.MethodPost, "/cart/item", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	require.Equal(t, 0, actual.UserId)
+	require.Equal(t, "192.0.2.1", actual.RemoteAddr)
+
+	// Guests are identified by their role.
+	r = httptest.NewRequest(http.MethodPost, "/cart/item", nil)
+	r.Header.Set(headerUserId, "5")
+	r.Header.Set(headerUserRoles, "shopper, guest")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	require.Equal(t, 5, actual.UserId)
+	require.True(t, actual.Guest)
+}
+
+func TestActorFromSystem(t *testing.T) {
+	require.Equal(t, actor{Source: sourceSystem}, actorFrom(context.Background()))
+}
+
+func TestMaskAddr(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Addr     string
+		Expected string
+	}{
+		{Name: "ipv4", Addr: "203.0.113.7", Expected: "203.0.113.0"},
+		{Name: "ipv4-mapped ipv6", Addr: "::ffff:198.51.100.2", Expected: "198.51.100.0"},
+		{Name: "ipv6", Addr: "2001:db8:85a3:8d3:1319:8a2e:370:7348", Expected: "2001:db8:85a3::"},
+		{Name: "empty", Addr: "", Expected: ""},
+		{Name: "not an ip", Addr: "bufconn", Expected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Expected, maskAddr(test.Addr))
+		})
+	}
+}
+
+func TestGRPCActorInterceptor(t *testing.T) {
+	var ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-user-id", "4", "x-user-roles", roleGuest, "x-request-id", "req-1"))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("198.51.100.2"), Port: 5000}})
+
+	var actual actor
+	_, err := grpcActorInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		actual = actorFrom(ctx)
+		return nil, nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, actor{UserId: 4, Guest: true, Source: sourceGRPC, RemoteAddr: "198.51.100.2", RequestId: "req-1"}, actual)
+}
+
+func TestChainUnaryInterceptors(t *testing.T) {
+	var calls []string
+	var interceptor = func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			calls = append(calls, name)
+			return handler(ctx, req)
+		}
+	}
+
+	resp, err := chainUnaryInterceptors(interceptor("first"), interceptor("second"))(
+		context.Background(), nil, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls = append(calls, "handler")
+			return "resp", nil
+		})
+	require.Nil(t, err)
+	require.Equal(t, "resp", resp)
+	require.Equal(t, []string{"first", "second", "handler"}, calls)
+}
@@ -0,0 +1,150 @@
+// Package blob stores binary objects, such as item images, by key.
+package blob
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotFound indicates the requested blob does not exist.
+	ErrNotFound = errors.New("blob: not found")
+
+	// ErrInvalidKey indicates a key is not a clean, relative, slash
+	// separated path, or ends in the suffix of temporary files.
+	ErrInvalidKey = errors.New("blob: invalid key")
+)
+
+// Store is a store of blobs. Keys are clean, relative, slash separated paths,
+// such as "items/1/photo.jpg", that do not end in tmpSuffix.
+type Store interface {
+	// Put stores the contents of r as the blob key, replacing any blob of
+	// the same key.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// Open opens the blob key for reading. If the blob does not exist, an
+	// error wrapping ErrNotFound is returned.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete deletes the blob key. Deleting a blob that does not exist is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+
+	// URL returns the URL the blob key is served at.
+	URL(key string) string
+}
+
+// tmpSuffix ends the names of the temporary files blobs are written to
+// before they are moved into place. Keys may not end in it, so that partial
+// blobs are never opened.
+const tmpSuffix = ".tmp"
+
+// checkKey returns an error wrapping ErrInvalidKey unless key is a clean,
+// relative, slash separated path that does not end in tmpSuffix.
+func checkKey(key string) error {
+	if key == "" || path.Clean(key) != key || path.IsAbs(key) ||
+		key == ".." || strings.HasPrefix(key, "../") || strings.Contains(key, "\\") ||
+		strings.HasSuffix(key, tmpSuffix) {
+		return errors.Wrapf(ErrInvalidKey, "failed to checkKey\tkey=%s", key)
+	}
+	return nil
+}
+
+// FS is a Store of files beneath a root directory of the local filesystem.
+// Its blobs are served beneath BaseURL, which FS does not do itself; see
+// Open.
+type FS struct {
+	Root    string
+	BaseURL string
+}
+
+// NewFS returns an FS storing blobs beneath root, which is created if
+// necessary, and served beneath baseURL.
+func NewFS(root, baseURL string) (*FS, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to NewFS/MkdirAll\troot=%s", root)
+	}
+	return &FS{Root: root, BaseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Put implements Store. The blob is written to a temporary file of its own
+// that is renamed into place, so that readers never observe a partial blob
+// and concurrent Puts of a key do not write the same file.
+func (s *FS) Put(ctx context.Context, key string, r io.Reader) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	var name = s.name(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return errors.Wrapf(err, "failed to FS.Put/MkdirAll\tkey=%s", key)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".*"+tmpSuffix)
+	if err != nil {
+		return errors.Wrapf(err, "failed to FS.Put/TempFile\tkey=%s", key)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "failed to FS.Put/Chmod\tkey=%s", key)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "failed to FS.Put/Copy\tkey=%s", key)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "failed to FS.Put/Close\tkey=%s", key)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrapf(err, "failed to FS.Put/Rename\tkey=%s", key)
+	}
+	return nil
+}
+
+// Open implements Store.
+func (s *FS) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(s.name(key))
+	if os.IsNotExist(err) {
+		return nil, errors.Wrapf(ErrNotFound, "failed to FS.Open\tkey=%s", key)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to FS.Open\tkey=%s", key)
+	}
+	return file, nil
+}
+
+// Delete implements Store.
+func (s *FS) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(s.name(key)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to FS.Delete\tkey=%s", key)
+	}
+	return nil
+}
+
+// URL implements Store.
+func (s *FS) URL(key string) string {
+	var u = url.URL{Path: key}
+	return s.BaseURL + "/" + u.EscapedPath()
+}
+
+// name returns the name of the file of the blob key.
+func (s *FS) name(key string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(key))
+}
@@ -0,0 +1,72 @@
+package blob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	root, err := ioutil.TempDir("", "blob")
+	require.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	var ctx = context.Background()
+	s, err := NewFS(root, "/media/")
+	require.Nil(t, err)
+
+	require.Nil(t, s.Put(ctx, "items/1/a b.jpg", strings.NewReader("jpeg")))
+	r, err := s.Open(ctx, "items/1/a b.jpg")
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(r)
+	require.Nil(t, err)
+	r.Close()
+	require.Equal(t, "jpeg", string(b))
+	require.Equal(t, "/media/items/1/a%20b.jpg", s.URL("items/1/a b.jpg"))
+
+	// Only the blob is left beneath the root.
+	names, err := ioutil.ReadDir(filepath.Join(root, "items", "1"))
+	require.Nil(t, err)
+	require.Len(t, names, 1)
+	require.Equal(t, "a b.jpg", names[0].Name())
+	require.Equal(t, os.FileMode(0644), names[0].Mode().Perm())
+
+	require.Nil(t, s.Delete(ctx, "items/1/a b.jpg"))
+	require.Nil(t, s.Delete(ctx, "items/1/a b.jpg"))
+	_, err = s.Open(ctx, "items/1/a b.jpg")
+	require.Equal(t, ErrNotFound, errors.Cause(err))
+}
+
+func TestCheckKey(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Key   string
+		Valid bool
+	}{
+		{Name: "valid", Key: "items/1/photo.jpg", Valid: true},
+		{Name: "empty", Key: ""},
+		{Name: "absolute", Key: "/etc/passwd"},
+		{Name: "parent", Key: "../secret"},
+		{Name: "inner parent", Key: "items/../../secret"},
+		{Name: "unclean", Key: "items//1"},
+		{Name: "backslash", Key: "items\\1"},
+		{Name: "temporary", Key: "items/1/photo.jpg.tmp"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := checkKey(test.Key)
+			if test.Valid {
+				require.Nil(t, err)
+				return
+			}
+			require.Equal(t, ErrInvalidKey, errors.Cause(err))
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package cart
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// Touch records that the cart of userId was modified now, and increments its
+// version. Touch is called in the transaction that modifies the cart. If
+// guest is not nil, the cart is marked a guest cart or a user cart
+// accordingly; otherwise it is left as it was, and new carts are user carts.
+func Touch(ctx context.Context, db Execer, userId int, guest *bool) error {
+	var sql = `
+  INSERT INTO cart_activity (user_id, modified_at, version, guest)
+  VALUES (?, UTC_TIMESTAMP(6), 1, COALESCE(?, FALSE))
+  ON DUPLICATE KEY UPDATE modified_at = VALUES(modified_at),
+                          version = version + 1,
+                          guest = COALESCE(?, guest)
+  `
+	var args = []interface{}{userId, guest, guest}
+	if _, err := db.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to Touch/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// Version identifies a state of a cart for optimistic concurrency control.
+// The zero Version is that of a cart that was never modified, or was purged.
+type Version struct {
+	Version    int
+	ModifiedAt time.Time
+}
+
+// CartVersion retrieves the Version of the cart of userId from the db.
+func CartVersion(ctx context.Context, db QueryRower, userId int) (Version, error) {
+	var sql = `
+  SELECT version, modified_at
+  FROM cart_activity
+  WHERE user_id = ?
+  `
+	var (
+		v          Version
+		modifiedAt mysql.NullTime
+	)
+	if err := db.QueryRowContext(ctx, sql, userId).Scan(&v.Version, &modifiedAt); err != nil {
+		if errors.Cause(classify(err)) == ErrNotFound {
+			return Version{}, nil
+		}
+		return Version{}, errors.Wrapf(err, "failed to CartVersion/Scan\tsql=%s\tuserId=%v", sql, userId)
+	}
+	v.ModifiedAt = modifiedAt.Time
+	return v, nil
+}
+
+// IdleCart is a non-empty cart that has not been modified for some time.
+type IdleCart struct {
+	UserId     int       `json:"userId"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+
+	// AbandonedAt is when an AbandonedCart event was last recorded for the
+	// cart, if ever. It precedes ModifiedAt if the cart's current idle period
+	// has not been reported.
+	AbandonedAt *time.Time `json:"abandonedAt,omitempty"`
+
+	ItemCount int `json:"itemCount"`
+}
+
+// IdleCarts retrieves at most limit non-empty carts last modified at or
+// before before, ordered by user id, starting after the user id after. If
+// unreported is true, only carts whose current idle period has not been
+// reported by MarkAbandoned are retrieved.
+func IdleCarts(ctx context.Context, db Queryer, before time.Time, after, limit int, unreported bool) ([]IdleCart, error) {
+	var sql = `
+  SELECT
+    cart_activity.user_id,
+    cart_activity.modified_at,
+    cart_activity.abandoned_at,
+    COUNT(cart.id)
+  FROM cart_activity
+  INNER JOIN cart
+  ON cart_activity.user_id = cart.user_id
+     AND cart.deleted_at IS NULL
+  WHERE cart_activity.modified_at <= ?
+        AND cart_activity.user_id > ?
+        AND (
+          ? = FALSE
+          OR cart_activity.abandoned_at IS NULL
+          OR cart_activity.abandoned_at < cart_activity.modified_at
+        )
+  GROUP BY cart_activity.user_id, cart_activity.modified_at, cart_activity.abandoned_at
+  ORDER BY cart_activity.user_id
+  LIMIT ?
+  `
+	var args = []interface{}{before.UTC(), after, unreported, limit}
+
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to IdleCarts/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	var carts = make([]IdleCart, 0)
+	for rows.Next() {
+		var (
+			c           IdleCart
+			modifiedAt  mysql.NullTime
+			abandonedAt mysql.NullTime
+		)
+		if err := rows.Scan(&c.UserId, &modifiedAt, &abandonedAt, &c.ItemCount); err != nil {
+			return nil, errors.Wrapf(err, "failed to IdleCarts/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		c.ModifiedAt = modifiedAt.Time
+		if abandonedAt.Valid {
+			c.AbandonedAt = &abandonedAt.Time
+		}
+		carts = append(carts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to IdleCarts/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return carts, nil
+}
+
+// MarkAbandoned records that the idle period of the cart of userId that
+// began at modifiedAt was reported. MarkAbandoned reports false if the
+// period was already reported, or the cart was modified since modifiedAt,
+// so that each idle period is reported once.
+func MarkAbandoned(ctx context.Context, db Execer, userId int, modifiedAt time.Time) (bool, error) {
+	var sql = `
+  UPDATE cart_activity
+  SET abandoned_at = UTC_TIMESTAMP(6)
+  WHERE user_id = ?
+        AND modified_at = ?
+        AND (abandoned_at IS NULL OR abandoned_at < modified_at)
+  `
+	var args = []interface{}{userId, modifiedAt.UTC()}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to MarkAbandoned/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to MarkAbandoned/RowsAffected\tsql=%s\targs=%v", sql, args)
+	}
+	return n == 1, nil
+}
+
+// Expiry specifies the carts that have expired: user carts last modified at
+// or before User, and guest carts last modified at or before Guest. A zero
+// time expires no carts of its kind.
+type Expiry struct {
+	User  time.Time
+	Guest time.Time
+}
+
+// Expired reports whether a guest or user cart last modified at modifiedAt
+// has expired.
+func (e Expiry) Expired(guest bool, modifiedAt time.Time) bool {
+	var before = e.User
+	if guest {
+		before = e.Guest
+	}
+	return !before.IsZero() && !modifiedAt.After(before)
+}
+
+// ExpiredCarts retrieves the user ids of at most limit carts that have
+// expired per exp, least recently modified first.
+func ExpiredCarts(ctx context.Context, db Queryer, exp Expiry, limit int) ([]int, error) {
+	var sql = `
+  SELECT user_id
+  FROM cart_activity
+  WHERE (guest = FALSE AND ? AND modified_at <= ?)
+        OR (guest = TRUE AND ? AND modified_at <= ?)
+  ORDER BY modified_at
+  LIMIT ?
+  `
+	var args = []interface{}{!exp.User.IsZero(), exp.User.UTC(), !exp.Guest.IsZero(), exp.Guest.UTC(), limit}
+
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to ExpiredCarts/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	var userIds = make([]int, 0)
+	for rows.Next() {
+		var userId int
+		if err := rows.Scan(&userId); err != nil {
+			return nil, errors.Wrapf(err, "failed to ExpiredCarts/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		userIds = append(userIds, userId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to ExpiredCarts/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return userIds, nil
+}
+
+// PurgeCart deletes at most limit lines of the cart of userId, provided the
+// cart has expired per exp. The cart's activity is locked until tx ends, so
+// that the cart is not modified while it is purged. The number of lines
+// deleted is returned; if it is less than limit, the cart is empty and its
+// activity is deleted too, which is reported by emptied. A cart that has not
+// expired, having been modified since, is left untouched.
+func PurgeCart(ctx context.Context, tx ExecQueryer, userId int, exp Expiry, limit int) (n int, emptied bool, err error) {
+	var sql = `
+  SELECT guest, modified_at
+  FROM cart_activity
+  WHERE user_id = ?
+  FOR UPDATE
+  `
+	var (
+		guest      bool
+		modifiedAt mysql.NullTime
+	)
+	if err := tx.QueryRowContext(ctx, sql, userId).Scan(&guest, &modifiedAt); err != nil {
+		if errors.Cause(classify(err)) == ErrNotFound {
+			return 0, false, nil
+		}
+		return 0, false, errors.Wrapf(err, "failed to PurgeCart/Scan\tsql=%s\tuserId=%v", sql, userId)
+	}
+	if !exp.Expired(guest, modifiedAt.Time) {
+		return 0, false, nil
+	}
+
+	sql = `
+  DELETE FROM cart
+  WHERE user_id = ?
+  ORDER BY id
+  LIMIT ?
+  `
+	var args = []interface{}{userId, limit}
+	res, err := tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "failed to PurgeCart/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "failed to PurgeCart/RowsAffected\tsql=%s\targs=%v", sql, args)
+	}
+	if n = int(deleted); n == limit {
+		return n, false, nil
+	}
+
+	sql = `
+  DELETE FROM cart_activity
+  WHERE user_id = ?
+  `
+	if _, err := tx.ExecContext(ctx, sql, userId); err != nil {
+		return n, false, errors.Wrapf(err, "failed to PurgeCart/ExecContext\tsql=%s\tuserId=%v", sql, userId)
+	}
+	return n, true, nil
+}
@@ -0,0 +1,208 @@
+package cart
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// Actions recorded in the cart audit trail.
+const (
+	AuditAdd    = "add"
+	AuditUpdate = "update"
+	AuditRemove = "remove"
+
+	// AuditRestore entries undo the removal of a line.
+	AuditRestore = "restore"
+
+	// AuditClear entries remove every line of a cart, and have no cart item.
+	AuditClear = "clear"
+)
+
+// AuditEntry records a single mutation of a cart.
+type AuditEntry struct {
+	Id int64 `json:"id"`
+
+	// CartId is the user id of the cart's owner.
+	CartId     int    `json:"cartId"`
+	CartItemId int    `json:"cartItemId,omitempty"`
+	ItemId     int    `json:"itemId,omitempty"`
+	Action     string `json:"action"`
+
+	// OldCount and NewCount are the count of the line before and after the
+	// mutation, and are nil if the line did not exist.
+	OldCount *int `json:"oldCount"`
+	NewCount *int `json:"newCount"`
+
+	// Price is the unit price of the line after the mutation, and is nil if
+	// the line does not exist or its price was not recorded.
+	Price *float64 `json:"price,omitempty"`
+
+	// ActorUserId is the user that made the mutation, if known.
+	ActorUserId int `json:"actorUserId,omitempty"`
+
+	// Source is the interface the mutation was made through, such as http.
+	Source     string `json:"source"`
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+	RequestId  string `json:"requestId,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// InsertAudit appends e to the audit trail in the db. e.Id and e.CreatedAt
+// are ignored.
+func InsertAudit(ctx context.Context, db Execer, e AuditEntry) error {
+	var sql = `
+  INSERT INTO cart_audit (
+    cart_id,
+    cart_item_id,
+    item_id,
+    action,
+    old_count,
+    new_count,
+    price,
+    actor_user_id,
+    source,
+    remote_addr,
+    request_id,
+    created_at
+  )
+  VALUES (?, NULLIF(?, 0), NULLIF(?, 0), ?, ?, ?, ?, NULLIF(?, 0), ?, ?, ?, UTC_TIMESTAMP(6))
+  `
+	var args = []interface{}{
+		e.CartId,
+		e.CartItemId,
+		e.ItemId,
+		e.Action,
+		e.OldCount,
+		e.NewCount,
+		e.Price,
+		e.ActorUserId,
+		e.Source,
+		e.RemoteAddr,
+		e.RequestId,
+	}
+	if _, err := db.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to InsertAudit/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// AuditHistory retrieves at most limit audit entries of cartId created in
+// [from, to), oldest first, starting after the entry id after. A zero from
+// or to leaves the range unbounded on that side.
+func AuditHistory(ctx context.Context, db Queryer, cartId int, from, to time.Time, after int64, limit int) ([]AuditEntry, error) {
+	var sql = `
+  SELECT
+    id,
+    cart_id,
+    cart_item_id,
+    item_id,
+    action,
+    old_count,
+    new_count,
+    price,
+    actor_user_id,
+    source,
+    remote_addr,
+    request_id,
+    created_at
+  FROM cart_audit
+  WHERE cart_id = ?
+        AND id > ?
+        AND (? OR created_at >= ?)
+        AND (? OR created_at < ?)
+  ORDER BY id
+  LIMIT ?
+  `
+	var args = []interface{}{cartId, after, from.IsZero(), from.UTC(), to.IsZero(), to.UTC(), limit}
+
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to AuditHistory/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	var entries = make([]AuditEntry, 0)
+	for rows.Next() {
+		var (
+			e           AuditEntry
+			cartItemId  *int
+			itemId      *int
+			actorUserId *int
+			createdAt   mysql.NullTime
+		)
+		if err := rows.Scan(
+			&e.Id,
+			&e.CartId,
+			&cartItemId,
+			&itemId,
+			&e.Action,
+			&e.OldCount,
+			&e.NewCount,
+			&e.Price,
+			&actorUserId,
+			&e.Source,
+			&e.RemoteAddr,
+			&e.RequestId,
+			&createdAt,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to AuditHistory/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		if cartItemId != nil {
+			e.CartItemId = *cartItemId
+		}
+		if itemId != nil {
+			e.ItemId = *itemId
+		}
+		if actorUserId != nil {
+			e.ActorUserId = *actorUserId
+		}
+		e.CreatedAt = createdAt.Time
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to AuditHistory/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return entries, nil
+}
+
+// AuditLine is a line of a cart reconstructed from its audit trail.
+type AuditLine struct {
+	CartItemId int `json:"cartItemId"`
+	ItemId     int `json:"itemId"`
+	Count      int `json:"count"`
+
+	// Price is the unit price of the line when it was last mutated, and is
+	// nil if it was not recorded.
+	Price *float64 `json:"price,omitempty"`
+}
+
+// Reconstruct replays entries, oldest first, and returns the lines of the
+// cart they leave, ordered by cart item id. Only audited mutations are
+// replayed, so lines added before auditing began are absent.
+func Reconstruct(entries []AuditEntry) []AuditLine {
+	var lines = make(map[int]AuditLine)
+	for _, e := range entries {
+		switch e.Action {
+		case AuditAdd, AuditUpdate, AuditRestore:
+			lines[e.CartItemId] = AuditLine{CartItemId: e.CartItemId, ItemId: e.ItemId, Count: *e.NewCount, Price: e.Price}
+		case AuditRemove:
+			delete(lines, e.CartItemId)
+		case AuditClear:
+			lines = make(map[int]AuditLine)
+		}
+	}
+
+	var reconstructed = make([]AuditLine, 0, len(lines))
+	for _, line := range lines {
+		reconstructed = append(reconstructed, line)
+	}
+	sort.Slice(reconstructed, func(i, j int) bool {
+		return reconstructed[i].CartItemId < reconstructed[j].CartItemId
+	})
+	return reconstructed
+}
@@ -0,0 +1,83 @@
+package cart
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func count(n int) *int {
+	return &n
+}
+
+func price(p float64) *float64 {
+	return &p
+}
+
+func TestReconstruct(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Entries  []AuditEntry
+		Expected []AuditLine
+	}{
+		{Name: "empty", Expected: []AuditLine{}},
+		{
+			Name: "add update remove",
+			Entries: []AuditEntry{
+				{CartItemId: 2, ItemId: 5, Action: AuditAdd, NewCount: count(1)},
+				{CartItemId: 1, ItemId: 4, Action: AuditAdd, NewCount: count(2)},
+				{CartItemId: 2, ItemId: 5, Action: AuditUpdate, OldCount: count(1), NewCount: count(3)},
+				{CartItemId: 3, ItemId: 6, Action: AuditAdd, NewCount: count(1)},
+				{CartItemId: 3, ItemId: 6, Action: AuditRemove, OldCount: count(1)},
+			},
+			Expected: []AuditLine{
+				{CartItemId: 1, ItemId: 4, Count: 2},
+				{CartItemId: 2, ItemId: 5, Count: 3},
+			},
+		},
+		{
+			Name: "update changes item",
+			Entries: []AuditEntry{
+				{CartItemId: 1, ItemId: 4, Action: AuditAdd, NewCount: count(2)},
+				{CartItemId: 1, ItemId: 7, Action: AuditUpdate, OldCount: count(2), NewCount: count(2)},
+			},
+			Expected: []AuditLine{{CartItemId: 1, ItemId: 7, Count: 2}},
+		},
+		{
+			Name: "restore",
+			Entries: []AuditEntry{
+				{CartItemId: 1, ItemId: 4, Action: AuditAdd, NewCount: count(2)},
+				{CartItemId: 1, ItemId: 4, Action: AuditRemove, OldCount: count(2)},
+				{CartItemId: 1, ItemId: 4, Action: AuditRestore, NewCount: count(2)},
+			},
+			Expected: []AuditLine{{CartItemId: 1, ItemId: 4, Count: 2}},
+		},
+		{
+			Name: "price of last change",
+			Entries: []AuditEntry{
+				{CartItemId: 1, ItemId: 4, Action: AuditAdd, NewCount: count(1), Price: price(10)},
+				{CartItemId: 1, ItemId: 4, Action: AuditUpdate, OldCount: count(1), NewCount: count(2), Price: price(8.5)},
+				{CartItemId: 2, ItemId: 5, Action: AuditAdd, NewCount: count(1)},
+			},
+			Expected: []AuditLine{
+				{CartItemId: 1, ItemId: 4, Count: 2, Price: price(8.5)},
+				{CartItemId: 2, ItemId: 5, Count: 1},
+			},
+		},
+		{
+			Name: "clear",
+			Entries: []AuditEntry{
+				{CartItemId: 1, ItemId: 4, Action: AuditAdd, NewCount: count(2)},
+				{Action: AuditClear},
+				{CartItemId: 2, ItemId: 5, Action: AuditAdd, NewCount: count(1)},
+			},
+			Expected: []AuditLine{{CartItemId: 2, ItemId: 5, Count: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Expected, Reconstruct(test.Entries))
+		})
+	}
+}
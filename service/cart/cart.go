@@ -3,6 +3,7 @@ package cart
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"github.com/tjper/shoppingcart-server/service/item"
 
@@ -35,14 +36,25 @@ func CartItems(ctx context.Context, db Queryer, userId int) ([]CartItem, error)
     cart.id,
     item.id,
     item.name,
-    item.price,
-    cart.count
+    COALESCE(item_variant.price, ` + item.PriceSQL + `),
+    cart.count,
+    cart.version,
+    cart.baseline_price,
+    item_variant.id,
+    item_variant.sku,
+    item_variant.attributes,
+    item_variant.price,
+    item_variant.stock
   FROM 
     cart
   JOIN
     item
     ON item.id = cart.item_id
+  LEFT JOIN
+    item_variant
+    ON item_variant.id = cart.variant_id
   WHERE cart.user_id = ?
+        AND cart.deleted_at IS NULL
   `
 
 	rows, err := db.QueryContext(ctx, sql, userId)
@@ -51,20 +63,33 @@ func CartItems(ctx context.Context, db Queryer, userId int) ([]CartItem, error)
 	}
 	defer rows.Close()
 
-	var (
-		cartItems = make([]CartItem, 0)
-		cartItem  CartItem
-	)
+	var cartItems = make([]CartItem, 0)
 	for rows.Next() {
+		var (
+			cartItem CartItem
+			baseline *float64
+			variant  variantRow
+		)
 		if err := rows.Scan(
 			&cartItem.Id,
 			&cartItem.Item.Id,
 			&cartItem.Item.Name,
 			&cartItem.Item.Price,
 			&cartItem.Count,
+			&cartItem.Version,
+			&baseline,
+			&variant.Id,
+			&variant.SKU,
+			&variant.Attributes,
+			&variant.Price,
+			&variant.Stock,
 		); err != nil {
 			return nil, errors.Wrapf(err, "failed to CartItems/Scan\tsql=%s\tuserId=%v", sql, userId)
 		}
+		if err := variant.set(&cartItem); err != nil {
+			return nil, errors.Wrapf(err, "failed to CartItems\tuserId=%v", userId)
+		}
+		cartItem.setPriceChange(baseline)
 		cartItems = append(cartItems, cartItem)
 	}
 	if err := rows.Err(); err != nil {
@@ -73,40 +98,137 @@ func CartItems(ctx context.Context, db Queryer, userId int) ([]CartItem, error)
 	return cartItems, nil
 }
 
-// CartItem is a item that belong's to a cart.
+// CountCartItems counts the cart items of userId in the db.
+func CountCartItems(ctx context.Context, db QueryRower, userId int) (int, error) {
+	var sql = `
+  SELECT COUNT(*)
+  FROM cart
+  WHERE user_id = ?
+        AND deleted_at IS NULL
+  `
+	var n int
+	if err := db.QueryRowContext(ctx, sql, userId).Scan(&n); err != nil {
+		return 0, errors.Wrapf(err, "failed to CountCartItems\tsql=%s\tuserId=%v", sql, userId)
+	}
+	return n, nil
+}
+
+// CartItem is a item that belong's to a cart. Item.Price is the price in
+// effect now, which is the price of the variant if the cart item is of a
+// variant of the item; see item.PriceSQL.
 type CartItem struct {
-	Id    int       `json:"id"`
-	Count int       `json:"count"`
-	Item  item.Item `json:"item"`
+	Id      int           `json:"id"`
+	Count   int           `json:"count"`
+	Item    item.Item     `json:"item"`
+	Variant *item.Variant `json:"variant,omitempty"`
+
+	// Version is incremented each time the cart item is modified.
+	Version int `json:"version"`
+
+	// PriceChange describes the change of the price of the cart item since
+	// it was added, or since the price changes of its cart were last
+	// acknowledged, or is nil if its price is unchanged.
+	PriceChange *PriceChange `json:"priceChange,omitempty"`
+}
+
+// PriceChange is the old and new unit price of a cart item.
+type PriceChange struct {
+	Old float64 `json:"old"`
+	New float64 `json:"new"`
+}
+
+// setPriceChange sets the PriceChange of cartItem from its baseline price,
+// which is nil if it was not recorded.
+func (cartItem *CartItem) setPriceChange(baseline *float64) {
+	cartItem.PriceChange = nil
+	if baseline != nil && *baseline != cartItem.Item.Price {
+		cartItem.PriceChange = &PriceChange{Old: *baseline, New: cartItem.Item.Price}
+	}
 }
 
-// FindCartItem retrieves the CartItem with the id passed from the db.
+// variantRow holds the item_variant columns of a cart item, which are NULL
+// if the cart item is not of a variant.
+type variantRow struct {
+	Id         *int
+	SKU        *string
+	Attributes []byte
+	Price      *float64
+	Stock      *int
+}
+
+// set sets the Variant of cartItem from r, if r holds a variant.
+func (r variantRow) set(cartItem *CartItem) error {
+	if r.Id == nil {
+		return nil
+	}
+	var v = item.Variant{
+		Id:     *r.Id,
+		ItemId: cartItem.Item.Id,
+		SKU:    *r.SKU,
+		Price:  r.Price,
+		Stock:  *r.Stock,
+	}
+	if err := json.Unmarshal(r.Attributes, &v.Attributes); err != nil {
+		return errors.Wrapf(err, "failed to variantRow/Unmarshal\tid=%v", v.Id)
+	}
+	cartItem.Variant = &v
+	return nil
+}
+
+// FindCartItem retrieves the CartItem with the id passed from the db. If the
+// cart item does not exist, an error wrapping ErrNotFound is returned.
 func FindCartItem(ctx context.Context, db QueryRower, id int) (*CartItem, error) {
 	var sql = `
   SELECT 
     cart.id,
     item.id,
     item.name,
-    item.price,
-    cart.count
+    COALESCE(item_variant.price, ` + item.PriceSQL + `),
+    cart.count,
+    cart.version,
+    cart.baseline_price,
+    item_variant.id,
+    item_variant.sku,
+    item_variant.attributes,
+    item_variant.price,
+    item_variant.stock
   FROM 
     cart
   JOIN
     item
     ON item.id = cart.item_id
+  LEFT JOIN
+    item_variant
+    ON item_variant.id = cart.variant_id
   WHERE cart.id = ?
+        AND cart.deleted_at IS NULL
   `
 
-	var cartItem CartItem
+	var (
+		cartItem CartItem
+		baseline *float64
+		variant  variantRow
+	)
 	if err := db.QueryRowContext(ctx, sql, id).Scan(
 		&cartItem.Id,
 		&cartItem.Item.Id,
 		&cartItem.Item.Name,
 		&cartItem.Item.Price,
 		&cartItem.Count,
+		&cartItem.Version,
+		&baseline,
+		&variant.Id,
+		&variant.SKU,
+		&variant.Attributes,
+		&variant.Price,
+		&variant.Stock,
 	); err != nil {
-		return nil, errors.Wrapf(err, "failed to FindCartItem\tsql=%s\tid=%v", sql, id)
+		return nil, errors.Wrapf(classify(err), "failed to FindCartItem\tsql=%s\tid=%v", sql, id)
+	}
+	if err := variant.set(&cartItem); err != nil {
+		return nil, errors.Wrapf(err, "failed to FindCartItem\tid=%v", id)
 	}
+	cartItem.setPriceChange(baseline)
 	return &cartItem, nil
 }
 
@@ -117,18 +239,92 @@ type UserCartItemRel struct {
 	ItemId int
 	UserId int
 	Count  int
+
+	// VariantId is the id of the variant of the item, or 0 if the cart item
+	// is not of a variant.
+	VariantId int
+
+	Version int
+}
+
+// FindUserCartItemRel retrieves the UserCartItemRel of the cart item with the
+// id passed from the db. If the cart item does not exist, an error wrapping
+// ErrNotFound is returned.
+func FindUserCartItemRel(ctx context.Context, db QueryRower, id int) (*UserCartItemRel, error) {
+	var sql = `
+  SELECT
+    cart.id,
+    cart.item_id,
+    cart.user_id,
+    cart.count,
+    IFNULL(cart.variant_id, 0),
+    cart.version
+  FROM
+    cart
+  WHERE cart.id = ?
+        AND cart.deleted_at IS NULL
+  `
+
+	var rel UserCartItemRel
+	if err := db.QueryRowContext(ctx, sql, id).Scan(
+		&rel.Id,
+		&rel.ItemId,
+		&rel.UserId,
+		&rel.Count,
+		&rel.VariantId,
+		&rel.Version,
+	); err != nil {
+		return nil, errors.Wrapf(classify(err), "failed to FindUserCartItemRel\tsql=%s\tid=%v", sql, id)
+	}
+	return &rel, nil
+}
+
+// LockUserCartItemRel retrieves the UserCartItemRel of the cart item with the
+// id passed from the db, and locks the cart item until tx ends, so that it
+// may be checked and modified without interleaving modifications. If the
+// cart item does not exist, an error wrapping ErrNotFound is returned.
+func LockUserCartItemRel(ctx context.Context, tx QueryRower, id int) (*UserCartItemRel, error) {
+	var sql = `
+  SELECT
+    cart.id,
+    cart.item_id,
+    cart.user_id,
+    cart.count,
+    IFNULL(cart.variant_id, 0),
+    cart.version
+  FROM
+    cart
+  WHERE cart.id = ?
+        AND cart.deleted_at IS NULL
+  FOR UPDATE
+  `
+
+	var rel UserCartItemRel
+	if err := tx.QueryRowContext(ctx, sql, id).Scan(
+		&rel.Id,
+		&rel.ItemId,
+		&rel.UserId,
+		&rel.Count,
+		&rel.VariantId,
+		&rel.Version,
+	); err != nil {
+		return nil, errors.Wrapf(classify(err), "failed to LockUserCartItemRel\tsql=%s\tid=%v", sql, id)
+	}
+	return &rel, nil
 }
 
 // CreateUserCartItemRel adds a cart item to a cart in the db based on the Create.
-func CreateUserCartItemRel(ctx context.Context, db Execer, rel UserCartItemRel) (int, error) {
+// price is the unit price of the cart item now, which becomes its baseline
+// price; see CartItem.PriceChange.
+func CreateUserCartItemRel(ctx context.Context, db Execer, rel UserCartItemRel, price float64) (int, error) {
 	var sql = `
-  INSERT INTO cart (item_id, user_id, count)
-  VALUES (?, ?, ?)
+  INSERT INTO cart (item_id, user_id, count, variant_id, baseline_price)
+  VALUES (?, ?, ?, NULLIF(?, 0), ?)
   `
-	var args = []interface{}{rel.ItemId, rel.UserId, rel.Count}
+	var args = []interface{}{rel.ItemId, rel.UserId, rel.Count, rel.VariantId, price}
 	res, err := db.ExecContext(ctx, sql, args...)
 	if err != nil {
-		return 0, errors.Wrapf(err, "failed to Insert/ExecContext\tsql=%s\targs=%v", sql, args)
+		return 0, errors.Wrapf(classify(err), "failed to Insert/ExecContext\tsql=%s\targs=%v", sql, args)
 	}
 	id, err := res.LastInsertId()
 	if err != nil {
@@ -137,19 +333,23 @@ func CreateUserCartItemRel(ctx context.Context, db Execer, rel UserCartItemRel)
 	return int(id), nil
 }
 
-// DeleteCartItem deletes the cart item associated with the id passed in the
-// db.
+// DeleteCartItem soft deletes the cart item associated with the id passed in
+// the db. The cart item is excluded from the cart, but may be restored by
+// RestoreCartItem until its tombstone is purged.
 func DeleteCartItem(ctx context.Context, db ExecQueryer, id int) error {
 	if _, err := FindCartItem(ctx, db, id); err != nil {
 		return errors.Wrap(err, "failed to DeleteCartItem")
 	}
 
 	var sql = `
-  DELETE FROM cart
+  UPDATE cart
+  SET deleted_at = UTC_TIMESTAMP(6),
+      version = version + 1
   WHERE id = ?
+        AND deleted_at IS NULL
   `
 	if _, err := db.ExecContext(ctx, sql, id); err != nil {
-		return errors.Wrapf(err, "failed to DeleteCartItem/ExecContext\tsql=%s\tid=%v", sql, id)
+		return errors.Wrapf(classify(err), "failed to DeleteCartItem/ExecContext\tsql=%s\tid=%v", sql, id)
 	}
 	return nil
 }
@@ -165,27 +365,48 @@ func UpdateUserCartItemRel(ctx context.Context, db ExecQueryer, id int, rel User
   UPDATE cart
   SET item_id = ?,
       user_id = ?,
-      count = ?
+      count = ?,
+      variant_id = NULLIF(?, 0),
+      version = version + 1
   WHERE id = ?
   `
-	var args = []interface{}{rel.ItemId, rel.UserId, rel.Count, id}
+	var args = []interface{}{rel.ItemId, rel.UserId, rel.Count, rel.VariantId, id}
 	if _, err := db.ExecContext(ctx, sql, args...); err != nil {
-		return errors.Wrapf(err, "failed to Update/ExecContext\tsql=%s\targs=%v", sql, args)
+		return errors.Wrapf(classify(err), "failed to Update/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// ResetBaselinePrice sets the baseline price of the cart item id in the db to
+// price, its unit price now, so that its price is no longer flagged as
+// changed, and increments its version.
+func ResetBaselinePrice(ctx context.Context, db Execer, id int, price float64) error {
+	var sql = `
+  UPDATE cart
+  SET baseline_price = ?,
+      version = version + 1
+  WHERE id = ?
+  `
+	if _, err := db.ExecContext(ctx, sql, price, id); err != nil {
+		return errors.Wrapf(err, "failed to ResetBaselinePrice/ExecContext\tsql=%s\tid=%v", sql, id)
 	}
 	return nil
 }
 
-// UserCartItemRelExists checks to see if a cart item exists for the userId and
-// itemId pair, and if it does the cart item id is returned. If a cart item
-// does not exist a non-valid (0) id it returned.
-func UserCartItemRelExists(ctx context.Context, db QueryRower, userId, itemId int) (int, error) {
+// UserCartItemRelExists checks to see if a cart item exists for the userId,
+// itemId and variantId triple, and if it does the cart item id is returned.
+// A variantId of 0 matches the cart item of the item without a variant. If
+// a cart item does not exist a non-valid (0) id it returned.
+func UserCartItemRelExists(ctx context.Context, db QueryRower, userId, itemId, variantId int) (int, error) {
 	var SQL = `
     SELECT cart.id
     FROM cart
     WHERE cart.item_id = ?
           AND cart.user_id = ?
+          AND cart.variant_id <=> NULLIF(?, 0)
+          AND cart.deleted_at IS NULL
   `
-	var args = []interface{}{itemId, userId}
+	var args = []interface{}{itemId, userId, variantId}
 	var id int
 	err := db.QueryRowContext(ctx, SQL, args...).Scan(&id)
 	if err == sql.ErrNoRows {
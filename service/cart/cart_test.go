@@ -0,0 +1,58 @@
+package cart
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/item"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPriceChange(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Baseline *float64
+		Expected *PriceChange
+	}{
+		{Name: "unrecorded"},
+		{Name: "unchanged", Baseline: price(2)},
+		{Name: "dropped", Baseline: price(2.5), Expected: &PriceChange{Old: 2.5, New: 2}},
+		{Name: "raised", Baseline: price(1), Expected: &PriceChange{Old: 1, New: 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var cartItem = CartItem{Item: item.Item{Price: 2}, PriceChange: &PriceChange{}}
+			cartItem.setPriceChange(test.Baseline)
+			require.Equal(t, test.Expected, cartItem.PriceChange)
+		})
+	}
+}
+
+func TestExpiryExpired(t *testing.T) {
+	var (
+		now = time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+		exp = Expiry{User: now.Add(-30 * 24 * time.Hour), Guest: now.Add(-7 * 24 * time.Hour)}
+	)
+	tests := []struct {
+		Name       string
+		Expiry     Expiry
+		Guest      bool
+		ModifiedAt time.Time
+		Expected   bool
+	}{
+		{Name: "user expired", Expiry: exp, ModifiedAt: now.Add(-31 * 24 * time.Hour), Expected: true},
+		{Name: "user at expiry", Expiry: exp, ModifiedAt: exp.User, Expected: true},
+		{Name: "user within ttl", Expiry: exp, ModifiedAt: now.Add(-8 * 24 * time.Hour)},
+		{Name: "guest expired", Expiry: exp, Guest: true, ModifiedAt: now.Add(-8 * 24 * time.Hour), Expected: true},
+		{Name: "guest within ttl", Expiry: exp, Guest: true, ModifiedAt: now.Add(-6 * 24 * time.Hour)},
+		{Name: "user purging disabled", Expiry: Expiry{Guest: exp.Guest}, ModifiedAt: now.Add(-365 * 24 * time.Hour)},
+		{Name: "guest purging disabled", Expiry: Expiry{User: exp.User}, Guest: true, ModifiedAt: now.Add(-365 * 24 * time.Hour)},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Expected, test.Expiry.Expired(test.Guest, test.ModifiedAt))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package cart
+
+import (
+	"github.com/tjper/shoppingcart-server/service/dberr"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotFound indicates the requested cart resource does not exist.
+	ErrNotFound = errors.New("cart: not found")
+
+	// ErrConflict indicates the cart resource conflicts with the current state
+	// of the db.
+	ErrConflict = errors.New("cart: conflict")
+
+	// ErrInvalid indicates the cart resource is semantically invalid, such as
+	// a cart item that references an item that does not exist.
+	ErrInvalid = errors.New("cart: invalid")
+
+	// ErrExpired indicates the cart resource can no longer be restored, such
+	// as a cart item deleted before the undo window.
+	ErrExpired = errors.New("cart: expired")
+)
+
+// classify translates db errors into the domain error they represent. Errors
+// that do not represent a domain error are returned unchanged.
+func classify(err error) error {
+	return dberr.Classify(err, ErrNotFound, ErrConflict, ErrInvalid)
+}
@@ -0,0 +1,53 @@
+package cart
+
+import "time"
+
+// Domain event types recorded in the outbox when a cart changes. Events are
+// recorded with the cart owner's user id as their aggregate id.
+const (
+	EventCartItemAdded   = "CartItemAdded"
+	EventCartItemUpdated = "CartItemUpdated"
+	EventCartItemRemoved = "CartItemRemoved"
+	EventCartCleared     = "CartCleared"
+
+	// EventAbandonedCart is recorded once for each period a non-empty cart
+	// is left unmodified beyond the abandonment threshold.
+	EventAbandonedCart = "AbandonedCart"
+)
+
+// CartItemEvent is the payload of CartItemAdded and CartItemUpdated events.
+type CartItemEvent struct {
+	UserId   int      `json:"userId"`
+	CartItem CartItem `json:"cartItem"`
+}
+
+// CartItemRemovedEvent is the payload of CartItemRemoved events.
+type CartItemRemovedEvent struct {
+	UserId     int `json:"userId"`
+	CartItemId int `json:"cartItemId"`
+}
+
+// CartClearedEvent is the payload of CartCleared events.
+type CartClearedEvent struct {
+	UserId int `json:"userId"`
+
+	// Reason is why the cart was cleared, such as ClearedExpired.
+	Reason string `json:"reason"`
+}
+
+// Reasons a cart is cleared.
+const (
+	// ClearedExpired carts were purged after going unmodified beyond the
+	// cart TTL.
+	ClearedExpired = "expired"
+
+	// ClearedUser carts were cleared at the request of a user.
+	ClearedUser = "user"
+)
+
+// AbandonedCartEvent is the payload of AbandonedCart events.
+type AbandonedCartEvent struct {
+	UserId     int        `json:"userId"`
+	ModifiedAt time.Time  `json:"modifiedAt"`
+	CartItems  []CartItem `json:"cartItems"`
+}
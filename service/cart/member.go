@@ -0,0 +1,95 @@
+package cart
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// CartMembers retrieves the user ids of the members of cartId from the db. A
+// cart is identified by the user id of its owner; members are the other
+// users granted access to the cart, such as a household sharing a cart.
+func CartMembers(ctx context.Context, db Queryer, cartId int) ([]int, error) {
+	var sql = `
+  SELECT user_id
+  FROM cart_member
+  WHERE cart_id = ?
+  ORDER BY user_id
+  `
+
+	rows, err := db.QueryContext(ctx, sql, cartId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to CartMembers/QueryContext\tsql=%s\tcartId=%v", sql, cartId)
+	}
+	defer rows.Close()
+
+	var userIds = make([]int, 0)
+	for rows.Next() {
+		var userId int
+		if err := rows.Scan(&userId); err != nil {
+			return nil, errors.Wrapf(err, "failed to CartMembers/Scan\tsql=%s\tcartId=%v", sql, cartId)
+		}
+		userIds = append(userIds, userId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to CartMembers/Err\tsql=%s\tcartId=%v", sql, cartId)
+	}
+	return userIds, nil
+}
+
+// IsCartMember reports whether userId is a member of cartId.
+func IsCartMember(ctx context.Context, db QueryRower, cartId, userId int) (bool, error) {
+	var sql = `
+  SELECT EXISTS (
+    SELECT 1
+    FROM cart_member
+    WHERE cart_id = ?
+          AND user_id = ?
+  )
+  `
+	var (
+		args   = []interface{}{cartId, userId}
+		exists bool
+	)
+	if err := db.QueryRowContext(ctx, sql, args...).Scan(&exists); err != nil {
+		return false, errors.Wrapf(err, "failed to IsCartMember\tsql=%s\targs=%v", sql, args)
+	}
+	return exists, nil
+}
+
+// AddCartMember grants userId access to cartId. Adding an existing member
+// has no effect.
+func AddCartMember(ctx context.Context, db Execer, cartId, userId int) error {
+	var sql = `
+  INSERT IGNORE INTO cart_member (cart_id, user_id)
+  VALUES (?, ?)
+  `
+	var args = []interface{}{cartId, userId}
+	if _, err := db.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(classify(err), "failed to AddCartMember/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// RemoveCartMember revokes userId's access to cartId. If userId is not a
+// member of cartId, an error wrapping ErrNotFound is returned.
+func RemoveCartMember(ctx context.Context, db Execer, cartId, userId int) error {
+	var sql = `
+  DELETE FROM cart_member
+  WHERE cart_id = ?
+        AND user_id = ?
+  `
+	var args = []interface{}{cartId, userId}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return errors.Wrapf(classify(err), "failed to RemoveCartMember/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to RemoveCartMember/RowsAffected\tres=%v", res)
+	}
+	if n == 0 {
+		return errors.Wrapf(ErrNotFound, "failed to RemoveCartMember\targs=%v", args)
+	}
+	return nil
+}
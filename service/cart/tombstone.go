@@ -0,0 +1,168 @@
+package cart
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LockDeletedUserCartItemRel retrieves the UserCartItemRel of the deleted
+// cart item id from the db, and locks it until tx ends, provided it was
+// deleted at or after since, so that it may be checked before it is restored
+// by RestoreCartItem. If the cart item is not deleted, or its tombstone was
+// purged, an error wrapping ErrNotFound is returned; if it was deleted
+// before since, an error wrapping ErrExpired is returned. If the cart
+// already holds a line of the same item and variant, an error wrapping
+// ErrConflict is returned.
+func LockDeletedUserCartItemRel(ctx context.Context, tx QueryRower, id int, since time.Time) (*UserCartItemRel, error) {
+	var sql = `
+  SELECT
+    cart.id,
+    cart.item_id,
+    cart.user_id,
+    cart.count,
+    IFNULL(cart.variant_id, 0),
+    cart.deleted_at >= ?
+  FROM
+    cart
+  WHERE cart.id = ?
+        AND cart.deleted_at IS NOT NULL
+  FOR UPDATE
+  `
+	var (
+		args     = []interface{}{since.UTC(), id}
+		rel      UserCartItemRel
+		undoable bool
+	)
+	if err := tx.QueryRowContext(ctx, sql, args...).Scan(
+		&rel.Id,
+		&rel.ItemId,
+		&rel.UserId,
+		&rel.Count,
+		&rel.VariantId,
+		&undoable,
+	); err != nil {
+		return nil, errors.Wrapf(classify(err), "failed to LockDeletedUserCartItemRel/Scan\tsql=%s\targs=%v", sql, args)
+	}
+	if !undoable {
+		return nil, errors.Wrapf(ErrExpired, "failed to LockDeletedUserCartItemRel\tid=%v\tsince=%v", id, since)
+	}
+
+	existing, err := UserCartItemRelExists(ctx, tx, rel.UserId, rel.ItemId, rel.VariantId)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to LockDeletedUserCartItemRel")
+	}
+	if existing != 0 {
+		return nil, errors.Wrapf(ErrConflict, "failed to LockDeletedUserCartItemRel\tid=%v\texisting=%v", id, existing)
+	}
+	return &rel, nil
+}
+
+// RestoreCartItem undoes the deletion of the cart item id, which must have
+// been locked by LockDeletedUserCartItemRel within tx.
+func RestoreCartItem(ctx context.Context, tx Execer, id int) error {
+	var sql = `
+  UPDATE cart
+  SET deleted_at = NULL,
+      version = version + 1
+  WHERE id = ?
+  `
+	if _, err := tx.ExecContext(ctx, sql, id); err != nil {
+		return errors.Wrapf(classify(err), "failed to RestoreCartItem/ExecContext\tsql=%s\tid=%v", sql, id)
+	}
+	return nil
+}
+
+// LockCartItemIds retrieves the ids of the cart items of userId from the db,
+// and locks them until tx ends, so that the cart may be cleared without
+// interleaving modifications.
+func LockCartItemIds(ctx context.Context, tx Queryer, userId int) ([]int, error) {
+	var sql = `
+  SELECT id
+  FROM cart
+  WHERE user_id = ?
+        AND deleted_at IS NULL
+  ORDER BY id
+  FOR UPDATE
+  `
+	rows, err := tx.QueryContext(ctx, sql, userId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to LockCartItemIds/QueryContext\tsql=%s\tuserId=%v", sql, userId)
+	}
+	defer rows.Close()
+
+	var ids = make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrapf(err, "failed to LockCartItemIds/Scan\tsql=%s\tuserId=%v", sql, userId)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to LockCartItemIds/Err\tsql=%s\tuserId=%v", sql, userId)
+	}
+	return ids, nil
+}
+
+// ClearCart soft deletes every cart item of userId in the db, as
+// DeleteCartItem does, and returns the number deleted.
+func ClearCart(ctx context.Context, db Execer, userId int) (int, error) {
+	var sql = `
+  UPDATE cart
+  SET deleted_at = UTC_TIMESTAMP(6),
+      version = version + 1
+  WHERE user_id = ?
+        AND deleted_at IS NULL
+  `
+	res, err := db.ExecContext(ctx, sql, userId)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to ClearCart/ExecContext\tsql=%s\tuserId=%v", sql, userId)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to ClearCart/RowsAffected\tsql=%s\tuserId=%v", sql, userId)
+	}
+	return int(n), nil
+}
+
+// PurgeUserCartItemTombstones deletes the tombstones of the item itemId and
+// variant variantId, or 0 for none, in the cart of userId, so that a new line
+// of the item replaces any deleted line rather than competing with it on
+// restore.
+func PurgeUserCartItemTombstones(ctx context.Context, db Execer, userId, itemId, variantId int) error {
+	var sql = `
+  DELETE FROM cart
+  WHERE user_id = ?
+        AND item_id = ?
+        AND variant_id <=> NULLIF(?, 0)
+        AND deleted_at IS NOT NULL
+  `
+	var args = []interface{}{userId, itemId, variantId}
+	if _, err := db.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to PurgeUserCartItemTombstones/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// PurgeTombstones deletes at most limit cart items deleted before before,
+// which can no longer be restored, and returns the number deleted.
+func PurgeTombstones(ctx context.Context, db Execer, before time.Time, limit int) (int, error) {
+	var sql = `
+  DELETE FROM cart
+  WHERE deleted_at < ?
+  ORDER BY deleted_at
+  LIMIT ?
+  `
+	var args = []interface{}{before.UTC(), limit}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to PurgeTombstones/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to PurgeTombstones/RowsAffected\tsql=%s\targs=%v", sql, args)
+	}
+	return int(n), nil
+}
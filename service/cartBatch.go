@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/pkg/errors"
+)
+
+// Modes in which a cart batch is applied.
+const (
+	// cartBatchAtomic batches are applied entirely, or not at all if any
+	// operation fails.
+	cartBatchAtomic = "atomic"
+
+	// cartBatchBestEffort batches apply every operation that succeeds, and
+	// report the failure of each that does not.
+	cartBatchBestEffort = "bestEffort"
+)
+
+// maxCartBatchOps is the maximum number of operations in a cart batch, as
+// enforced by the max rule of cartBatchRequest.Operations.
+const maxCartBatchOps = 100
+
+// cartBatchRequest is the body of a cart batch request.
+type cartBatchRequest struct {
+	// Mode defaults to atomic.
+	Mode       string   `json:"mode" validate:"oneof=atomic bestEffort"`
+	Operations []cartOp `json:"operations" validate:"min=1,max=100"`
+}
+
+// cartBatchResult is the outcome of a cart batch operation. cartItem is the
+// resulting cart item, or nil if the operation removed it or failed.
+type cartBatchResult struct {
+	cartItem *cart.CartItem
+	err      error
+}
+
+// applyCartBatch applies ops to cartId in a single transaction, as the cart
+// session operations are, and returns the result of each. In atomic mode
+// the first failure rolls back every operation, and is returned; results
+// are then returned up to and including the failed operation. In best-effort
+// mode each operation is applied in a savepoint, so that a failure rolls
+// back only that operation. The events of the operations applied are
+// published once the transaction commits.
+func (svc *Service) applyCartBatch(ctx context.Context, cartId int, mode string, ops []cartOp) ([]cartBatchResult, error) {
+	var unlock = svc.cartLocks.lock(cartId)
+	defer unlock()
+
+	var (
+		results []cartBatchResult
+		events  []typedCartEvent
+	)
+	if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		results, events = make([]cartBatchResult, 0, len(ops)), nil
+		for _, op := range ops {
+			if mode == cartBatchAtomic {
+				cartItem, opEvents, err := svc.applyCartOpTx(ctx, tx, cartId, op)
+				results = append(results, cartBatchResult{cartItem: cartItem, err: err})
+				if err != nil {
+					return err
+				}
+				events = append(events, opEvents...)
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT cart_batch_op"); err != nil {
+				return errors.Wrap(err, "failed to applyCartBatch/Savepoint")
+			}
+			cartItem, opEvents, err := svc.applyCartOpTx(ctx, tx, cartId, op)
+			results = append(results, cartBatchResult{cartItem: cartItem, err: err})
+			if err != nil {
+				if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT cart_batch_op"); rbErr != nil {
+					return errors.Wrap(rbErr, "failed to applyCartBatch/RollbackToSavepoint")
+				}
+				continue
+			}
+			events = append(events, opEvents...)
+		}
+		return nil
+	}); err != nil {
+		return results, err
+	}
+
+	svc.publishCartEvents(events)
+	return results, nil
+}
+
+// cartBatchHandler returns a handler that applies a batch of operations to
+// the cart of the userId URL param, rendering cart items with render.
+// Handlers must wrap the returned handler in their own closure before
+// describing it, as every handler it returns shares a code pointer.
+//
+// Every result reports the status the operation would have had as a
+// request of its own. Best-effort batches respond 200 OK. Atomic batches
+// respond with the status of the operation that failed, if any; the results
+// of the operations before it report 424 Failed Dependency, as they were
+// rolled back.
+func (svc *Service) cartBatchHandler(render cartRenderer) http.HandlerFunc {
+	type (
+		Result struct {
+			Id       string      `json:"id,omitempty"`
+			Status   int         `json:"status"`
+			CartItem interface{} `json:"cartItem,omitempty"`
+			Error    *ErrorBody  `json:"error,omitempty"`
+		}
+		Response struct {
+			// Applied reports whether any operation was applied.
+			Applied bool     `json:"applied"`
+			Results []Result `json:"results"`
+		}
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = r.Context()
+			req cartBatchRequest
+		)
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if req.Mode == "" {
+			req.Mode = cartBatchAtomic
+		}
+		var v = new(validate.Validator)
+		v.Merge("", validate.Struct(req))
+		for i, op := range req.Operations {
+			v.Merge(fmt.Sprintf("operations[%d]", i), op.validate())
+		}
+		if err := v.Err(); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		results, err := svc.applyCartBatch(ctx, userId, req.Mode, req.Operations)
+		var failed = err != nil && req.Mode == cartBatchAtomic && len(results) > 0 && results[len(results)-1].err != nil
+		if err != nil && !failed {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var (
+			resp   = Response{Results: make([]Result, 0, len(results))}
+			status = http.StatusOK
+		)
+		for i, res := range results {
+			var result = Result{Id: req.Operations[i].Id, Status: http.StatusOK}
+			switch {
+			case res.err != nil:
+				var body = errorBody(res.err)
+				if body.Status >= http.StatusInternalServerError {
+					svc.Zap.Error(res.err.Error())
+				}
+				result.Status, result.Error = body.Status, &body
+			case failed:
+				result.Status = http.StatusFailedDependency
+				result.Error = &ErrorBody{Status: result.Status, Message: "rolled back"}
+			default:
+				resp.Applied = true
+				if res.cartItem != nil {
+					result.CartItem = render.cartItem(*res.cartItem)
+				}
+			}
+			resp.Results = append(resp.Results, result)
+		}
+		if failed {
+			status = resp.Results[len(resp.Results)-1].Status
+		}
+
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostCartBatchInvalid(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Path     string
+		Body     string
+		Expected int
+	}{
+		{Name: "malformed user", Path: "/v1/cart/abc/batch", Body: `{"operations": [{"op": "remove", "cartItemId": 1}]}`, Expected: http.StatusBadRequest},
+		{Name: "malformed body", Path: "/v1/cart/1/batch", Body: `{"operations": `, Expected: http.StatusBadRequest},
+		{Name: "no operations", Path: "/v1/cart/1/batch", Body: `{"operations": []}`, Expected: http.StatusUnprocessableEntity},
+		{Name: "unknown mode", Path: "/v2/cart/1/batch", Body: `{"mode": "some", "operations": [{"op": "remove", "cartItemId": 1}]}`, Expected: http.StatusUnprocessableEntity},
+		{Name: "unknown op", Path: "/v2/cart/1/batch", Body: `{"operations": [{"op": "move", "cartItemId": 1}]}`, Expected: http.StatusUnprocessableEntity},
+		{Name: "add without item", Path: "/v1/cart/1/batch", Body: `{"operations": [{"op": "add", "count": 1}]}`, Expected: http.StatusUnprocessableEntity},
+		{Name: "set without count", Path: "/v2/cart/1/batch", Body: `{"operations": [{"op": "remove", "cartItemId": 1}, {"op": "set", "cartItemId": 1}]}`, Expected: http.StatusUnprocessableEntity},
+		{Name: "too many operations", Path: "/v1/cart/1/batch", Body: `{"operations": [` + strings.Repeat(`{"op": "remove", "cartItemId": 1},`, maxCartBatchOps) + `{"op": "remove", "cartItemId": 1}]}`, Expected: http.StatusUnprocessableEntity},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(http.MethodPost, test.Path, strings.NewReader(test.Body))
+			)
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Expected, w.Code)
+		})
+	}
+}
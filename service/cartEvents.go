@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/outbox"
+	"github.com/tjper/shoppingcart-server/service/pubsub"
+
+	"github.com/pkg/errors"
+)
+
+// Cart event types, reported as the event field of event stream messages.
+const (
+	cartItemAdded   = "cartItem.added"
+	cartItemUpdated = "cartItem.updated"
+	cartItemRemoved = "cartItem.removed"
+
+	// cartSnapshot carries the entire cart, and is sent in place of the
+	// events a reconnecting client missed when they can no longer be
+	// replayed.
+	cartSnapshot = "cart.snapshot"
+)
+
+// cartEvent describes a change to a user's cart. It is the Data of the
+// pubsub.Events published to the user's cart topic.
+type cartEvent struct {
+	UserId int
+
+	// CartItem is the resulting cart item of cartItemAdded and
+	// cartItemUpdated events.
+	CartItem *cart.CartItem
+
+	// CartItemId is the id of the cart item removed by cartItemRemoved
+	// events.
+	CartItemId int
+
+	// CartItems is the entire cart of cartSnapshot events.
+	CartItems []cart.CartItem
+}
+
+// typedCartEvent is a cartEvent and its type.
+type typedCartEvent struct {
+	typ string
+	ev  cartEvent
+}
+
+// cartDomainEvents maps cart event types to the domain event types recorded
+// in the outbox.
+var cartDomainEvents = map[string]string{
+	cartItemAdded:   cart.EventCartItemAdded,
+	cartItemUpdated: cart.EventCartItemUpdated,
+	cartItemRemoved: cart.EventCartItemRemoved,
+}
+
+// touchCart records that the cart of userId was modified within tx. A cart
+// modified by its owner is marked a guest or user cart by whether the owner
+// is a guest; modifications by others, such as cart members and admins,
+// leave it as it was.
+func touchCart(ctx context.Context, tx cart.Execer, userId int) error {
+	var guest *bool
+	if a := actorFrom(ctx); a.UserId == userId {
+		guest = &a.Guest
+	}
+	return cart.Touch(ctx, tx, userId, guest)
+}
+
+// recordCartEvent records the domain event that corresponds to an event of
+// type typ in the outbox, and that the cart of ev.UserId was modified, within
+// tx.
+func recordCartEvent(ctx context.Context, tx *sql.Tx, typ string, ev cartEvent) error {
+	if err := touchCart(ctx, tx, ev.UserId); err != nil {
+		return err
+	}
+
+	var payload interface{}
+	switch typ {
+	case cartItemRemoved:
+		payload = cart.CartItemRemovedEvent{UserId: ev.UserId, CartItemId: ev.CartItemId}
+	default:
+		payload = cart.CartItemEvent{UserId: ev.UserId, CartItem: *ev.CartItem}
+	}
+	return outbox.Insert(ctx, tx, cartDomainEvents[typ], ev.UserId, payload)
+}
+
+// cartTopic returns the pubsub topic the events of userId's cart are
+// published to.
+func cartTopic(userId int) string {
+	return "cart." + strconv.Itoa(userId)
+}
+
+// publishCartEvent publishes an event of type typ to the cart of ev.UserId.
+// If the Service has no Hub, the event is discarded.
+func (svc *Service) publishCartEvent(typ string, ev cartEvent) {
+	if svc.Hub == nil {
+		return
+	}
+	svc.Hub.Publish(cartTopic(ev.UserId), typ, ev)
+}
+
+// publishCartEvents publishes events, in order.
+func (svc *Service) publishCartEvents(events []typedCartEvent) {
+	for _, e := range events {
+		svc.publishCartEvent(e.typ, e.ev)
+	}
+}
+
+// GetCartEventsHandler streams the changes to a user's cart as Server-Sent
+// Events.
+func (svc *Service) GetCartEventsHandler() http.HandlerFunc {
+	type (
+		CartItemEvent struct {
+			UserId   int           `json:"userId"`
+			CartItem cart.CartItem `json:"cartItem"`
+		}
+		CartItemRemovedEvent struct {
+			UserId     int `json:"userId"`
+			CartItemId int `json:"cartItemId"`
+		}
+		CartSnapshotEvent struct {
+			UserId    int             `json:"userId"`
+			CartItems []cart.CartItem `json:"cartItems"`
+		}
+	)
+	var stream = svc.cartEventsHandler(func(typ string, ev cartEvent) interface{} {
+		switch typ {
+		case cartItemRemoved:
+			return CartItemRemovedEvent{UserId: ev.UserId, CartItemId: ev.CartItemId}
+		case cartSnapshot:
+			return CartSnapshotEvent{UserId: ev.UserId, CartItems: ev.CartItems}
+		default:
+			return CartItemEvent{UserId: ev.UserId, CartItem: *ev.CartItem}
+		}
+	})
+	return describe(operation{
+		Id:      "GetCartEventsHandler",
+		Summary: "Stream the changes to a user's cart as Server-Sent Events.",
+	}, func(w http.ResponseWriter, r *http.Request) {
+		stream(w, r)
+	})
+}
+
+// cartEventsHandler returns a handler that streams the events of the cart of
+// the userId URL param as Server-Sent Events, rendering each with render. If
+// the client reconnects with a Last-Event-ID header, the events it missed
+// are replayed; if they are no longer retained, a cartSnapshot event is sent
+// instead. Handlers must wrap the returned handler in their own closure
+// before describing it, as every handler it returns shares a code pointer.
+func (svc *Service) cartEventsHandler(render func(typ string, ev cartEvent) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var lastEventId uint64
+		if header := r.Header.Get("Last-Event-ID"); header != "" {
+			lastEventId, err = strconv.ParseUint(header, 10, 64)
+			if err != nil {
+				svc.HandleError(w, errors.Wrapf(errMalformed, "failed to parse Last-Event-ID\tLast-Event-ID=%s", header))
+				return
+			}
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			svc.HandleError(w, errors.New("failed to cartEventsHandler, streaming unsupported"))
+			return
+		}
+
+		sub, missed, complete := svc.Hub.Subscribe(cartTopic(userId), lastEventId)
+		defer sub.Close()
+
+		if !complete {
+			cartItems, err := cart.CartItems(ctx, svc.DB, userId)
+			if err != nil {
+				svc.HandleError(w, err)
+				return
+			}
+			missed = []pubsub.Event{{
+				Id:   sub.Last,
+				Type: cartSnapshot,
+				Data: cartEvent{UserId: userId, CartItems: cartItems},
+			}}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		var write = func(ev pubsub.Event) error {
+			data, err := json.Marshal(render(ev.Type, ev.Data.(cartEvent)))
+			if err != nil {
+				return errors.Wrapf(err, "failed to cartEventsHandler/Marshal\tid=%v", ev.Id)
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.Id, ev.Type, data); err != nil {
+				return errors.Wrapf(err, "failed to cartEventsHandler/Fprintf\tid=%v", ev.Id)
+			}
+			return nil
+		}
+		for _, ev := range missed {
+			if err := write(ev); err != nil {
+				svc.Zap.Debug(err.Error())
+				return
+			}
+		}
+		flusher.Flush()
+
+		var keepAlive = time.NewTicker(svc.Viper.GetDuration(EnvVarEventsKeepAlive))
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					svc.Zap.Debug(errors.Wrap(err, "failed to cartEventsHandler/Fprint").Error())
+					return
+				}
+			case ev, ok := <-sub.Events():
+				// The hub closes the subscription of clients that fall
+				// behind; they resume from their Last-Event-ID.
+				if !ok {
+					return
+				}
+				if err := write(ev); err != nil {
+					svc.Zap.Debug(err.Error())
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/item"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newEventsTestServer(t *testing.T) (*Service, *httptest.Server) {
+	var svc = newTestService()
+	svc.Viper.Set(EnvVarEventsKeepAlive, "1h")
+	WithHub()(svc)
+	WithRouters(svc.Routes())(svc)
+	return svc, httptest.NewServer(svc.Router)
+}
+
+// readEvent reads a single Server-Sent Event message from r.
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	var msg strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		require.Nil(t, err)
+		if line == "\n" {
+			return msg.String()
+		}
+		msg.WriteString(line)
+	}
+}
+
+func openEvents(t *testing.T, url, lastEventId string) (*http.Response, *bufio.Reader) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.Nil(t, err)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestGetCartEventsHandler(t *testing.T) {
+	var svc, ts = newEventsTestServer(t)
+	defer ts.Close()
+
+	resp, r := openEvents(t, ts.URL+"/v1/cart/1/events", "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var cartItem = cart.CartItem{Id: 5, Count: 2, Item: item.Item{Id: 3, Name: "pen", Price: 1.5}, Version: 1}
+	svc.publishCartEvent(cartItemAdded, cartEvent{UserId: 1, CartItem: &cartItem})
+	svc.publishCartEvent(cartItemAdded, cartEvent{UserId: 2, CartItem: &cartItem})
+	svc.publishCartEvent(cartItemRemoved, cartEvent{UserId: 1, CartItemId: 5})
+
+	require.Equal(t,
+		"id: 1\nevent: cartItem.added\n"+
+			`data: {"userId":1,"cartItem":{"id":5,"count":2,"item":{"id":3,"name":"pen","description":"","price":1.5},"version":1}}`+"\n",
+		readEvent(t, r),
+	)
+	require.Equal(t,
+		"id: 3\nevent: cartItem.removed\n"+`data: {"userId":1,"cartItemId":5}`+"\n",
+		readEvent(t, r),
+	)
+}
+
+func TestGetCartEventsHandlerLastEventId(t *testing.T) {
+	var svc, ts = newEventsTestServer(t)
+	defer ts.Close()
+
+	for id := 1; id <= 3; id++ {
+		svc.publishCartEvent(cartItemRemoved, cartEvent{UserId: 1, CartItemId: id})
+	}
+
+	resp, r := openEvents(t, ts.URL+"/cart/1/events", "1")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for id := 2; id <= 3; id++ {
+		require.Equal(t,
+			"id: "+strconv.Itoa(id)+"\nevent: cartItem.removed\n"+
+				`data: {"userId":1,"cartItemId":`+strconv.Itoa(id)+"}\n",
+			readEvent(t, r),
+		)
+	}
+
+	// Events published after the client reconnected follow the replay.
+	svc.publishCartEvent(cartItemRemoved, cartEvent{UserId: 1, CartItemId: 4})
+	require.Equal(t,
+		"id: 4\nevent: cartItem.removed\n"+`data: {"userId":1,"cartItemId":4}`+"\n",
+		readEvent(t, r),
+	)
+}
+
+func TestGetCartEventsHandlerMalformedLastEventId(t *testing.T) {
+	var _, ts = newEventsTestServer(t)
+	defer ts.Close()
+
+	resp, _ := openEvents(t, ts.URL+"/cart/1/events", "abc")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetCartEventsV2Handler(t *testing.T) {
+	var svc, ts = newEventsTestServer(t)
+	defer ts.Close()
+
+	resp, r := openEvents(t, ts.URL+"/v2/cart/1/events", "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var cartItem = cart.CartItem{Id: 5, Count: 2, Item: item.Item{Id: 3, Name: "pen", Price: 1.5}, Version: 1}
+	svc.publishCartEvent(cartItemUpdated, cartEvent{UserId: 1, CartItem: &cartItem})
+
+	require.Equal(t,
+		"id: 1\nevent: cartItem.updated\n"+
+			`data: {"userId":1,"cartItem":{"id":5,"count":2,"item":{"id":3,"name":"pen","description":"",`+
+			`"price":{"amount":150,"currency":"USD"}},"total":{"amount":300,"currency":"USD"},"version":1}}`+"\n",
+		readEvent(t, r),
+	)
+}
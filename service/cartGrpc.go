@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/pb"
+	"github.com/tjper/shoppingcart-server/service/validate"
+)
+
+// cartServer implements pb.CartServiceServer over the same data layer as
+// the cart REST endpoints.
+type cartServer struct {
+	svc *Service
+}
+
+// pbCartItem converts ci to its protobuf representation.
+func (svc *Service) pbCartItem(ci cart.CartItem) *pb.CartItem {
+	var v2 = svc.newCartItemV2(ci)
+	var c = &pb.CartItem{
+		Id:    int64(v2.Id),
+		Count: int64(v2.Count),
+		Item:  svc.pbItem(ci.Item),
+		Total: &pb.Money{Amount: v2.Total.Amount, Currency: v2.Total.Currency},
+	}
+	if ci.Variant != nil {
+		c.VariantId = int64(ci.Variant.Id)
+	}
+	return c
+}
+
+// pbCart converts userId's cartItems to their protobuf representation.
+func (svc *Service) pbCart(userId int, cartItems []cart.CartItem) *pb.Cart {
+	var v2 = svc.newCartV2(userId, cartItems)
+	var c = &pb.Cart{
+		UserId:    int64(v2.UserId),
+		CartItems: make([]*pb.CartItem, 0, len(cartItems)),
+		ItemCount: int64(v2.ItemCount),
+		Subtotal:  &pb.Money{Amount: v2.Subtotal.Amount, Currency: v2.Subtotal.Currency},
+	}
+	for _, ci := range cartItems {
+		c.CartItems = append(c.CartItems, svc.pbCartItem(ci))
+	}
+	return c
+}
+
+// AddCartItem adds an item to a user's cart, summing counts if the item is
+// already in the cart.
+func (s *cartServer) AddCartItem(ctx context.Context, req *pb.AddCartItemRequest) (*pb.AddCartItemResponse, error) {
+	var v validate.Validator
+	v.Check("itemId", validate.Required(req.ItemId))
+	v.Check("userId", validate.Required(req.UserId))
+	v.Check("count", validate.IntMin(int(req.Count), 1))
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	cartItem, err := s.svc.addCartItem(ctx, cart.UserCartItemRel{
+		ItemId:    int(req.ItemId),
+		UserId:    int(req.UserId),
+		Count:     int(req.Count),
+		VariantId: int(req.VariantId),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AddCartItemResponse{CartItem: s.svc.pbCartItem(*cartItem)}, nil
+}
+
+// GetCart retrieves a user's cart.
+func (s *cartServer) GetCart(ctx context.Context, req *pb.GetCartRequest) (*pb.GetCartResponse, error) {
+	var v validate.Validator
+	v.Check("userId", validate.Required(req.UserId))
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	cartItems, err := cart.CartItems(ctx, s.svc.DB, int(req.UserId))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetCartResponse{Cart: s.svc.pbCart(int(req.UserId), cartItems)}, nil
+}
+
+// PutCartItem updates a cart item.
+func (s *cartServer) PutCartItem(ctx context.Context, req *pb.PutCartItemRequest) (*pb.PutCartItemResponse, error) {
+	var v validate.Validator
+	v.Check("id", validate.Required(req.Id))
+	v.Check("itemId", validate.Required(req.ItemId))
+	v.Check("userId", validate.Required(req.UserId))
+	v.Check("count", validate.IntMin(int(req.Count), 1))
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	cartItem, err := s.svc.putCartItem(ctx, int(req.Id), cart.UserCartItemRel{
+		ItemId:    int(req.ItemId),
+		UserId:    int(req.UserId),
+		Count:     int(req.Count),
+		VariantId: int(req.VariantId),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.PutCartItemResponse{CartItem: s.svc.pbCartItem(*cartItem)}, nil
+}
+
+// DeleteCartItem deletes a cart item.
+func (s *cartServer) DeleteCartItem(ctx context.Context, req *pb.DeleteCartItemRequest) (*pb.DeleteCartItemResponse, error) {
+	var v validate.Validator
+	v.Check("id", validate.Required(req.Id))
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.svc.deleteCartItem(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteCartItemResponse{}, nil
+}
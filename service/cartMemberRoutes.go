@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+// CartMemberRoutes defines the REST endpoints that manage which users share
+// a cart. Only a cart's owner may manage its members.
+func (svc *Service) CartMemberRoutes(r chi.Router) {
+	r.Get("/cart/{cartId}/members", svc.GetCartMembersHandler())
+	r.Put("/cart/{cartId}/members/{userId}", svc.PutCartMemberHandler())
+	r.Delete("/cart/{cartId}/members/{userId}", svc.DeleteCartMemberHandler())
+}
+
+// cartOwner returns the cartId URL param of r, and an error wrapping
+// errForbidden if the user making r does not own said cart.
+func cartOwner(r *http.Request) (int, error) {
+	cartId, err := urlParamId(r, "cartId")
+	if err != nil {
+		return 0, err
+	}
+	userId, err := requestUserId(r)
+	if err != nil {
+		return 0, err
+	}
+	if userId != cartId {
+		return 0, errors.Wrapf(errForbidden, "failed to cartOwner\tcartId=%v\tuserId=%v", cartId, userId)
+	}
+	return cartId, nil
+}
+
+// GetCartMembersHandler retrieves the members of a cart.
+func (svc *Service) GetCartMembersHandler() http.HandlerFunc {
+	type Response struct {
+		UserIds []int `json:"userIds"`
+	}
+	return describe(operation{
+		Id:       "GetCartMembersHandler",
+		Summary:  "Retrieve the users a cart is shared with.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		cartId, err := cartOwner(r)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		userIds, err := cart.CartMembers(ctx, svc.DB, cartId)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			UserIds: userIds,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// PutCartMemberHandler shares a cart with a user.
+func (svc *Service) PutCartMemberHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "PutCartMemberHandler",
+		Summary: "Share a cart with a user.",
+		Status:  http.StatusNoContent,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		cartId, err := cartOwner(r)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if userId == cartId {
+			svc.HandleError(w, errors.Wrap(errInvalid, "failed to PutCartMember, owner cannot be a member"))
+			return
+		}
+
+		if err := cart.AddCartMember(ctx, svc.DB, cartId, userId); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// DeleteCartMemberHandler stops sharing a cart with a user.
+func (svc *Service) DeleteCartMemberHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "DeleteCartMemberHandler",
+		Summary: "Stop sharing a cart with a user.",
+		Status:  http.StatusNoContent,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		cartId, err := cartOwner(r)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		if err := cart.RemoveCartMember(ctx, svc.DB, cartId, userId); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
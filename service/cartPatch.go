@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/outbox"
+	"github.com/tjper/shoppingcart-server/service/validate"
+)
+
+// cartItemPatch is a JSON merge patch (RFC 7396) of a cart item's itemId,
+// variantId, userId and count. Every field of a cart item but variantId is
+// required, so the patch may not remove them with null.
+type cartItemPatch map[string]json.RawMessage
+
+// apply applies p to rel, and validates the members of p and the result. A
+// count of 0 is valid, and requests the cart item be removed.
+func (p cartItemPatch) apply(rel *cart.UserCartItemRel) error {
+	var keys = make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var v = new(validate.Validator)
+	for _, key := range keys {
+		var dst *int
+		switch key {
+		case "itemId":
+			dst = &rel.ItemId
+		case "userId":
+			dst = &rel.UserId
+		case "count":
+			dst = &rel.Count
+		case "variantId":
+			dst = &rel.VariantId
+			if string(p[key]) == "null" {
+				*dst = 0
+				continue
+			}
+		default:
+			v.Check(key, fail("is not a cart item field"))
+			continue
+		}
+		if string(p[key]) == "null" {
+			v.Check(key, fail("may not be removed"))
+			continue
+		}
+		if err := json.Unmarshal(p[key], dst); err != nil {
+			v.Check(key, fail("must be an integer"))
+		}
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	v.Check("itemId", validate.Required(rel.ItemId))
+	v.Check("userId", validate.Required(rel.UserId))
+	v.Check("count", validate.IntMin(rel.Count, 0))
+	return v.Err()
+}
+
+// fail returns a validate.Check that always fails with msg.
+func fail(msg string) validate.Check {
+	return func() string { return msg }
+}
+
+// patchCartItem applies patch to the cart item id and returns the resulting
+// cart item, provided the cart item matches the If-Match header carried by
+// ctx, if any. If the patch sets the count to 0, the cart item is removed
+// and nil is returned. The change is published to the event streams of the
+// carts affected.
+func (svc *Service) patchCartItem(ctx context.Context, id int, patch cartItemPatch) (*cart.CartItem, error) {
+	var (
+		cartItem *cart.CartItem
+		events   []typedCartEvent
+	)
+	if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		rel, err := cart.LockUserCartItemRel(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(ctx, cartItemETag(rel.Version)); err != nil {
+			return err
+		}
+		if err := patch.apply(rel); err != nil {
+			return err
+		}
+		if rel.Count == 0 {
+			events, err = deleteCartItemTx(ctx, tx, id)
+			return err
+		}
+		cartItem, events, err = svc.putCartItemTx(ctx, tx, id, *rel)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	svc.publishCartEvents(events)
+	return cartItem, nil
+}
+
+// clearCart removes every cart item of userId, and returns the number
+// removed. The cart items are soft deleted, so each may be restored within
+// the undo window. A CartCleared event is recorded, and the removal of each
+// cart item is published to the cart's event stream, unless the cart was
+// already empty.
+func (svc *Service) clearCart(ctx context.Context, userId int) (int, error) {
+	var ids []int
+	if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		ids, err = cart.LockCartItemIds(ctx, tx, userId)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		if _, err := cart.ClearCart(ctx, tx, userId); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, cart.AuditEntry{CartId: userId, Action: cart.AuditClear}); err != nil {
+			return err
+		}
+		if err := touchCart(ctx, tx, userId); err != nil {
+			return err
+		}
+		return outbox.Insert(ctx, tx, cart.EventCartCleared, userId, cart.CartClearedEvent{
+			UserId: userId,
+			Reason: cart.ClearedUser,
+		})
+	}); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		svc.publishCartEvent(cartItemRemoved, cartEvent{UserId: userId, CartItemId: id})
+	}
+	return len(ids), nil
+}
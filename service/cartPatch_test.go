@@ -0,0 +1,56 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCartItemPatchApply(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Patch    string
+		Expected cart.UserCartItemRel
+		Fields   []string
+	}{
+		{Name: "empty", Patch: `{}`, Expected: cart.UserCartItemRel{Id: 1, ItemId: 2, UserId: 3, Count: 4}},
+		{Name: "count", Patch: `{"count": 7}`, Expected: cart.UserCartItemRel{Id: 1, ItemId: 2, UserId: 3, Count: 7}},
+		{Name: "count zero", Patch: `{"count": 0}`, Expected: cart.UserCartItemRel{Id: 1, ItemId: 2, UserId: 3, Count: 0}},
+		{Name: "item and user", Patch: `{"itemId": 5, "userId": 6}`, Expected: cart.UserCartItemRel{Id: 1, ItemId: 5, UserId: 6, Count: 4}},
+		{Name: "variant", Patch: `{"variantId": 8}`, Expected: cart.UserCartItemRel{Id: 1, ItemId: 2, UserId: 3, Count: 4, VariantId: 8}},
+		{Name: "variant null", Patch: `{"variantId": null}`, Expected: cart.UserCartItemRel{Id: 1, ItemId: 2, UserId: 3, Count: 4}},
+		{Name: "null", Patch: `{"count": null}`, Fields: []string{"count"}},
+		{Name: "not an integer", Patch: `{"itemId": "5", "count": 1.5}`, Fields: []string{"count", "itemId"}},
+		{Name: "unknown member", Patch: `{"id": 9}`, Fields: []string{"id"}},
+		{Name: "negative count", Patch: `{"count": -1}`, Fields: []string{"count"}},
+		{Name: "zero item", Patch: `{"itemId": 0}`, Fields: []string{"itemId"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var patch cartItemPatch
+			require.Nil(t, json.Unmarshal([]byte(test.Patch), &patch))
+
+			var rel = cart.UserCartItemRel{Id: 1, ItemId: 2, UserId: 3, Count: 4}
+			err := patch.apply(&rel)
+			if test.Fields == nil {
+				require.Nil(t, err)
+				require.Equal(t, test.Expected, rel)
+				return
+			}
+
+			errs, ok := errors.Cause(err).(validate.Errors)
+			require.True(t, ok)
+			var fields []string
+			for _, fe := range errs {
+				fields = append(fields, fe.Field)
+			}
+			require.Equal(t, test.Fields, fields)
+		})
+	}
+}
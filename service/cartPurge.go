@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/outbox"
+
+	"go.uber.org/zap"
+)
+
+// PurgeExpiredCarts deletes the lines of every user cart that has not been
+// modified for ttl, and of every guest cart that has not been modified for
+// guestTTL, and returns the number of carts and lines purged. A TTL of 0
+// purges no carts of its kind. Lines are deleted in transactions of at most
+// the batch size specified in viper, so that carts are locked briefly; a
+// cart modified while it is purged keeps its remaining lines. A CartCleared
+// event is recorded for each non-empty cart purged entirely.
+func (svc *Service) PurgeExpiredCarts(ctx context.Context, ttl, guestTTL time.Duration) (carts int, lines int, err error) {
+	var (
+		now       = time.Now()
+		exp       cart.Expiry
+		batchSize = svc.Viper.GetInt(EnvVarCartPurgeBatchSize)
+	)
+	if ttl > 0 {
+		exp.User = now.Add(-ttl)
+	}
+	if guestTTL > 0 {
+		exp.Guest = now.Add(-guestTTL)
+	}
+	for {
+		userIds, err := cart.ExpiredCarts(ctx, svc.DB, exp, batchSize)
+		if err != nil {
+			return carts, lines, err
+		}
+
+		for _, userId := range userIds {
+			n, err := svc.purgeCart(ctx, userId, exp, batchSize)
+			lines += n
+			if err != nil {
+				return carts, lines, err
+			}
+			if n > 0 {
+				carts++
+			}
+		}
+
+		if len(userIds) < batchSize {
+			return carts, lines, nil
+		}
+	}
+}
+
+// purgeCart deletes the lines of the cart of userId in batches of batchSize,
+// provided the cart remains expired per exp, and returns the number of lines
+// deleted.
+func (svc *Service) purgeCart(ctx context.Context, userId int, exp cart.Expiry, batchSize int) (int, error) {
+	var purged int
+	for {
+		var n int
+		if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+			var (
+				emptied bool
+				err     error
+			)
+			n, emptied, err = cart.PurgeCart(ctx, tx, userId, exp, batchSize)
+			if err != nil || !emptied || purged+n == 0 {
+				return err
+			}
+			if err := recordAudit(ctx, tx, cart.AuditEntry{CartId: userId, Action: cart.AuditClear}); err != nil {
+				return err
+			}
+			return outbox.Insert(ctx, tx, cart.EventCartCleared, userId, cart.CartClearedEvent{
+				UserId: userId,
+				Reason: cart.ClearedExpired,
+			})
+		}); err != nil {
+			return purged, err
+		}
+		purged += n
+		if n < batchSize {
+			return purged, nil
+		}
+	}
+}
+
+// PurgeTombstones deletes the cart items deleted more than window ago, which
+// can no longer be restored, in transactions of at most the batch size
+// specified in viper, and returns the number deleted.
+func (svc *Service) PurgeTombstones(ctx context.Context, window time.Duration) (int, error) {
+	var (
+		before    = time.Now().Add(-window)
+		batchSize = svc.Viper.GetInt(EnvVarCartPurgeBatchSize)
+		purged    int
+	)
+	for {
+		n, err := cart.PurgeTombstones(ctx, svc.DB, before, batchSize)
+		purged += n
+		if err != nil || n < batchSize {
+			return purged, err
+		}
+	}
+}
+
+// purgeCarts purges expired carts, the tombstones of cart items deleted
+// before the undo window, expired idempotency keys and delivered outbox
+// events past their retention, at the interval specified in viper until ctx
+// is done, and counts what was purged in svc.Metrics. Purging carts is
+// disabled if both cart TTLs are 0, and purging the outbox if its retention
+// is 0.
+func (svc *Service) purgeCarts(ctx context.Context) {
+	var (
+		ttl       = svc.Viper.GetDuration(EnvVarCartTTL)
+		guestTTL  = svc.Viper.GetDuration(EnvVarGuestCartTTL)
+		window    = svc.Viper.GetDuration(EnvVarCartUndoWindow)
+		retention = svc.Viper.GetDuration(EnvVarOutboxRetention)
+		ticker    = time.NewTicker(svc.Viper.GetDuration(EnvVarCartPurgeInterval))
+	)
+	defer ticker.Stop()
+
+	for {
+		if ttl > 0 || guestTTL > 0 {
+			var start = time.Now()
+			carts, lines, err := svc.PurgeExpiredCarts(ctx, ttl, guestTTL)
+			if err != nil {
+				svc.Zap.Error(err.Error())
+			}
+			svc.Metrics.Add(metricCartsPurged, int64(carts))
+			svc.Metrics.Add(metricCartLinesPurged, int64(lines))
+			svc.Zap.Info("purged expired carts",
+				zap.Int("carts", carts),
+				zap.Int("lines", lines),
+				zap.Duration("ttl", ttl),
+				zap.Duration("guestTTL", guestTTL),
+				zap.Duration("elapsed", time.Since(start)),
+			)
+		}
+
+		var start = time.Now()
+		tombstones, err := svc.PurgeTombstones(ctx, window)
+		if err != nil {
+			svc.Zap.Error(err.Error())
+		}
+		svc.Metrics.Add(metricTombstonesPurged, int64(tombstones))
+		svc.Zap.Info("purged cart item tombstones",
+			zap.Int("tombstones", tombstones),
+			zap.Duration("window", window),
+			zap.Duration("elapsed", time.Since(start)),
+		)
+
+		start = time.Now()
+		keys, err := svc.PurgeIdempotencyKeys(ctx)
+		if err != nil {
+			svc.Zap.Error(err.Error())
+		}
+		svc.Metrics.Add(metricIdempotencyKeysPurged, int64(keys))
+		svc.Zap.Info("purged expired idempotency keys",
+			zap.Int("keys", keys),
+			zap.Duration("elapsed", time.Since(start)),
+		)
+
+		if retention > 0 {
+			start = time.Now()
+			events, err := svc.PurgeOutbox(ctx, retention)
+			if err != nil {
+				svc.Zap.Error(err.Error())
+			}
+			svc.Metrics.Add(metricOutboxEventsPurged, int64(events))
+			svc.Zap.Info("purged delivered outbox events",
+				zap.Int("events", events),
+				zap.Duration("retention", retention),
+				zap.Duration("elapsed", time.Since(start)),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,104 +1,94 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/validate"
 
 	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
 )
 
 // CartRoutes defines the cart resources REST endpoints.
 func (svc *Service) CartRoutes(r chi.Router) {
 	// r.Use(defaultMiddleware()...)
-	r.Post("/cart/item", svc.AddCartItemHandler())
+	r.With(svc.idempotent).Post("/cart/item", svc.AddCartItemHandler())
 	r.Get("/cart/{userId}", svc.GetCartHandler())
-	r.Put("/cart/item/{id}", svc.PutCartItemHandler())
-	r.Delete("/cart/item/{id}", svc.DeleteCartItemHandler())
+	r.Get("/cart/{userId}/events", svc.GetCartEventsHandler())
+	r.Get("/cart/{cartId}/ws", svc.GetCartSessionHandler())
+	r.With(svc.idempotent).Put("/cart/item/{id}", svc.PutCartItemHandler())
+	r.With(svc.idempotent).Patch("/cart/item/{id}", svc.PatchCartItemHandler())
+	r.With(svc.idempotent).Delete("/cart/item/{id}", svc.DeleteCartItemHandler())
+	r.With(svc.idempotent).Delete("/cart/{userId}", svc.ClearCartHandler())
+	r.With(svc.idempotent).Post("/cart/item/{id}/restore", svc.RestoreCartItemHandler())
+	r.With(svc.idempotent).Post("/cart/{userId}/batch", svc.PostCartBatchHandler())
+	r.With(svc.idempotent).Post("/cart/{userId}/price-changes/acknowledge", svc.AcknowledgePriceChangesHandler())
 }
 
 // PostCreatItemHandler creates a CartItem resource on the service.
 func (svc *Service) AddCartItemHandler() http.HandlerFunc {
 	type (
 		Request struct {
-			ItemId int `json:"itemId"`
-			UserId int `json:"userId"`
-			Count  int `json:"count"`
+			ItemId int `json:"itemId" validate:"required"`
+			UserId int `json:"userId" validate:"required"`
+			Count  int `json:"count" validate:"min=1"`
+
+			// VariantId is required if the item has variants.
+			VariantId int `json:"variantId,omitempty"`
 		}
 		Response struct {
 			CartItem cart.CartItem `json:"cartItem"`
 		}
 	)
-	return func(w http.ResponseWriter, r *http.Request) {
+	return describe(operation{
+		Id:       "AddCartItemHandler",
+		Summary:  "Add an item to a user's cart, summing counts if the item is already in the cart.",
+		Request:  Request{},
+		Response: Response{},
+		Status:   http.StatusCreated,
+	}, func(w http.ResponseWriter, r *http.Request) {
 		var (
 			ctx = r.Context()
 			req Request
 		)
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
 			return
 		}
 
-		v := new(validate)
-		v.check("ItemId", intNotEmpty(req.ItemId))
-		v.check("UserId", intNotEmpty(req.UserId))
-		v.check("Count", intGreaterThan(req.Count, 0))
-		if err := v.Err; err != nil {
-			svc.Error(w, err, http.StatusBadRequest)
+		if err := validate.Struct(req); err != nil {
+			svc.HandleError(w, err)
 			return
 		}
 
-		id, err := cart.UserCartItemRelExists(ctx, svc.DB, req.UserId, req.ItemId)
-		if err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
-			return
-		}
-		if id != 0 {
-			cartItem, err := cart.FindCartItem(ctx, svc.DB, id)
-			if err != nil {
-				svc.Error(w, err, http.StatusInternalServerError)
-				return
-			}
-			rel := cart.UserCartItemRel{
-				ItemId: req.ItemId,
-				UserId: req.UserId,
-				Count:  cartItem.Count + req.Count,
-			}
-			if err := cart.UpdateUserCartItemRel(ctx, svc.DB, id, rel); err != nil {
-				svc.Error(w, err, http.StatusInternalServerError)
-				return
-			}
-
-		} else {
-			rel := cart.UserCartItemRel{
-				ItemId: req.ItemId,
-				UserId: req.UserId,
-				Count:  req.Count,
-			}
-			id, err = cart.CreateUserCartItemRel(ctx, svc.DB, rel)
-			if err != nil {
-				svc.Error(w, err, http.StatusInternalServerError)
-				return
-			}
-		}
-
-		cartItem, err := cart.FindCartItem(ctx, svc.DB, id)
+		cartItem, err := svc.addCartItem(ctx, cart.UserCartItemRel{
+			ItemId:    req.ItemId,
+			UserId:    req.UserId,
+			Count:     req.Count,
+			VariantId: req.VariantId,
+		})
 		if err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+			svc.HandleError(w, err)
 			return
 		}
 
+		w.Header().Set("ETag", cartItemETag(cartItem.Version))
 		w.WriteHeader(http.StatusCreated)
 		var resp = Response{
 			CartItem: *cartItem,
 		}
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+			svc.HandleError(w, err)
 		}
 
-	}
+	})
 }
 
 // GetCartHandler retrieves a user's cart from the service.
@@ -107,17 +97,39 @@ func (svc *Service) GetCartHandler() http.HandlerFunc {
 		CartItems []cart.CartItem `json:"cartItems"`
 	}
 
-	return func(w http.ResponseWriter, r *http.Request) {
+	return describe(operation{
+		Id:       "GetCartHandler",
+		Summary:  "Retrieve a user's cart. Lines whose price changed since they were added, or since the cart's price changes were last acknowledged, carry a priceChange with the old and new unit prices. The ETag header identifies the cart's version and prices; 304 Not Modified is returned if it matches If-None-Match.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
 		var ctx = r.Context()
 
-		userId, err := strconv.Atoi(chi.URLParam(r, "userId"))
-		if err != nil || userId == 0 {
-			svc.Error(w, err, http.StatusBadRequest)
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
 		}
 
+		// The version is read first so that a concurrent modification yields
+		// an ETag older than the cart items, never newer.
+		version, err := cart.CartVersion(ctx, svc.DB, userId)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
 		cartItems, err := cart.CartItems(ctx, svc.DB, userId)
 		if err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+			svc.HandleError(w, err)
+			return
+		}
+		var etag = cartETag(version, cartItems)
+		w.Header().Set("ETag", etag)
+		if notModified(r, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if err := svc.withCartMedia(ctx, cartItems); err != nil {
+			svc.HandleError(w, err)
 			return
 		}
 
@@ -125,84 +137,649 @@ func (svc *Service) GetCartHandler() http.HandlerFunc {
 			CartItems: cartItems,
 		}
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+			svc.HandleError(w, err)
 		}
-	}
+	})
 }
 
 // PutCartItemHandler updates a cart item in the service.
 func (svc *Service) PutCartItemHandler() http.HandlerFunc {
 	type (
 		Request struct {
-			ItemId int `json:"itemId"`
-			UserId int `json:"userId"`
-			Count  int `json:"count"`
+			ItemId int `json:"itemId" validate:"required"`
+			UserId int `json:"userId" validate:"required"`
+			Count  int `json:"count" validate:"min=1"`
+
+			// VariantId is required if the item has variants.
+			VariantId int `json:"variantId,omitempty"`
 		}
 		Response struct {
 			CartItem cart.CartItem `json:"cartItem"`
 		}
 	)
-	return func(w http.ResponseWriter, r *http.Request) {
+	return describe(operation{
+		Id:       "PutCartItemHandler",
+		Summary:  "Update a cart item, provided it matches the If-Match header, if any; otherwise 412 Precondition Failed is returned.",
+		Request:  Request{},
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
 		var (
-			ctx = r.Context()
+			ctx = withIfMatch(r.Context(), r)
 			req Request
 		)
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
 			return
 		}
 
-		v := new(validate)
-		v.check("ItemId", intNotEmpty(req.ItemId))
-		v.check("UserId", intNotEmpty(req.UserId))
-		v.check("Count", intGreaterThan(req.Count, 0))
-		if err := v.Err; err != nil {
-			svc.Error(w, err, http.StatusBadRequest)
+		if err := validate.Struct(req); err != nil {
+			svc.HandleError(w, err)
 			return
 		}
 
-		id, err := strconv.Atoi(chi.URLParam(r, "id"))
-		if err != nil || id == 0 {
-			svc.Error(w, err, http.StatusBadRequest)
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
 		}
 
-		rel := cart.UserCartItemRel{
-			ItemId: req.ItemId,
-			UserId: req.UserId,
-			Count:  req.Count,
+		cartItem, err := svc.putCartItem(ctx, id, cart.UserCartItemRel{
+			ItemId:    req.ItemId,
+			UserId:    req.UserId,
+			Count:     req.Count,
+			VariantId: req.VariantId,
+		})
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", cartItemETag(cartItem.Version))
+		var resp = Response{
+			CartItem: *cartItem,
 		}
-		if err := cart.UpdateUserCartItemRel(ctx, svc.DB, id, rel); err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+
+	})
+}
+
+// PatchCartItemHandler applies a JSON merge patch to a cart item.
+func (svc *Service) PatchCartItemHandler() http.HandlerFunc {
+	type (
+		// Request documents the members of the patch, each optional.
+		Request struct {
+			ItemId    *int `json:"itemId"`
+			VariantId *int `json:"variantId"`
+			UserId    *int `json:"userId"`
+			Count     *int `json:"count"`
+		}
+		Response struct {
+			CartItem cart.CartItem `json:"cartItem"`
+		}
+	)
+	return describe(operation{
+		Id:       "PatchCartItemHandler",
+		Summary:  "Update the given fields of a cart item with a JSON merge patch (RFC 7396), provided it matches the If-Match header, if any. Setting count to 0 removes the cart item, and 204 No Content is returned.",
+		Request:  Request{},
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx   = withIfMatch(r.Context(), r)
+			patch cartItemPatch
+		)
+		if err := decode(r, &patch); err != nil {
+			svc.HandleError(w, err)
 			return
 		}
-		cartItem, err := cart.FindCartItem(ctx, svc.DB, id)
+
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		cartItem, err := svc.patchCartItem(ctx, id, patch)
 		if err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+			svc.HandleError(w, err)
 			return
 		}
+		if cartItem == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("ETag", cartItemETag(cartItem.Version))
 		var resp = Response{
 			CartItem: *cartItem,
 		}
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+			svc.HandleError(w, err)
 		}
-
-	}
+	})
 }
 
 // DeleteCartItemHandler deletes a cart item from the service.
 func (svc *Service) DeleteCartItemHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return describe(operation{
+		Id:      "DeleteCartItemHandler",
+		Summary: "Delete a cart item, provided it matches the If-Match header, if any. The deletion may be undone by restoring the cart item within the undo window.",
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = withIfMatch(r.Context(), r)
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		if err := svc.deleteCartItem(ctx, id); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+	})
+}
+
+// ClearCartHandler removes every item from a user's cart.
+func (svc *Service) ClearCartHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "ClearCartHandler",
+		Summary: "Remove every item from a user's cart. Each removed cart item may be restored within the undo window.",
+		Status:  http.StatusNoContent,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		if _, err := svc.clearCart(ctx, userId); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// AcknowledgePriceChangesHandler acknowledges the price changes of the items
+// of a user's cart.
+func (svc *Service) AcknowledgePriceChangesHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "AcknowledgePriceChangesHandler",
+		Summary: "Acknowledge the price changes of the items of a user's cart, so that their current prices become the prices they are compared to and they are no longer flagged.",
+		Status:  http.StatusNoContent,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		if _, err := svc.acknowledgePriceChanges(ctx, userId); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// RestoreCartItemHandler undoes the deletion of a cart item.
+func (svc *Service) RestoreCartItemHandler() http.HandlerFunc {
+	type Response struct {
+		CartItem cart.CartItem `json:"cartItem"`
+	}
+	return describe(operation{
+		Id:       "RestoreCartItemHandler",
+		Summary:  "Restore a deleted cart item. Deletions may be undone within the undo window; later, 410 Gone is returned. Restored cart items are validated as added cart items are, against the quantity limits of their item, the stock of their variant and the maximum lines per cart.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
 		var ctx = r.Context()
-		id, err := strconv.Atoi(chi.URLParam(r, "id"))
-		if err != nil || id == 0 {
-			svc.Error(w, err, http.StatusBadRequest)
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
 			return
 		}
 
-		if err := cart.DeleteCartItem(ctx, svc.DB, id); err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+		cartItem, err := svc.restoreCartItem(ctx, id)
+		if err != nil {
+			svc.HandleError(w, err)
 			return
 		}
+
+		w.Header().Set("ETag", cartItemETag(cartItem.Version))
+		var resp = Response{
+			CartItem: *cartItem,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// addCartItem adds rel.Count of the item rel.ItemId to the cart of
+// rel.UserId, summing counts if the item is already in the cart. The
+// resulting cart item is returned and published to the cart's event stream.
+func (svc *Service) addCartItem(ctx context.Context, rel cart.UserCartItemRel) (*cart.CartItem, error) {
+	var (
+		cartItem *cart.CartItem
+		events   []typedCartEvent
+	)
+	if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		cartItem, events, err = svc.addCartItemTx(ctx, tx, rel)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	svc.publishCartEvents(events)
+	return cartItem, nil
+}
+
+// addCartItemTx adds rel.Count of the item rel.ItemId to the cart of
+// rel.UserId within tx, as addCartItem does, and returns the resulting cart
+// item and the events to publish once tx commits.
+func (svc *Service) addCartItemTx(ctx context.Context, tx *sql.Tx, rel cart.UserCartItemRel) (*cart.CartItem, []typedCartEvent, error) {
+	i, err := item.FindItem(ctx, tx, rel.ItemId)
+	if err != nil {
+		if errors.Cause(err) == item.ErrNotFound {
+			err = errors.Wrap(errInvalid, err.Error())
+		}
+		return nil, nil, err
+	}
+	variant, err := findCartVariant(ctx, tx, rel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := cart.UserCartItemRelExists(ctx, tx, rel.UserId, rel.ItemId, rel.VariantId)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		event = cartItemUpdated
+		audit = cart.AuditEntry{CartId: rel.UserId, ItemId: rel.ItemId, Action: cart.AuditUpdate}
+	)
+	if id != 0 {
+		existing, err := cart.FindCartItem(ctx, tx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		audit.OldCount = auditCount(existing.Count)
+		rel.Count += existing.Count
+		if err := svc.checkCartLimits(ctx, tx, *i, variant, rel, false); err != nil {
+			return nil, nil, err
+		}
+		if err := cart.UpdateUserCartItemRel(ctx, tx, id, rel); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if err := svc.checkCartLimits(ctx, tx, *i, variant, rel, true); err != nil {
+			return nil, nil, err
+		}
+		if err := cart.PurgeUserCartItemTombstones(ctx, tx, rel.UserId, rel.ItemId, rel.VariantId); err != nil {
+			return nil, nil, err
+		}
+		id, err = cart.CreateUserCartItemRel(ctx, tx, rel, unitPrice(*i, variant))
+		if err != nil {
+			return nil, nil, err
+		}
+		event = cartItemAdded
+		audit.Action = cart.AuditAdd
+	}
+	cartItem, err := cart.FindCartItem(ctx, tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	audit.CartItemId = id
+	audit.NewCount = auditCount(rel.Count)
+	audit.Price = auditPrice(cartItem)
+	if err := recordAudit(ctx, tx, audit); err != nil {
+		return nil, nil, err
+	}
+	var ev = cartEvent{UserId: rel.UserId, CartItem: cartItem}
+	if err := recordCartEvent(ctx, tx, event, ev); err != nil {
+		return nil, nil, err
+	}
+	return cartItem, []typedCartEvent{{typ: event, ev: ev}}, nil
+}
+
+// findCartVariant retrieves the variant of the item of rel, or nil if rel is
+// not of a variant. If the variant does not exist or is not of the item, an
+// error wrapping errInvalid is returned; if rel is not of a variant but the
+// item has variants, a validation error is returned.
+func findCartVariant(ctx context.Context, tx *sql.Tx, rel cart.UserCartItemRel) (*item.Variant, error) {
+	if rel.VariantId == 0 {
+		variants, err := item.ItemVariants(ctx, tx, []int{rel.ItemId})
+		if err != nil {
+			return nil, err
+		}
+		if len(variants[rel.ItemId]) > 0 {
+			var v validate.Validator
+			v.Check("variantId", fail("is required for items with variants"))
+			return nil, v.Err()
+		}
+		return nil, nil
+	}
+
+	variant, err := item.FindVariant(ctx, tx, rel.VariantId)
+	if err != nil {
+		if errors.Cause(err) == item.ErrNotFound {
+			err = errors.Wrap(errInvalid, err.Error())
+		}
+		return nil, err
+	}
+	if variant.ItemId != rel.ItemId {
+		return nil, errors.Wrapf(errInvalid, "failed to findCartVariant\tvariantId=%v\titemId=%v", rel.VariantId, rel.ItemId)
+	}
+	return variant, nil
+}
+
+// unitPrice returns the unit price now of a cart line of i, or of its
+// variant, if not nil.
+func unitPrice(i item.Item, variant *item.Variant) float64 {
+	if variant != nil && variant.Price != nil {
+		return *variant.Price
 	}
+	return i.Price
+}
+
+// checkCartLimits returns a validation error if rel would break the
+// quantity limits of its item i, the stock of its variant, if any, or, if
+// rel is a new line of its cart, the maximum number of lines per cart
+// specified in viper.
+func (svc *Service) checkCartLimits(ctx context.Context, db cart.QueryRower, i item.Item, variant *item.Variant, rel cart.UserCartItemRel, newLine bool) error {
+	var (
+		v      = new(validate.Validator)
+		checks = []validate.Check{i.CheckCount(rel.Count)}
+	)
+	if variant != nil {
+		checks = append(checks, variant.CheckStock(rel.Count))
+	}
+	v.Check("count", checks...)
+	if max := svc.Viper.GetInt(EnvVarCartMaxLines); newLine && max > 0 {
+		n, err := cart.CountCartItems(ctx, db, rel.UserId)
+		if err != nil {
+			return err
+		}
+		if n >= max {
+			v.Check("cart", fail(fmt.Sprintf("may hold at most %d distinct items", max)))
+		}
+	}
+	return v.Err()
+}
+
+// putCartItem overwrites the cart item id with rel and returns the resulting
+// cart item, provided the cart item matches the If-Match header carried by
+// ctx, if any. The change is published to the event streams of the carts
+// affected.
+func (svc *Service) putCartItem(ctx context.Context, id int, rel cart.UserCartItemRel) (*cart.CartItem, error) {
+	var (
+		cartItem *cart.CartItem
+		events   []typedCartEvent
+	)
+	if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		cartItem, events, err = svc.putCartItemTx(ctx, tx, id, rel)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	svc.publishCartEvents(events)
+	return cartItem, nil
+}
+
+// putCartItemTx overwrites the cart item id with rel within tx, as
+// putCartItem does, and returns the resulting cart item and the events to
+// publish once tx commits.
+func (svc *Service) putCartItemTx(ctx context.Context, tx *sql.Tx, id int, rel cart.UserCartItemRel) (*cart.CartItem, []typedCartEvent, error) {
+	prev, err := cart.LockUserCartItemRel(ctx, tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkIfMatch(ctx, cartItemETag(prev.Version)); err != nil {
+		return nil, nil, err
+	}
+	i, err := item.FindItem(ctx, tx, rel.ItemId)
+	if err != nil {
+		if errors.Cause(err) == item.ErrNotFound {
+			err = errors.Wrap(errInvalid, err.Error())
+		}
+		return nil, nil, err
+	}
+	variant, err := findCartVariant(ctx, tx, rel)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := svc.checkCartLimits(ctx, tx, *i, variant, rel, prev.UserId != rel.UserId); err != nil {
+		return nil, nil, err
+	}
+	if prev.UserId != rel.UserId || prev.ItemId != rel.ItemId || prev.VariantId != rel.VariantId {
+		if err := cart.PurgeUserCartItemTombstones(ctx, tx, rel.UserId, rel.ItemId, rel.VariantId); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cart.UpdateUserCartItemRel(ctx, tx, id, rel); err != nil {
+		return nil, nil, err
+	}
+	if prev.UserId != rel.UserId || prev.ItemId != rel.ItemId || prev.VariantId != rel.VariantId {
+		// The line is new to its cart, or of a different item, so its price
+		// has not changed since it was added.
+		if err := cart.ResetBaselinePrice(ctx, tx, id, unitPrice(*i, variant)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cartItem, err := cart.FindCartItem(ctx, tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		events []typedCartEvent
+		audits []cart.AuditEntry
+	)
+	if prev.UserId != rel.UserId {
+		// The cart item moved between carts.
+		events = []typedCartEvent{
+			{typ: cartItemRemoved, ev: cartEvent{UserId: prev.UserId, CartItemId: id}},
+			{typ: cartItemAdded, ev: cartEvent{UserId: rel.UserId, CartItem: cartItem}},
+		}
+		audits = []cart.AuditEntry{
+			{CartId: prev.UserId, CartItemId: id, ItemId: prev.ItemId, Action: cart.AuditRemove, OldCount: auditCount(prev.Count)},
+			{CartId: rel.UserId, CartItemId: id, ItemId: rel.ItemId, Action: cart.AuditAdd, NewCount: auditCount(rel.Count), Price: auditPrice(cartItem)},
+		}
+	} else {
+		events = []typedCartEvent{
+			{typ: cartItemUpdated, ev: cartEvent{UserId: rel.UserId, CartItem: cartItem}},
+		}
+		audits = []cart.AuditEntry{
+			{CartId: rel.UserId, CartItemId: id, ItemId: rel.ItemId, Action: cart.AuditUpdate, OldCount: auditCount(prev.Count), NewCount: auditCount(rel.Count), Price: auditPrice(cartItem)},
+		}
+	}
+	for _, audit := range audits {
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, e := range events {
+		if err := recordCartEvent(ctx, tx, e.typ, e.ev); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cartItem, events, nil
+}
+
+// deleteCartItem soft deletes the cart item id, provided it matches the
+// If-Match header carried by ctx, if any, and publishes its removal to the
+// cart's event stream.
+func (svc *Service) deleteCartItem(ctx context.Context, id int) error {
+	var events []typedCartEvent
+	if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		events, err = deleteCartItemTx(ctx, tx, id)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	svc.publishCartEvents(events)
+	return nil
+}
+
+// deleteCartItemTx soft deletes the cart item id within tx, as
+// deleteCartItem does, and returns the events to publish once tx commits.
+func deleteCartItemTx(ctx context.Context, tx *sql.Tx, id int) ([]typedCartEvent, error) {
+	rel, err := cart.LockUserCartItemRel(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIfMatch(ctx, cartItemETag(rel.Version)); err != nil {
+		return nil, err
+	}
+	if err := cart.DeleteCartItem(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, cart.AuditEntry{
+		CartId:     rel.UserId,
+		CartItemId: id,
+		ItemId:     rel.ItemId,
+		Action:     cart.AuditRemove,
+		OldCount:   auditCount(rel.Count),
+	}); err != nil {
+		return nil, err
+	}
+	var ev = cartEvent{UserId: rel.UserId, CartItemId: id}
+	if err := recordCartEvent(ctx, tx, cartItemRemoved, ev); err != nil {
+		return nil, err
+	}
+	return []typedCartEvent{{typ: cartItemRemoved, ev: ev}}, nil
+}
+
+// acknowledgePriceChanges resets the baseline price of each cart item of
+// userId whose price changed to its price now, so that it is no longer
+// flagged, and returns the number reset. Each is published to the cart's
+// event stream as updated.
+func (svc *Service) acknowledgePriceChanges(ctx context.Context, userId int) (int, error) {
+	var events []typedCartEvent
+	if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := cart.LockCartItemIds(ctx, tx, userId); err != nil {
+			return err
+		}
+		cartItems, err := cart.CartItems(ctx, tx, userId)
+		if err != nil {
+			return err
+		}
+		for _, ci := range cartItems {
+			if ci.PriceChange == nil {
+				continue
+			}
+			if err := cart.ResetBaselinePrice(ctx, tx, ci.Id, ci.PriceChange.New); err != nil {
+				return err
+			}
+			cartItem, err := cart.FindCartItem(ctx, tx, ci.Id)
+			if err != nil {
+				return err
+			}
+			var ev = cartEvent{UserId: userId, CartItem: cartItem}
+			if err := recordCartEvent(ctx, tx, cartItemUpdated, ev); err != nil {
+				return err
+			}
+			events = append(events, typedCartEvent{typ: cartItemUpdated, ev: ev})
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	svc.publishCartEvents(events)
+	return len(events), nil
+}
+
+// restoreCartItem undoes the deletion of the cart item id, provided it was
+// deleted within the undo window specified in viper, and returns the
+// restored cart item. Its return is published to the cart's event stream.
+func (svc *Service) restoreCartItem(ctx context.Context, id int) (*cart.CartItem, error) {
+	var (
+		since    = time.Now().Add(-svc.Viper.GetDuration(EnvVarCartUndoWindow))
+		rel      *cart.UserCartItemRel
+		cartItem *cart.CartItem
+	)
+	if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		rel, err = cart.LockDeletedUserCartItemRel(ctx, tx, id, since)
+		if err != nil {
+			return err
+		}
+		i, err := item.FindItem(ctx, tx, rel.ItemId)
+		if err != nil {
+			if errors.Cause(err) == item.ErrNotFound {
+				err = errors.Wrap(errInvalid, err.Error())
+			}
+			return err
+		}
+		variant, err := findCartVariant(ctx, tx, *rel)
+		if err != nil {
+			return err
+		}
+		// The item's limits and the variant's stock may have changed since
+		// the deletion, and the restored line is new to its cart.
+		if err := svc.checkCartLimits(ctx, tx, *i, variant, *rel, true); err != nil {
+			return err
+		}
+		if err := cart.RestoreCartItem(ctx, tx, id); err != nil {
+			return err
+		}
+		cartItem, err = cart.FindCartItem(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, cart.AuditEntry{
+			CartId:     rel.UserId,
+			CartItemId: id,
+			ItemId:     rel.ItemId,
+			Action:     cart.AuditRestore,
+			NewCount:   auditCount(rel.Count),
+			Price:      auditPrice(cartItem),
+		}); err != nil {
+			return err
+		}
+		return recordCartEvent(ctx, tx, cartItemAdded, cartEvent{UserId: rel.UserId, CartItem: cartItem})
+	}); err != nil {
+		return nil, err
+	}
+
+	svc.publishCartEvent(cartItemAdded, cartEvent{UserId: rel.UserId, CartItem: cartItem})
+	return cartItem, nil
+}
+
+// PostCartBatchHandler applies a batch of operations to a user's cart.
+func (svc *Service) PostCartBatchHandler() http.HandlerFunc {
+	type (
+		Result struct {
+			Id       string         `json:"id,omitempty"`
+			Status   int            `json:"status"`
+			CartItem *cart.CartItem `json:"cartItem,omitempty"`
+			Error    *ErrorBody     `json:"error,omitempty"`
+		}
+		Response struct {
+			Applied bool     `json:"applied"`
+			Results []Result `json:"results"`
+		}
+	)
+	var batch = svc.cartBatchHandler(cartRenderer{
+		cartItem: func(cartItem cart.CartItem) interface{} {
+			return cartItem
+		},
+	})
+	return describe(operation{
+		Id:       "PostCartBatchHandler",
+		Summary:  "Apply up to 100 add, set and remove operations to a user's cart in a single transaction. Atomic batches, the default, apply every operation or none; best-effort batches apply each operation that succeeds. Each result reports the status the operation would have had as a request of its own.",
+		Request:  cartBatchRequest{},
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		batch(w, r)
+	})
 }
@@ -0,0 +1,448 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/money"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/go-chi/chi"
+)
+
+// CartRoutesV2 defines the v2 cart resources REST endpoints. v2 represents
+// prices as Money and reports cart totals.
+func (svc *Service) CartRoutesV2(r chi.Router) {
+	r.With(svc.idempotent).Post("/cart/item", svc.AddCartItemV2Handler())
+	r.Get("/cart/{userId}", svc.GetCartV2Handler())
+	r.Get("/cart/{userId}/events", svc.GetCartEventsV2Handler())
+	r.Get("/cart/{cartId}/ws", svc.GetCartSessionV2Handler())
+	r.With(svc.idempotent).Put("/cart/item/{id}", svc.PutCartItemV2Handler())
+	r.With(svc.idempotent).Patch("/cart/item/{id}", svc.PatchCartItemV2Handler())
+	r.With(svc.idempotent).Delete("/cart/item/{id}", svc.DeleteCartItemHandler())
+	r.With(svc.idempotent).Delete("/cart/{userId}", svc.ClearCartHandler())
+	r.With(svc.idempotent).Post("/cart/item/{id}/restore", svc.RestoreCartItemV2Handler())
+	r.With(svc.idempotent).Post("/cart/{userId}/batch", svc.PostCartBatchV2Handler())
+	r.With(svc.idempotent).Post("/cart/{userId}/price-changes/acknowledge", svc.AcknowledgePriceChangesHandler())
+}
+
+// cartItemV2 is the v2 representation of a cart.CartItem.
+type cartItemV2 struct {
+	Id    int    `json:"id"`
+	Count int    `json:"count"`
+	Item  itemV2 `json:"item"`
+
+	// Variant is the variant of the item, if any. Item.Price is then the
+	// price of the variant.
+	Variant *variantV2 `json:"variant,omitempty"`
+
+	// Total is the item price multiplied by Count.
+	Total money.Money `json:"total"`
+
+	// Version is incremented each time the cart item is modified.
+	Version int `json:"version"`
+
+	// PriceChange describes the change of the price of the item since it
+	// was added, or since the price changes of the cart were last
+	// acknowledged, or is nil if its price is unchanged.
+	PriceChange *priceChangeV2 `json:"priceChange,omitempty"`
+}
+
+// priceChangeV2 is the v2 representation of a cart.PriceChange.
+type priceChangeV2 struct {
+	Old money.Money `json:"old"`
+	New money.Money `json:"new"`
+}
+
+// newCartItemV2 converts ci to its v2 representation.
+func (svc *Service) newCartItemV2(ci cart.CartItem) cartItemV2 {
+	var i = svc.newItemV2(ci.Item)
+	var civ2 = cartItemV2{
+		Id:      ci.Id,
+		Count:   ci.Count,
+		Item:    i,
+		Total:   i.Price.Mul(ci.Count),
+		Version: ci.Version,
+	}
+	if ci.Variant != nil {
+		var v = svc.newVariantV2(*ci.Variant)
+		civ2.Variant = &v
+	}
+	if ci.PriceChange != nil {
+		var currency = svc.Viper.GetString(EnvVarCurrency)
+		civ2.PriceChange = &priceChangeV2{
+			Old: money.FromFloat(ci.PriceChange.Old, currency),
+			New: money.FromFloat(ci.PriceChange.New, currency),
+		}
+	}
+	return civ2
+}
+
+// cartV2 is the v2 representation of a user's cart.
+type cartV2 struct {
+	UserId    int          `json:"userId"`
+	CartItems []cartItemV2 `json:"cartItems"`
+
+	// ItemCount is the sum of the count of every cart item.
+	ItemCount int `json:"itemCount"`
+
+	// Subtotal is the sum of the total of every cart item.
+	Subtotal money.Money `json:"subtotal"`
+}
+
+// newCartV2 converts userId's cartItems to their v2 representation.
+func (svc *Service) newCartV2(userId int, cartItems []cart.CartItem) cartV2 {
+	var c = cartV2{
+		UserId:    userId,
+		CartItems: make([]cartItemV2, 0, len(cartItems)),
+		Subtotal:  money.Money{Currency: svc.Viper.GetString(EnvVarCurrency)},
+	}
+	for _, ci := range cartItems {
+		var civ2 = svc.newCartItemV2(ci)
+		c.CartItems = append(c.CartItems, civ2)
+		c.ItemCount += civ2.Count
+		c.Subtotal = c.Subtotal.Add(civ2.Total)
+	}
+	return c
+}
+
+// AddCartItemV2Handler adds an item to a user's cart.
+func (svc *Service) AddCartItemV2Handler() http.HandlerFunc {
+	type (
+		Request struct {
+			ItemId int `json:"itemId" validate:"required"`
+			UserId int `json:"userId" validate:"required"`
+			Count  int `json:"count" validate:"min=1"`
+
+			// VariantId is required if the item has variants.
+			VariantId int `json:"variantId,omitempty"`
+		}
+		Response struct {
+			CartItem cartItemV2 `json:"cartItem"`
+		}
+	)
+	return describe(operation{
+		Id:       "AddCartItemV2Handler",
+		Summary:  "Add an item to a user's cart, summing counts if the item is already in the cart.",
+		Request:  Request{},
+		Response: Response{},
+		Status:   http.StatusCreated,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = r.Context()
+			req Request
+		)
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		cartItem, err := svc.addCartItem(ctx, cart.UserCartItemRel{
+			ItemId:    req.ItemId,
+			UserId:    req.UserId,
+			Count:     req.Count,
+			VariantId: req.VariantId,
+		})
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", cartItemETag(cartItem.Version))
+		w.WriteHeader(http.StatusCreated)
+		var resp = Response{
+			CartItem: svc.newCartItemV2(*cartItem),
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// GetCartV2Handler retrieves a user's cart, with totals, from the service.
+func (svc *Service) GetCartV2Handler() http.HandlerFunc {
+	type Response struct {
+		Cart cartV2 `json:"cart"`
+	}
+	return describe(operation{
+		Id:       "GetCartV2Handler",
+		Summary:  "Retrieve a user's cart with totals. Lines whose price changed since they were added, or since the cart's price changes were last acknowledged, carry a priceChange with the old and new unit prices. The ETag header identifies the cart's version and prices; 304 Not Modified is returned if it matches If-None-Match.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		userId, err := urlParamId(r, "userId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		// The version is read first so that a concurrent modification yields
+		// an ETag older than the cart items, never newer.
+		version, err := cart.CartVersion(ctx, svc.DB, userId)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		cartItems, err := cart.CartItems(ctx, svc.DB, userId)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		var etag = cartETag(version, cartItems)
+		w.Header().Set("ETag", etag)
+		if notModified(r, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if err := svc.withCartMedia(ctx, cartItems); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Cart: svc.newCartV2(userId, cartItems),
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// PutCartItemV2Handler updates a cart item in the service.
+func (svc *Service) PutCartItemV2Handler() http.HandlerFunc {
+	type (
+		Request struct {
+			ItemId int `json:"itemId" validate:"required"`
+			UserId int `json:"userId" validate:"required"`
+			Count  int `json:"count" validate:"min=1"`
+
+			// VariantId is required if the item has variants.
+			VariantId int `json:"variantId,omitempty"`
+		}
+		Response struct {
+			CartItem cartItemV2 `json:"cartItem"`
+		}
+	)
+	return describe(operation{
+		Id:       "PutCartItemV2Handler",
+		Summary:  "Update a cart item, provided it matches the If-Match header, if any; otherwise 412 Precondition Failed is returned.",
+		Request:  Request{},
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = withIfMatch(r.Context(), r)
+			req Request
+		)
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		cartItem, err := svc.putCartItem(ctx, id, cart.UserCartItemRel{
+			ItemId:    req.ItemId,
+			UserId:    req.UserId,
+			Count:     req.Count,
+			VariantId: req.VariantId,
+		})
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", cartItemETag(cartItem.Version))
+		var resp = Response{
+			CartItem: svc.newCartItemV2(*cartItem),
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// PatchCartItemV2Handler applies a JSON merge patch to a cart item.
+func (svc *Service) PatchCartItemV2Handler() http.HandlerFunc {
+	type (
+		// Request documents the members of the patch, each optional.
+		Request struct {
+			ItemId    *int `json:"itemId"`
+			VariantId *int `json:"variantId"`
+			UserId    *int `json:"userId"`
+			Count     *int `json:"count"`
+		}
+		Response struct {
+			CartItem cartItemV2 `json:"cartItem"`
+		}
+	)
+	return describe(operation{
+		Id:       "PatchCartItemV2Handler",
+		Summary:  "Update the given fields of a cart item with a JSON merge patch (RFC 7396), provided it matches the If-Match header, if any. Setting count to 0 removes the cart item, and 204 No Content is returned.",
+		Request:  Request{},
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx   = withIfMatch(r.Context(), r)
+			patch cartItemPatch
+		)
+		if err := decode(r, &patch); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		cartItem, err := svc.patchCartItem(ctx, id, patch)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if cartItem == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("ETag", cartItemETag(cartItem.Version))
+		var resp = Response{
+			CartItem: svc.newCartItemV2(*cartItem),
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// RestoreCartItemV2Handler undoes the deletion of a cart item.
+func (svc *Service) RestoreCartItemV2Handler() http.HandlerFunc {
+	type Response struct {
+		CartItem cartItemV2 `json:"cartItem"`
+	}
+	return describe(operation{
+		Id:       "RestoreCartItemV2Handler",
+		Summary:  "Restore a deleted cart item. Deletions may be undone within the undo window; later, 410 Gone is returned. Restored cart items are validated as added cart items are, against the quantity limits of their item, the stock of their variant and the maximum lines per cart.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		cartItem, err := svc.restoreCartItem(ctx, id)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", cartItemETag(cartItem.Version))
+		var resp = Response{
+			CartItem: svc.newCartItemV2(*cartItem),
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// GetCartEventsV2Handler streams the changes to a user's cart as Server-Sent
+// Events, representing cart items and carts as v2 does.
+func (svc *Service) GetCartEventsV2Handler() http.HandlerFunc {
+	type (
+		CartItemEvent struct {
+			UserId   int        `json:"userId"`
+			CartItem cartItemV2 `json:"cartItem"`
+		}
+		CartItemRemovedEvent struct {
+			UserId     int `json:"userId"`
+			CartItemId int `json:"cartItemId"`
+		}
+		CartSnapshotEvent struct {
+			Cart cartV2 `json:"cart"`
+		}
+	)
+	var stream = svc.cartEventsHandler(func(typ string, ev cartEvent) interface{} {
+		switch typ {
+		case cartItemRemoved:
+			return CartItemRemovedEvent{UserId: ev.UserId, CartItemId: ev.CartItemId}
+		case cartSnapshot:
+			return CartSnapshotEvent{Cart: svc.newCartV2(ev.UserId, ev.CartItems)}
+		default:
+			return CartItemEvent{UserId: ev.UserId, CartItem: svc.newCartItemV2(*ev.CartItem)}
+		}
+	})
+	return describe(operation{
+		Id:      "GetCartEventsV2Handler",
+		Summary: "Stream the changes to a user's cart as Server-Sent Events.",
+	}, func(w http.ResponseWriter, r *http.Request) {
+		stream(w, r)
+	})
+}
+
+// GetCartSessionV2Handler upgrades to a WebSocket through which the owner and
+// members of a cart edit it together, representing cart items and carts as
+// v2 does.
+func (svc *Service) GetCartSessionV2Handler() http.HandlerFunc {
+	var session = svc.cartSessionHandler(cartRenderer{
+		cart: func(cartId int, cartItems []cart.CartItem) interface{} {
+			return svc.newCartV2(cartId, cartItems)
+		},
+		cartItem: func(cartItem cart.CartItem) interface{} {
+			return svc.newCartItemV2(cartItem)
+		},
+	})
+	return describe(operation{
+		Id:      "GetCartSessionV2Handler",
+		Summary: "Edit a shared cart together over a WebSocket.",
+		Status:  http.StatusSwitchingProtocols,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		session(w, r)
+	})
+}
+
+// PostCartBatchV2Handler applies a batch of operations to a user's cart.
+func (svc *Service) PostCartBatchV2Handler() http.HandlerFunc {
+	type (
+		Result struct {
+			Id       string      `json:"id,omitempty"`
+			Status   int         `json:"status"`
+			CartItem *cartItemV2 `json:"cartItem,omitempty"`
+			Error    *ErrorBody  `json:"error,omitempty"`
+		}
+		Response struct {
+			Applied bool     `json:"applied"`
+			Results []Result `json:"results"`
+		}
+	)
+	var batch = svc.cartBatchHandler(cartRenderer{
+		cartItem: func(cartItem cart.CartItem) interface{} {
+			return svc.newCartItemV2(cartItem)
+		},
+	})
+	return describe(operation{
+		Id:       "PostCartBatchV2Handler",
+		Summary:  "Apply up to 100 add, set and remove operations to a user's cart in a single transaction. Atomic batches, the default, apply every operation or none; best-effort batches apply each operation that succeeds. Each result reports the status the operation would have had as a request of its own.",
+		Request:  cartBatchRequest{},
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		batch(w, r)
+	})
+}
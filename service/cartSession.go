@@ -0,0 +1,447 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/pubsub"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// Cart session operations a client may request.
+const (
+	cartOpAdd    = "add"
+	cartOpSet    = "set"
+	cartOpRemove = "remove"
+)
+
+// Cart session message types sent to clients.
+const (
+	cartMsgState = "state"
+	cartMsgAck   = "ack"
+	cartMsgError = "error"
+)
+
+const (
+	// cartSessionWriteWait is the time allowed to write a message to a
+	// client.
+	cartSessionWriteWait = 10 * time.Second
+
+	// cartSessionReadLimit is the maximum size of a message read from a
+	// client, in bytes.
+	cartSessionReadLimit = 4096
+)
+
+// cartOp is an operation requested by a cart session client.
+type cartOp struct {
+	// Id is chosen by the client and echoed in the ack or error sent in
+	// response, so that the client may correlate them.
+	Id string `json:"id"`
+
+	Op         string `json:"op" validate:"oneof=add set remove"`
+	ItemId     int    `json:"itemId"`
+	VariantId  int    `json:"variantId,omitempty"`
+	CartItemId int    `json:"cartItemId"`
+	Count      int    `json:"count"`
+}
+
+// validate validates the fields op.Op requires.
+func (op cartOp) validate() error {
+	if err := validate.Struct(op); err != nil {
+		return err
+	}
+	var v validate.Validator
+	switch op.Op {
+	case cartOpAdd:
+		v.Check("itemId", validate.Required(op.ItemId))
+		v.Check("count", validate.IntMin(op.Count, 1))
+	case cartOpSet:
+		v.Check("cartItemId", validate.Required(op.CartItemId))
+		v.Check("count", validate.IntMin(op.Count, 1))
+	case cartOpRemove:
+		v.Check("cartItemId", validate.Required(op.CartItemId))
+	}
+	return v.Err()
+}
+
+// cartMsg is a message sent to cart session clients.
+type cartMsg struct {
+	Type string `json:"type"`
+
+	// Id is the id of the cartOp an ack or error responds to.
+	Id string `json:"id,omitempty"`
+
+	// Revision orders state messages. It increases with every change to the
+	// cart.
+	Revision uint64 `json:"revision,omitempty"`
+
+	Cart     interface{} `json:"cart,omitempty"`
+	CartItem interface{} `json:"cartItem,omitempty"`
+	Error    *ErrorBody  `json:"error,omitempty"`
+}
+
+// cartRenderer renders carts and cart items in the representation of an API
+// version.
+type cartRenderer struct {
+	cart     func(cartId int, cartItems []cart.CartItem) interface{}
+	cartItem func(cartItem cart.CartItem) interface{}
+}
+
+// GetCartSessionHandler upgrades to a WebSocket through which the owner and
+// members of a cart edit it together.
+func (svc *Service) GetCartSessionHandler() http.HandlerFunc {
+	type Cart struct {
+		CartId    int             `json:"cartId"`
+		CartItems []cart.CartItem `json:"cartItems"`
+	}
+	var session = svc.cartSessionHandler(cartRenderer{
+		cart: func(cartId int, cartItems []cart.CartItem) interface{} {
+			return Cart{CartId: cartId, CartItems: cartItems}
+		},
+		cartItem: func(cartItem cart.CartItem) interface{} {
+			return cartItem
+		},
+	})
+	return describe(operation{
+		Id:      "GetCartSessionHandler",
+		Summary: "Edit a shared cart together over a WebSocket.",
+		Status:  http.StatusSwitchingProtocols,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		session(w, r)
+	})
+}
+
+// cartSessionHandler returns a handler that upgrades to a WebSocket session
+// on the cart of the cartId URL param, rendering carts and cart items with
+// render. Handlers must wrap the returned handler in their own closure
+// before describing it, as every handler it returns shares a code pointer.
+//
+// Clients send cartOps and receive an ack or error for each. Every
+// participant is sent the cart's state when it joins and whenever the cart
+// changes, including through other APIs. Operations on a cart are applied
+// one at a time, in the order the service receives them, so concurrent
+// edits resolve deterministically:
+//
+//	add     counts are summed, so concurrent adds of an item all apply
+//	set     the last set of a cart item applied wins
+//	remove  sets and removes of a removed cart item fail with a 404 error
+func (svc *Service) cartSessionHandler(render cartRenderer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		cartId, err := urlParamId(r, "cartId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		userId, err := requestUserId(r)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if err := svc.authorizeCart(ctx, cartId, userId); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		// Subscribe before the upgrade so that no change made after the
+		// initial state is read is missed.
+		sub, _, _ := svc.Hub.Subscribe(cartTopic(cartId), 0)
+		defer sub.Close()
+
+		var upgrader = websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+
+			// Cross-origin storefronts are permitted from the origins the
+			// REST endpoints permit; see defaultMiddleware.
+			CheckOrigin: svc.checkOrigin,
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			svc.Zap.Debug(errors.Wrap(err, "failed to cartSessionHandler/Upgrade").Error())
+			return
+		}
+		defer conn.Close()
+
+		var s = &cartSession{
+			svc:    svc,
+			conn:   conn,
+			render: render,
+			cartId: cartId,
+		}
+		if err := s.sendState(ctx, sub.Last); err != nil {
+			svc.Zap.Debug(err.Error())
+			return
+		}
+
+		var done = make(chan struct{})
+		defer close(done)
+		go s.broadcast(ctx, sub, done)
+
+		s.read(ctx)
+	}
+}
+
+// cartSession is a single client's WebSocket session on a cart.
+type cartSession struct {
+	svc    *Service
+	conn   *websocket.Conn
+	render cartRenderer
+	cartId int
+
+	// mu serializes writes to conn.
+	mu sync.Mutex
+}
+
+// send writes msg to the client.
+func (s *cartSession) send(msg cartMsg) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(cartSessionWriteWait))
+	if err := s.conn.WriteJSON(msg); err != nil {
+		return errors.Wrapf(err, "failed to cartSession/WriteJSON\ttype=%s", msg.Type)
+	}
+	return nil
+}
+
+// sendState sends the cart's current state to the client.
+func (s *cartSession) sendState(ctx context.Context, revision uint64) error {
+	cartItems, err := cart.CartItems(ctx, s.svc.DB, s.cartId)
+	if err != nil {
+		return err
+	}
+	return s.send(cartMsg{
+		Type:     cartMsgState,
+		Revision: revision,
+		Cart:     s.render.cart(s.cartId, cartItems),
+	})
+}
+
+// broadcast sends the cart's state to the client whenever the cart changes,
+// and pings the client to detect dead connections, until done is closed. If
+// a write fails, or the client falls too far behind the cart's changes, the
+// connection is closed, ending the session.
+func (s *cartSession) broadcast(ctx context.Context, sub *pubsub.Subscription, done <-chan struct{}) {
+	var ping = time.NewTicker(s.svc.Viper.GetDuration(EnvVarEventsKeepAlive))
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(cartSessionWriteWait)); err != nil {
+				s.svc.Zap.Debug(errors.Wrap(err, "failed to cartSession/WriteControl").Error())
+				s.conn.Close()
+				return
+			}
+		case ev, ok := <-sub.Events():
+			if !ok {
+				s.conn.Close()
+				return
+			}
+			// Changes made while the state was being sent are coalesced
+			// into a single state message.
+			for drained := false; !drained; {
+				select {
+				case next, ok := <-sub.Events():
+					if !ok {
+						s.conn.Close()
+						return
+					}
+					ev = next
+				default:
+					drained = true
+				}
+			}
+			if err := s.sendState(ctx, ev.Id); err != nil {
+				s.svc.Zap.Debug(err.Error())
+				s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// read applies the cartOps sent by the client until the connection fails.
+func (s *cartSession) read(ctx context.Context) {
+	var pongWait = 2 * s.svc.Viper.GetDuration(EnvVarEventsKeepAlive)
+	s.conn.SetReadLimit(cartSessionReadLimit)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			s.svc.Zap.Debug(errors.Wrap(err, "failed to cartSession/ReadMessage").Error())
+			return
+		}
+
+		var op cartOp
+		if err := json.Unmarshal(data, &op); err != nil {
+			err = errors.Wrap(errMalformed, err.Error())
+			if err := s.sendError(op.Id, err); err != nil {
+				return
+			}
+			continue
+		}
+
+		cartItem, err := s.svc.applyCartOp(ctx, s.cartId, op)
+		if err != nil {
+			if err := s.sendError(op.Id, err); err != nil {
+				return
+			}
+			continue
+		}
+
+		var ack = cartMsg{Type: cartMsgAck, Id: op.Id}
+		if cartItem != nil {
+			ack.CartItem = s.render.cartItem(*cartItem)
+		}
+		if err := s.send(ack); err != nil {
+			s.svc.Zap.Debug(err.Error())
+			return
+		}
+	}
+}
+
+// sendError sends the error that describes why the cartOp id failed to the
+// client.
+func (s *cartSession) sendError(id string, err error) error {
+	var body = errorBody(err)
+	if body.Status >= http.StatusInternalServerError {
+		s.svc.Zap.Error(err.Error())
+	} else {
+		s.svc.Zap.Debug(err.Error())
+	}
+	if err := s.send(cartMsg{Type: cartMsgError, Id: id, Error: &body}); err != nil {
+		s.svc.Zap.Debug(err.Error())
+		return err
+	}
+	return nil
+}
+
+// applyCartOp applies op to cartId, using the cart mutations of the REST
+// handlers. The resulting cart item is returned, or nil if op removed it.
+// The operations applied to a cart through applyCartOp are serialized.
+func (svc *Service) applyCartOp(ctx context.Context, cartId int, op cartOp) (*cart.CartItem, error) {
+	if err := op.validate(); err != nil {
+		return nil, err
+	}
+
+	var unlock = svc.cartLocks.lock(cartId)
+	defer unlock()
+
+	var (
+		cartItem *cart.CartItem
+		events   []typedCartEvent
+	)
+	if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		cartItem, events, err = svc.applyCartOpTx(ctx, tx, cartId, op)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	svc.publishCartEvents(events)
+	return cartItem, nil
+}
+
+// applyCartOpTx applies the validated op to cartId within tx, and returns
+// the resulting cart item, or nil if op removed it, and the events to
+// publish once tx commits.
+func (svc *Service) applyCartOpTx(ctx context.Context, tx *sql.Tx, cartId int, op cartOp) (*cart.CartItem, []typedCartEvent, error) {
+	switch op.Op {
+	case cartOpAdd:
+		return svc.addCartItemTx(ctx, tx, cart.UserCartItemRel{
+			ItemId:    op.ItemId,
+			UserId:    cartId,
+			Count:     op.Count,
+			VariantId: op.VariantId,
+		})
+	case cartOpSet:
+		rel, err := findCartItemRel(ctx, tx, cartId, op.CartItemId)
+		if err != nil {
+			return nil, nil, err
+		}
+		rel.Count = op.Count
+		return svc.putCartItemTx(ctx, tx, op.CartItemId, *rel)
+	default:
+		if _, err := findCartItemRel(ctx, tx, cartId, op.CartItemId); err != nil {
+			return nil, nil, err
+		}
+		events, err := deleteCartItemTx(ctx, tx, op.CartItemId)
+		return nil, events, err
+	}
+}
+
+// findCartItemRel retrieves the UserCartItemRel of the cart item id. If the
+// cart item does not belong to cartId, an error wrapping cart.ErrNotFound is
+// returned.
+func findCartItemRel(ctx context.Context, db cart.QueryRower, cartId, id int) (*cart.UserCartItemRel, error) {
+	rel, err := cart.FindUserCartItemRel(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if rel.UserId != cartId {
+		return nil, errors.Wrapf(cart.ErrNotFound, "failed to findCartItemRel\tcartId=%v\tid=%v", cartId, id)
+	}
+	return rel, nil
+}
+
+// cartLocks serializes the cart session operations on each cart. The lock of
+// a cart exists only while it is held or awaited, so that locks do not
+// accumulate for every cart ever opened.
+type cartLocks struct {
+	mu    sync.Mutex
+	locks map[int]*cartLock
+}
+
+// cartLock is the lock of a cart, and the number of goroutines that hold or
+// await it.
+type cartLock struct {
+	sync.Mutex
+	refs int
+}
+
+// newCartLocks returns an initialized cartLocks.
+func newCartLocks() *cartLocks {
+	return &cartLocks{locks: make(map[int]*cartLock)}
+}
+
+// lock blocks until the lock of cartId is acquired, and returns the func
+// that releases it.
+func (l *cartLocks) lock(cartId int) (unlock func()) {
+	l.mu.Lock()
+	var cl, ok = l.locks[cartId]
+	if !ok {
+		cl = new(cartLock)
+		l.locks[cartId] = cl
+	}
+	cl.refs++
+	l.mu.Unlock()
+
+	cl.Lock()
+	return func() {
+		cl.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if cl.refs--; cl.refs == 0 {
+			delete(l.locks, cartId)
+		}
+	}
+}
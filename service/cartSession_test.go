@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestUserId(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Header   string
+		Query    string
+		Expected int
+		Err      error
+	}{
+		{Name: "header", Header: "7", Expected: 7},
+		{Name: "query ignored", Query: "?userId=8", Err: errUnauthenticated},
+		{Name: "header not query", Header: "7", Query: "?userId=8", Expected: 7},
+		{Name: "missing", Err: errUnauthenticated},
+		{Name: "non-numeric", Header: "abc", Err: errUnauthenticated},
+		{Name: "zero", Header: "0", Err: errUnauthenticated},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var r = httptest.NewRequest(http.MethodGet, "/cart/1/ws"+test.Query, nil)
+			if test.Header != "" {
+				r.Header.Set(headerUserId, test.Header)
+			}
+			userId, err := requestUserId(r)
+			if test.Err != nil {
+				require.Equal(t, statusCode(test.Err), statusCode(err))
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, test.Expected, userId)
+		})
+	}
+}
+
+func TestAuthorizeAdmin(t *testing.T) {
+	tests := []struct {
+		Name   string
+		UserId string
+		Query  string
+		Roles  string
+		Err    error
+	}{
+		{Name: "admin", UserId: "7", Roles: roleAdmin},
+		{Name: "admin among roles", UserId: "7", Roles: "support, admin"},
+		{Name: "unauthenticated", Roles: roleAdmin, Err: errUnauthenticated},
+		{Name: "query param user", Query: "?userId=7", Roles: roleAdmin, Err: errUnauthenticated},
+		{Name: "malformed user", UserId: "abc", Roles: roleAdmin, Err: errUnauthenticated},
+		{Name: "no roles", UserId: "7", Err: errForbidden},
+		{Name: "other roles", UserId: "7", Roles: "support,administrator", Err: errForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var r = httptest.NewRequest(http.MethodGet, "/admin/carts/abandoned"+test.Query, nil)
+			if test.UserId != "" {
+				r.Header.Set(headerUserId, test.UserId)
+			}
+			if test.Roles != "" {
+				r.Header.Set(headerUserRoles, test.Roles)
+			}
+			var err = authorizeAdmin(r)
+			if test.Err != nil {
+				require.Equal(t, test.Err, errors.Cause(err))
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
+
+func TestCartSessionAndMemberAuth(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		UserId string
+		Status int
+	}{
+		{Name: "session unauthenticated", Method: http.MethodGet, Path: "/v1/cart/1/ws", Status: http.StatusUnauthorized},
+		{Name: "v2 session unauthenticated", Method: http.MethodGet, Path: "/v2/cart/1/ws", Status: http.StatusUnauthorized},
+		{Name: "session query param user", Method: http.MethodGet, Path: "/v1/cart/5/ws?userId=5", Status: http.StatusUnauthorized},
+		{Name: "members unauthenticated", Method: http.MethodGet, Path: "/v1/cart/1/members", Status: http.StatusUnauthorized},
+		{Name: "members not owner", Method: http.MethodGet, Path: "/v1/cart/1/members", UserId: "2", Status: http.StatusForbidden},
+		{Name: "add member query param user", Method: http.MethodPut, Path: "/v1/cart/5/members/99?userId=5", Status: http.StatusUnauthorized},
+		{Name: "add member not owner", Method: http.MethodPut, Path: "/v1/cart/1/members/3", UserId: "2", Status: http.StatusForbidden},
+		{Name: "add owner as member", Method: http.MethodPut, Path: "/v1/cart/1/members/1", UserId: "1", Status: http.StatusUnprocessableEntity},
+		{Name: "remove member not owner", Method: http.MethodDelete, Path: "/v2/cart/1/members/3", UserId: "2", Status: http.StatusForbidden},
+	}
+
+	var svc = newTestService()
+	WithHub()(svc)
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(test.Method, test.Path, nil)
+			)
+			if test.UserId != "" {
+				r.Header.Set(headerUserId, test.UserId)
+			}
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+
+			var resp ErrorResponse
+			require.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			require.Equal(t, test.Status, resp.Error.Status)
+		})
+	}
+}
+
+func TestApplyCartOpValidation(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Op       cartOp
+		Expected string
+	}{
+		{
+			Name:     "unknown op",
+			Op:       cartOp{Op: "clear"},
+			Expected: "validation failed: op: must be one of [add, set, remove]",
+		},
+		{
+			Name:     "add",
+			Op:       cartOp{Op: cartOpAdd},
+			Expected: "validation failed: itemId: is required; count: must be greater than or equal to 1",
+		},
+		{
+			Name:     "set",
+			Op:       cartOp{Op: cartOpSet, Count: 1},
+			Expected: "validation failed: cartItemId: is required",
+		},
+		{
+			Name:     "remove",
+			Op:       cartOp{Op: cartOpRemove},
+			Expected: "validation failed: cartItemId: is required",
+		},
+	}
+
+	var svc = newTestService()
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := svc.applyCartOp(context.Background(), 1, test.Op)
+			require.NotNil(t, err)
+			require.Equal(t, http.StatusUnprocessableEntity, statusCode(err))
+			require.Equal(t, test.Expected, err.Error())
+		})
+	}
+}
+
+func TestCartSessionOrigin(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Allowed string
+		Origin  string
+		Status  int
+	}{
+		{Name: "cross-origin not allowed", Allowed: "https://shop.example.com", Origin: "https://evil.example.net", Status: http.StatusForbidden},
+		{Name: "wildcard pattern not matched", Allowed: "https://*.example.com", Origin: "https://example.com.evil.net", Status: http.StatusForbidden},
+		{Name: "every origin not honoured", Allowed: "*", Origin: "https://evil.example.net", Status: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var svc = newTestService()
+			svc.Viper.Set(EnvVarCORSAllowedOrigins, test.Allowed)
+			WithHub()(svc)
+			WithRouters(svc.Routes())(svc)
+
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(http.MethodGet, "/v1/cart/1/ws", nil)
+			)
+			r.Header.Set(headerUserId, "1")
+			r.Header.Set("Origin", test.Origin)
+			r.Header.Set("Connection", "Upgrade")
+			r.Header.Set("Upgrade", "websocket")
+			r.Header.Set("Sec-WebSocket-Version", "13")
+			r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+		})
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Allowed  string
+		Host     string
+		Origin   string
+		Expected bool
+	}{
+		{Name: "no origin", Allowed: "https://shop.example.com", Expected: true},
+		{Name: "same origin", Allowed: "https://shop.example.com", Host: "api.example.com", Origin: "https://api.example.com", Expected: true},
+		{Name: "allowed", Allowed: "https://a.example.com, https://shop.example.com", Origin: "https://shop.example.com", Expected: true},
+		{Name: "allowed case-insensitively", Allowed: "https://Shop.example.com", Origin: "https://shop.EXAMPLE.com", Expected: true},
+		{Name: "wildcard pattern", Allowed: "https://*.example.com", Origin: "https://shop.example.com", Expected: true},
+		{Name: "every origin not honoured", Allowed: "*", Origin: "https://evil.example.net", Expected: false},
+		{Name: "listed beside every origin", Allowed: "*, https://shop.example.com", Origin: "https://shop.example.com", Expected: true},
+		{Name: "not allowed", Allowed: "https://shop.example.com", Origin: "https://evil.example.net", Expected: false},
+		{Name: "scheme differs", Allowed: "https://shop.example.com", Origin: "http://shop.example.com", Expected: false},
+		{Name: "none allowed", Allowed: "", Origin: "https://shop.example.com", Expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var svc = newTestService()
+			svc.Viper.Set(EnvVarCORSAllowedOrigins, test.Allowed)
+
+			var r = httptest.NewRequest(http.MethodGet, "/v1/cart/1/ws", nil)
+			if test.Host != "" {
+				r.Host = test.Host
+			}
+			if test.Origin != "" {
+				r.Header.Set("Origin", test.Origin)
+			}
+			require.Equal(t, test.Expected, svc.checkOrigin(r))
+		})
+	}
+}
+
+func TestCartLocks(t *testing.T) {
+	var (
+		locks   = newCartLocks()
+		wg      sync.WaitGroup
+		counter int
+	)
+	for n := 0; n < 50; n++ {
+		wg.Add(1)
+		go func(cartId int) {
+			defer wg.Done()
+			var unlock = locks.lock(cartId)
+			defer unlock()
+			if cartId == 1 {
+				// Unsynchronized increments are serialized by the lock.
+				counter++
+			}
+		}(n % 2)
+	}
+	wg.Wait()
+
+	require.Equal(t, 25, counter)
+	require.Empty(t, locks.locks)
+}
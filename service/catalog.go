@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+
+	"github.com/tjper/shoppingcart-server/service/catalog"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/outbox"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/pkg/errors"
+)
+
+// ImportCatalog upserts the items read by r, in batches of
+// EnvVarCatalogImportBatchSize rows, each in its own transaction. Rows that
+// fail validation are reported and skipped. On a dry run, the report
+// describes the changes the import would make, and nothing is written.
+//
+// If an error is returned, the batches preceding the failing one have been
+// written, as described by the report returned alongside it.
+func (svc *Service) ImportCatalog(ctx context.Context, r catalog.Reader, dryRun bool) (*catalog.Report, error) {
+	var (
+		batchSize = svc.Viper.GetInt(EnvVarCatalogImportBatchSize)
+		report    = catalog.NewReport(dryRun)
+		seen      = make(map[int]int)
+		batch     = make([]catalog.Row, 0, batchSize)
+	)
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+
+		if err := checkCatalogRow(row, seen); err != nil {
+			report.Fail(row, err)
+			continue
+		}
+		batch = append(batch, row)
+		if len(batch) < batchSize {
+			continue
+		}
+		if err := svc.importCatalogBatch(ctx, batch, report); err != nil {
+			return report, err
+		}
+		batch = batch[:0]
+	}
+	if len(batch) > 0 {
+		if err := svc.importCatalogBatch(ctx, batch, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// checkCatalogRow returns the reason row may not be imported, if any. seen
+// maps the ids of the rows accepted so far to their lines, so that an item
+// is not imported twice.
+func checkCatalogRow(row catalog.Row, seen map[int]int) error {
+	if row.Err != nil {
+		return row.Err
+	}
+	if err := catalog.Check(row.Item); err != nil {
+		return err
+	}
+	if row.Item.Id == 0 {
+		return nil
+	}
+	if line, ok := seen[row.Item.Id]; ok {
+		var v validate.Validator
+		v.Check("id", fail(fmt.Sprintf("is a duplicate of line %d", line)))
+		return v.Err()
+	}
+	seen[row.Item.Id] = row.Line
+	return nil
+}
+
+// importCatalogBatch upserts the items of batch in a single transaction,
+// recording their changes in report, and an ItemCreated or ItemUpdated event
+// for each item written in the outbox.
+func (svc *Service) importCatalogBatch(ctx context.Context, batch []catalog.Row, report *catalog.Report) error {
+	var ids = make([]int, 0, len(batch))
+	for _, row := range batch {
+		if row.Item.Id != 0 {
+			ids = append(ids, row.Item.Id)
+		}
+	}
+
+	var changes = make([]catalog.Change, 0, len(batch))
+	err := svc.inTx(ctx, func(tx *sql.Tx) error {
+		items, err := item.FindItems(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+		var existing = make(map[int]*item.Item, len(items))
+		for n := range items {
+			existing[items[n].Id] = &items[n]
+		}
+
+		for _, row := range batch {
+			var c = catalog.Diff(existing[row.Item.Id], row)
+			if !report.DryRun && c.Action != catalog.Unchanged {
+				c.Id, err = item.UpsertItem(ctx, tx, row.Item)
+				if err != nil {
+					return errors.Wrapf(err, "failed to importCatalogBatch\tline=%d", row.Line)
+				}
+				var (
+					typ = item.EventItemUpdated
+					i   = row.Item
+				)
+				if c.Action == catalog.Create {
+					typ = item.EventItemCreated
+				}
+				i.Id = c.Id
+				if err := outbox.Insert(ctx, tx, typ, c.Id, item.ItemEvent{Item: i}); err != nil {
+					return err
+				}
+			}
+			changes = append(changes, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		report.Record(c)
+	}
+	return nil
+}
+
+// ExportCatalog writes every item to w, in id order, and flushes it. The
+// number of items written is returned.
+func (svc *Service) ExportCatalog(ctx context.Context, w catalog.Writer) (int, error) {
+	var n int
+	if err := item.EachItem(ctx, svc.DB, func(i item.Item) error {
+		if err := w.Write(i); err != nil {
+			return err
+		}
+		n++
+		return nil
+	}); err != nil {
+		return n, err
+	}
+	return n, w.Flush()
+}
@@ -0,0 +1,373 @@
+// Package catalog reads and writes items in bulk, as CSV or JSON Lines, and
+// describes the outcome of importing them.
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/pkg/errors"
+)
+
+// The supported formats.
+const (
+	// CSV is comma separated values with a header row naming the columns,
+	// which are the json names of the fields of an item; see record.
+	CSV = "csv"
+
+	// JSONLines is one JSON object per line, with the same fields as CSV.
+	JSONLines = "jsonl"
+)
+
+// maxLineBytes bounds the length of a JSON Lines line.
+const maxLineBytes = 1 << 20
+
+// ErrFormat indicates a format is not supported, or a file is not of its
+// format as a whole, such as a CSV file with an unknown column.
+var ErrFormat = errors.New("catalog: unsupported format")
+
+// FormatOf returns the format of the file name by its extension, or "" if
+// the extension is not of a supported format.
+func FormatOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return CSV
+	case ".jsonl", ".ndjson":
+		return JSONLines
+	}
+	return ""
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	switch format {
+	case CSV:
+		return "text/csv"
+	case JSONLines:
+		return "application/x-ndjson"
+	}
+	return ""
+}
+
+// record is the representation of an item in a catalog file. Fields are
+// named by their json tags, in both formats.
+type record struct {
+	Id          int     `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	MinPerOrder int     `json:"minPerOrder"`
+	MaxPerOrder int     `json:"maxPerOrder"`
+	Step        int     `json:"step"`
+}
+
+// columns are the CSV columns, in the order they are written.
+var columns = []string{"id", "name", "description", "price", "minPerOrder", "maxPerOrder", "step"}
+
+// newRecord returns the record of i, with its base price, not any scheduled
+// price in effect.
+func newRecord(i item.Item) record {
+	var price = i.Price
+	if i.RegularPrice != nil {
+		price = *i.RegularPrice
+	}
+	return record{
+		Id:          i.Id,
+		Name:        i.Name,
+		Description: i.Description,
+		Price:       price,
+		MinPerOrder: i.MinPerOrder,
+		MaxPerOrder: i.MaxPerOrder,
+		Step:        i.Step,
+	}
+}
+
+func (r record) item() item.Item {
+	return item.Item{
+		Id:          r.Id,
+		Name:        r.Name,
+		Description: r.Description,
+		Price:       r.Price,
+		MinPerOrder: r.MinPerOrder,
+		MaxPerOrder: r.MaxPerOrder,
+		Step:        r.Step,
+	}
+}
+
+// Row is an item read from a catalog file. An Id of 0 requests a new item.
+type Row struct {
+	// Line is the line of the file the row was read from, counting from 1.
+	Line int
+
+	Item item.Item
+
+	// Err is non-nil if the row could not be parsed, in which case Item
+	// holds the fields that could be. Err is a validate.Errors if the
+	// failing fields are known.
+	Err error
+}
+
+// Reader reads the rows of a catalog file.
+type Reader interface {
+	// Read returns the next row, or io.EOF once the file is exhausted. Rows
+	// that cannot be parsed are returned with Row.Err set; other errors are
+	// returned as is, and end the file.
+	Read() (Row, error)
+}
+
+// NewReader returns a Reader of the file r of format. If format is not
+// supported, an error wrapping ErrFormat is returned.
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case CSV:
+		var cr = csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		return &csvReader{r: cr}, nil
+	case JSONLines:
+		var s = bufio.NewScanner(r)
+		s.Buffer(make([]byte, 64*1024), maxLineBytes)
+		return &jsonLinesReader{s: s}, nil
+	}
+	return nil, errors.Wrapf(ErrFormat, "failed to NewReader\tformat=%s", format)
+}
+
+// csvReader is the Reader of CSV files.
+type csvReader struct {
+	r *csv.Reader
+
+	// header maps column indexes to column names, once read.
+	header []string
+}
+
+func (r *csvReader) Read() (Row, error) {
+	if r.header == nil {
+		header, err := r.r.Read()
+		if err == io.EOF {
+			return Row{}, io.EOF
+		}
+		if err != nil {
+			return Row{}, errors.Wrapf(ErrFormat, "failed to csvReader.Read header\terr=%v", err)
+		}
+		if err := checkHeader(header); err != nil {
+			return Row{}, err
+		}
+		r.header = header
+	}
+
+	fields, err := r.r.Read()
+	if err == io.EOF {
+		return Row{}, io.EOF
+	}
+	if err, ok := err.(*csv.ParseError); ok {
+		return Row{Line: err.StartLine, Err: errors.Errorf("invalid CSV: %v", err.Err)}, nil
+	}
+	if err != nil {
+		return Row{}, errors.Wrap(err, "failed to csvReader.Read")
+	}
+
+	line, _ := r.r.FieldPos(0)
+	var (
+		row = Row{Line: line}
+		v   validate.Validator
+		rec record
+	)
+	if len(fields) != len(r.header) {
+		v.Check("", fail(fmt.Sprintf("has %d fields, not %d", len(fields), len(r.header))))
+		row.Err = v.Err()
+		return row, nil
+	}
+	for n, value := range fields {
+		var (
+			column = r.header[n]
+			err    error
+		)
+		value = strings.TrimSpace(value)
+		switch column {
+		case "id":
+			rec.Id, err = atoi(value)
+		case "name":
+			rec.Name = value
+		case "description":
+			rec.Description = value
+		case "price":
+			rec.Price, err = atof(value)
+		case "minPerOrder":
+			rec.MinPerOrder, err = atoi(value)
+		case "maxPerOrder":
+			rec.MaxPerOrder, err = atoi(value)
+		case "step":
+			rec.Step, err = atoi(value)
+		}
+		if err != nil {
+			v.Check(column, fail(err.Error()))
+		}
+	}
+	row.Item, row.Err = rec.item(), v.Err()
+	return row, nil
+}
+
+// checkHeader returns an error wrapping ErrFormat unless header names known
+// columns, each at most once, including name.
+func checkHeader(header []string) error {
+	var seen = make(map[string]bool, len(header))
+	for n := range header {
+		var column = strings.TrimSpace(strings.TrimPrefix(header[n], "\uFEFF"))
+		header[n] = column
+		var known bool
+		for _, c := range columns {
+			known = known || c == column
+		}
+		if !known || seen[column] {
+			return errors.Wrapf(ErrFormat, "failed to checkHeader\tcolumn=%q", column)
+		}
+		seen[column] = true
+	}
+	if !seen["name"] {
+		return errors.Wrapf(ErrFormat, "failed to checkHeader: missing column \"name\"\theader=%v", header)
+	}
+	return nil
+}
+
+// atoi parses an integer column; blank is 0.
+func atoi(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("must be an integer")
+	}
+	return i, nil
+}
+
+// atof parses a number column; blank is 0.
+func atof(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, errors.New("must be a number")
+	}
+	return f, nil
+}
+
+// fail returns a validate.Check that always fails with msg.
+func fail(msg string) validate.Check {
+	return func() string { return msg }
+}
+
+// jsonLinesReader is the Reader of JSON Lines files.
+type jsonLinesReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func (r *jsonLinesReader) Read() (Row, error) {
+	for r.s.Scan() {
+		r.line++
+		var b = bytes.TrimSpace(r.s.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		var (
+			rec record
+			d   = json.NewDecoder(bytes.NewReader(b))
+		)
+		d.DisallowUnknownFields()
+		if err := d.Decode(&rec); err != nil {
+			return Row{Line: r.line, Err: errors.Errorf("invalid JSON: %v", err)}, nil
+		}
+		return Row{Line: r.line, Item: rec.item()}, nil
+	}
+	if err := r.s.Err(); err != nil {
+		return Row{}, errors.Wrapf(err, "failed to jsonLinesReader.Read\tline=%d", r.line+1)
+	}
+	return Row{}, io.EOF
+}
+
+// Writer writes items to a catalog file.
+type Writer interface {
+	Write(i item.Item) error
+
+	// Flush writes any buffered data to the underlying file.
+	Flush() error
+}
+
+// NewWriter returns a Writer of a file of format to w. If format is not
+// supported, an error wrapping ErrFormat is returned.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case JSONLines:
+		var bw = bufio.NewWriter(w)
+		return &jsonLinesWriter{w: bw, e: json.NewEncoder(bw)}, nil
+	}
+	return nil, errors.Wrapf(ErrFormat, "failed to NewWriter\tformat=%s", format)
+}
+
+// csvWriter is the Writer of CSV files.
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (w *csvWriter) Write(i item.Item) error {
+	if !w.wroteHeader {
+		if err := w.w.Write(columns); err != nil {
+			return errors.Wrap(err, "failed to csvWriter.Write header")
+		}
+		w.wroteHeader = true
+	}
+	var rec = newRecord(i)
+	if err := w.w.Write([]string{
+		strconv.Itoa(rec.Id),
+		rec.Name,
+		rec.Description,
+		strconv.FormatFloat(rec.Price, 'f', -1, 64),
+		strconv.Itoa(rec.MinPerOrder),
+		strconv.Itoa(rec.MaxPerOrder),
+		strconv.Itoa(rec.Step),
+	}); err != nil {
+		return errors.Wrapf(err, "failed to csvWriter.Write\tid=%v", i.Id)
+	}
+	return nil
+}
+
+func (w *csvWriter) Flush() error {
+	if !w.wroteHeader {
+		// An empty catalog is still a valid file.
+		if err := w.w.Write(columns); err != nil {
+			return errors.Wrap(err, "failed to csvWriter.Flush header")
+		}
+		w.wroteHeader = true
+	}
+	w.w.Flush()
+	return errors.Wrap(w.w.Error(), "failed to csvWriter.Flush")
+}
+
+// jsonLinesWriter is the Writer of JSON Lines files.
+type jsonLinesWriter struct {
+	w *bufio.Writer
+	e *json.Encoder
+}
+
+func (w *jsonLinesWriter) Write(i item.Item) error {
+	return errors.Wrapf(w.e.Encode(newRecord(i)), "failed to jsonLinesWriter.Write\tid=%v", i.Id)
+}
+
+func (w *jsonLinesWriter) Flush() error {
+	return errors.Wrap(w.w.Flush(), "failed to jsonLinesWriter.Flush")
+}
@@ -0,0 +1,234 @@
+package catalog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Format   string
+		File     string
+		Expected []Row
+		Err      error
+	}{
+		{
+			Name:   "csv",
+			Format: CSV,
+			File:   "name,price,id\nApple,1.5,\n\"Pear, green\",2,7\n",
+			Expected: []Row{
+				{Line: 2, Item: item.Item{Name: "Apple", Price: 1.5}},
+				{Line: 3, Item: item.Item{Id: 7, Name: "Pear, green", Price: 2}},
+			},
+		},
+		{
+			Name:   "csv invalid fields",
+			Format: CSV,
+			File:   "name,price,step\nApple,cheap,x\nPear\n",
+			Expected: []Row{
+				{Line: 2, Item: item.Item{Name: "Apple"}, Err: validate.Errors{
+					{Field: "price", Message: "must be a number"},
+					{Field: "step", Message: "must be an integer"},
+				}},
+				{Line: 3, Err: validate.Errors{{Field: "", Message: "has 1 fields, not 3"}}},
+			},
+		},
+		{
+			Name:   "csv unknown column",
+			Format: CSV,
+			File:   "name,colour\nApple,red\n",
+			Err:    ErrFormat,
+		},
+		{
+			Name:   "csv missing name",
+			Format: CSV,
+			File:   "id,price\n1,2\n",
+			Err:    ErrFormat,
+		},
+		{
+			Name:     "csv empty",
+			Format:   CSV,
+			Expected: []Row{},
+		},
+		{
+			Name:   "jsonl",
+			Format: JSONLines,
+			File:   "{\"name\":\"Apple\",\"price\":1.5}\n\n{\"id\":7,\"name\":\"Pear\",\"maxPerOrder\":3}\n",
+			Expected: []Row{
+				{Line: 1, Item: item.Item{Name: "Apple", Price: 1.5}},
+				{Line: 3, Item: item.Item{Id: 7, Name: "Pear", MaxPerOrder: 3}},
+			},
+		},
+		{
+			Name:   "unsupported",
+			Format: "xml",
+			Err:    ErrFormat,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			r, err := NewReader(strings.NewReader(test.File), test.Format)
+			if err != nil {
+				require.Equal(t, test.Err, errors.Cause(err))
+				return
+			}
+
+			var rows = make([]Row, 0)
+			for {
+				row, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					require.Equal(t, test.Err, errors.Cause(err))
+					return
+				}
+				rows = append(rows, row)
+			}
+			require.Nil(t, test.Err)
+			require.Equal(t, test.Expected, rows)
+		})
+	}
+}
+
+func TestReaderInvalidJSON(t *testing.T) {
+	r, err := NewReader(strings.NewReader("{\"name\":\"Apple\",\"colour\":\"red\"}\n{\"name\":\n"), JSONLines)
+	require.Nil(t, err)
+
+	for _, line := range []int{1, 2} {
+		row, err := r.Read()
+		require.Nil(t, err)
+		require.Equal(t, line, row.Line)
+		require.NotNil(t, row.Err)
+	}
+	_, err = r.Read()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	var items = []item.Item{
+		{Id: 1, Name: "Apple", Description: "Crisp, \"red\"\nand sweet", Price: 1.25, MinPerOrder: 2, MaxPerOrder: 10, Step: 2},
+		{Id: 2, Name: "Pear", Price: 0.5},
+	}
+
+	for _, format := range []string{CSV, JSONLines} {
+		t.Run(format, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriter(&b, format)
+			require.Nil(t, err)
+			for _, i := range items {
+				require.Nil(t, w.Write(i))
+			}
+			require.Nil(t, w.Flush())
+
+			r, err := NewReader(&b, format)
+			require.Nil(t, err)
+			for _, i := range items {
+				row, err := r.Read()
+				require.Nil(t, err)
+				require.Nil(t, row.Err)
+				require.Equal(t, i, row.Item)
+			}
+			_, err = r.Read()
+			require.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Item     item.Item
+		Expected []string
+	}{
+		{Name: "valid", Item: item.Item{Name: "Apple", Price: 1, MinPerOrder: 2, MaxPerOrder: 2}},
+		{Name: "missing name", Item: item.Item{Price: 1}, Expected: []string{"name"}},
+		{Name: "negative", Item: item.Item{Id: -1, Name: "Apple", Price: -1, Step: -1}, Expected: []string{"id", "price", "step"}},
+		{Name: "max below min", Item: item.Item{Name: "Apple", MinPerOrder: 3, MaxPerOrder: 2}, Expected: []string{"maxPerOrder"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := Check(test.Item)
+			if len(test.Expected) == 0 {
+				require.Nil(t, err)
+				return
+			}
+			var fields = make([]string, 0)
+			for _, fe := range err.(validate.Errors) {
+				fields = append(fields, fe.Field)
+			}
+			require.Equal(t, test.Expected, fields)
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var old = &item.Item{Id: 1, Name: "Apple", Price: 1}
+	tests := []struct {
+		Name     string
+		Old      *item.Item
+		Item     item.Item
+		Action   string
+		Expected map[string]FieldChange
+	}{
+		{
+			Name:   "create",
+			Item:   item.Item{Name: "Pear", Price: 2},
+			Action: Create,
+			Expected: map[string]FieldChange{
+				"name":        {New: "Pear"},
+				"description": {New: ""},
+				"price":       {New: 2.0},
+				"minPerOrder": {New: 0},
+				"maxPerOrder": {New: 0},
+				"step":        {New: 0},
+			},
+		},
+		{
+			Name:     "update",
+			Old:      old,
+			Item:     item.Item{Id: 1, Name: "Apple", Price: 1.5, Step: 6},
+			Action:   Update,
+			Expected: map[string]FieldChange{"price": {Old: 1.0, New: 1.5}, "step": {Old: 0, New: 6}},
+		},
+		{
+			Name:     "scheduled price in effect",
+			Old:      &item.Item{Id: 1, Name: "Apple", Price: 0.5, RegularPrice: &old.Price},
+			Item:     item.Item{Id: 1, Name: "Apple", Price: 1},
+			Action:   Unchanged,
+			Expected: map[string]FieldChange{},
+		},
+		{
+			Name:     "unchanged",
+			Old:      old,
+			Item:     item.Item{Id: 1, Name: "Apple", Price: 1},
+			Action:   Unchanged,
+			Expected: map[string]FieldChange{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var c = Diff(test.Old, Row{Line: 2, Item: test.Item})
+			require.Equal(t, test.Action, c.Action)
+			require.Equal(t, test.Expected, c.Fields)
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	require.Equal(t, CSV, FormatOf("items.CSV"))
+	require.Equal(t, JSONLines, FormatOf("dir/items.ndjson"))
+	require.Equal(t, "", FormatOf("items.json"))
+}
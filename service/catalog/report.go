@@ -0,0 +1,139 @@
+package catalog
+
+import (
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/validate"
+)
+
+// The actions an import takes on a row.
+const (
+	Create    = "create"
+	Update    = "update"
+	Unchanged = "unchanged"
+)
+
+// Report describes the outcome of an import. For a dry run, it describes
+// the changes the import would have made.
+type Report struct {
+	DryRun    bool `json:"dryRun"`
+	Created   int  `json:"created"`
+	Updated   int  `json:"updated"`
+	Unchanged int  `json:"unchanged"`
+	Failed    int  `json:"failed"`
+
+	// Errors are the rows that failed validation, which were skipped.
+	Errors []RowError `json:"errors"`
+
+	// Changes are the rows that created or updated an item.
+	Changes []Change `json:"changes"`
+}
+
+// NewReport returns an empty Report.
+func NewReport(dryRun bool) *Report {
+	return &Report{
+		DryRun:  dryRun,
+		Errors:  make([]RowError, 0),
+		Changes: make([]Change, 0),
+	}
+}
+
+// Fail records that the row failed validation with err.
+func (r *Report) Fail(row Row, err error) {
+	var v validate.Validator
+	v.Merge("", err)
+	r.Failed++
+	r.Errors = append(r.Errors, RowError{Line: row.Line, Id: row.Item.Id, Errors: v.Err().(validate.Errors)})
+}
+
+// Record records the change of the row. The Id of a created item is the id
+// it was assigned, or 0 on a dry run.
+func (r *Report) Record(c Change) {
+	switch c.Action {
+	case Create:
+		r.Created++
+	case Update:
+		r.Updated++
+	case Unchanged:
+		r.Unchanged++
+		return
+	}
+	r.Changes = append(r.Changes, c)
+}
+
+// RowError is a row that failed validation.
+type RowError struct {
+	Line   int             `json:"line"`
+	Id     int             `json:"id,omitempty"`
+	Errors validate.Errors `json:"errors"`
+}
+
+// Change is the change a row makes to an item.
+type Change struct {
+	Line   int    `json:"line"`
+	Id     int    `json:"id,omitempty"`
+	Action string `json:"action"`
+
+	// Fields are the fields the row changes, by their json names.
+	Fields map[string]FieldChange `json:"fields"`
+}
+
+// FieldChange is the old and new value of a field. Old is nil for items
+// that are created.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Check returns a validate.Errors describing the fields of i that may not be
+// imported, or nil if it may be.
+func Check(i item.Item) error {
+	var v validate.Validator
+	v.Check("id", validate.IntMin(i.Id, 0))
+	v.Check("name", validate.Required(i.Name), validate.MaxLen(i.Name, 255))
+	v.Check("price", validate.FloatRange(i.Price, 0, 1e9))
+	v.Check("minPerOrder", validate.IntMin(i.MinPerOrder, 0))
+	v.Check("maxPerOrder", validate.IntMin(i.MaxPerOrder, 0), func() string {
+		if i.MaxPerOrder > 0 && i.MaxPerOrder < i.MinPerOrder {
+			return "must be at least minPerOrder"
+		}
+		return ""
+	})
+	v.Check("step", validate.IntMin(i.Step, 0))
+	return v.Err()
+}
+
+// Diff returns the Change row makes to old, which is nil if the row creates
+// an item.
+func Diff(old *item.Item, row Row) Change {
+	var (
+		c = Change{Line: row.Line, Id: row.Item.Id, Action: Create, Fields: make(map[string]FieldChange)}
+		o record
+		n = newRecord(row.Item)
+	)
+	if old != nil {
+		c.Action, o = Update, newRecord(*old)
+	}
+	var fields = []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"name", o.Name, n.Name},
+		{"description", o.Description, n.Description},
+		{"price", o.Price, n.Price},
+		{"minPerOrder", o.MinPerOrder, n.MinPerOrder},
+		{"maxPerOrder", o.MaxPerOrder, n.MaxPerOrder},
+		{"step", o.Step, n.Step},
+	}
+	for _, f := range fields {
+		switch {
+		case old == nil:
+			c.Fields[f.name] = FieldChange{New: f.new}
+		case f.old != f.new:
+			c.Fields[f.name] = FieldChange{Old: f.old, New: f.new}
+		}
+	}
+	if old != nil && len(c.Fields) == 0 {
+		c.Action = Unchanged
+	}
+	return c
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/tjper/shoppingcart-server/service/catalog"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// CatalogRoutes defines the catalog import and export REST endpoints. They
+// are restricted to admins.
+func (svc *Service) CatalogRoutes(r chi.Router) {
+	r.Use(svc.requireAdmin)
+	r.Post("/admin/items/import", svc.PostCatalogImportHandler())
+	r.Get("/admin/items/export", svc.GetCatalogExportHandler())
+}
+
+// PostCatalogImportHandler imports items from a CSV or JSON Lines body.
+func (svc *Service) PostCatalogImportHandler() http.HandlerFunc {
+	type Response struct {
+		Report *catalog.Report `json:"report"`
+	}
+	return describe(operation{
+		Id:       "PostCatalogImportHandler",
+		Summary:  "Create or update items from a CSV or JSON Lines body, as given by the format query parameter or the Content-Type (text/csv or application/x-ndjson). Rows without an id create items. Rows failing validation are reported and skipped. With dryRun=true, the changes are reported but not made.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		format, err := catalogFormat(r)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		var dryRun = r.URL.Query().Get("dryRun") == "true"
+
+		reader, err := catalog.NewReader(r.Body, format)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		report, err := svc.ImportCatalog(ctx, reader, dryRun)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(Response{Report: report}); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// GetCatalogExportHandler exports every item as CSV or JSON Lines.
+func (svc *Service) GetCatalogExportHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "GetCatalogExportHandler",
+		Summary: "Retrieve every item, ordered by id, as CSV or JSON Lines, as given by the format query parameter (csv or jsonl, default csv). The file is streamed as it is read, and may be imported as is.",
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		var format = r.URL.Query().Get("format")
+		if format == "" {
+			format = catalog.CSV
+		}
+		writer, err := catalog.NewWriter(w, format)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", catalog.ContentType(format))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "items." + format}))
+		n, err := svc.ExportCatalog(ctx, writer)
+		switch {
+		case err != nil && n == 0:
+			// Nothing has been written, so the error may still be reported.
+			w.Header().Del("Content-Disposition")
+			svc.HandleError(w, err)
+		case err != nil:
+			// The status may have been written with the items before the
+			// error, so the export can only be cut short.
+			svc.Zap.Error("failed to GetCatalogExport/ExportCatalog", zap.Int("written", n), zap.Error(err))
+		}
+	})
+}
+
+// catalogFormat returns the catalog format of the body of r, given by its
+// format query parameter or its Content-Type. If neither identifies a
+// supported format, an error wrapping catalog.ErrFormat is returned.
+func catalogFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case catalog.ContentType(catalog.CSV):
+		return catalog.CSV, nil
+	case catalog.ContentType(catalog.JSONLines), "application/jsonl", "application/jsonlines":
+		return catalog.JSONLines, nil
+	}
+	return "", errors.Wrapf(catalog.ErrFormat, "failed to catalogFormat\tcontentType=%s", r.Header.Get("Content-Type"))
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tjper/shoppingcart-server/service/catalog"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogFormat(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Target      string
+		ContentType string
+		Expected    string
+		Err         error
+	}{
+		{Name: "query", Target: "/admin/items/import?format=jsonl", ContentType: "text/csv", Expected: catalog.JSONLines},
+		{Name: "csv", Target: "/admin/items/import", ContentType: "text/csv; charset=utf-8", Expected: catalog.CSV},
+		{Name: "ndjson", Target: "/admin/items/import", ContentType: "application/x-ndjson", Expected: catalog.JSONLines},
+		{Name: "json", Target: "/admin/items/import", ContentType: "application/json", Err: catalog.ErrFormat},
+		{Name: "none", Target: "/admin/items/import", Err: catalog.ErrFormat},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var r = httptest.NewRequest(http.MethodPost, test.Target, nil)
+			r.Header.Set("Content-Type", test.ContentType)
+			format, err := catalogFormat(r)
+			require.Equal(t, test.Err, errors.Cause(err))
+			require.Equal(t, test.Expected, format)
+		})
+	}
+}
+
+func TestCatalogRoutesForbidden(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		UserId string
+		Status int
+	}{
+		{Name: "import anonymous", Method: http.MethodPost, Path: "/v1/admin/items/import?format=jsonl", Status: http.StatusUnauthorized},
+		{Name: "import customer", Method: http.MethodPost, Path: "/v2/admin/items/import?format=jsonl", UserId: "7", Status: http.StatusForbidden},
+		{Name: "export customer", Method: http.MethodGet, Path: "/v1/admin/items/export", UserId: "7", Status: http.StatusForbidden},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(test.Method, test.Path, strings.NewReader(`{"name": "Imported", "price": 3}`))
+			)
+			if test.UserId != "" {
+				r.Header.Set(headerUserId, test.UserId)
+			}
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/outbox"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultCategoryItemsLimit and maxCategoryItemsLimit bound the number of
+	// items listed per request.
+	defaultCategoryItemsLimit = 50
+	maxCategoryItemsLimit     = 500
+)
+
+// CategoryRoutes defines the category resource REST endpoints. Categories
+// are managed by admins.
+func (svc *Service) CategoryRoutes(r chi.Router) {
+	r.Get("/categories", svc.GetCategoriesHandler())
+	r.Get("/categories/{slug}/items", svc.GetCategoryItemsHandler())
+	r.Group(func(r chi.Router) {
+		r.Use(svc.requireAdmin)
+		r.Post("/admin/categories", svc.PostCategoryHandler())
+		r.Put("/admin/items/{id}/categories", svc.PutItemCategoriesHandler())
+	})
+}
+
+// CategoryRoutesV2 defines the v2 category resource REST endpoints.
+func (svc *Service) CategoryRoutesV2(r chi.Router) {
+	r.Get("/categories", svc.GetCategoriesHandler())
+	r.Get("/categories/{slug}/items", svc.GetCategoryItemsV2Handler())
+	r.Group(func(r chi.Router) {
+		r.Use(svc.requireAdmin)
+		r.Post("/admin/categories", svc.PostCategoryHandler())
+		r.Put("/admin/items/{id}/categories", svc.PutItemCategoriesHandler())
+	})
+}
+
+// GetCategoriesHandler retrieves the category tree.
+func (svc *Service) GetCategoriesHandler() http.HandlerFunc {
+	type Response struct {
+		Categories []item.Category `json:"categories"`
+	}
+	return describe(operation{
+		Id:       "GetCategoriesHandler",
+		Summary:  "Retrieve the category tree. Root categories and the children of each category are in sort order.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		categories, err := item.Categories(ctx, svc.DB)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Categories: item.CategoryTree(categories),
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// GetCategoryItemsHandler retrieves the items of a category.
+func (svc *Service) GetCategoryItemsHandler() http.HandlerFunc {
+	type Response struct {
+		Items []item.Item `json:"items"`
+
+		// NextAfter is the after query parameter that retrieves the next
+		// page, if there may be one.
+		NextAfter int `json:"nextAfter,omitempty"`
+	}
+	return describe(operation{
+		Id:       "GetCategoryItemsHandler",
+		Summary:  "Retrieve the items of a category and its subcategories, ordered by id. Pages are retrieved with the after and limit query parameters.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		items, nextAfter, err := svc.categoryItems(ctx, r)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Items:     items,
+			NextAfter: nextAfter,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// GetCategoryItemsV2Handler retrieves the items of a category.
+func (svc *Service) GetCategoryItemsV2Handler() http.HandlerFunc {
+	type Response struct {
+		Items []itemV2 `json:"items"`
+
+		// NextAfter is the after query parameter that retrieves the next
+		// page, if there may be one.
+		NextAfter int `json:"nextAfter,omitempty"`
+	}
+	return describe(operation{
+		Id:       "GetCategoryItemsV2Handler",
+		Summary:  "Retrieve the items of a category and its subcategories, ordered by id. Pages are retrieved with the after and limit query parameters.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		items, nextAfter, err := svc.categoryItems(ctx, r)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Items:     make([]itemV2, 0, len(items)),
+			NextAfter: nextAfter,
+		}
+		for _, i := range items {
+			resp.Items = append(resp.Items, svc.newItemV2(i))
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// categoryItems retrieves the page of items of the category identified by
+// the slug URL parameter of r, and of its subcategories, requested by the
+// after and limit query parameters of r, and the after query parameter of the
+// next page, if there may be one. If the category does not exist, an error
+// wrapping item.ErrNotFound is returned.
+func (svc *Service) categoryItems(ctx context.Context, r *http.Request) ([]item.Item, int, error) {
+	var after int
+	if param := r.URL.Query().Get("after"); param != "" {
+		var err error
+		after, err = strconv.Atoi(param)
+		if err != nil || after < 0 {
+			return nil, 0, errors.Wrapf(errMalformed, "failed to categoryItems\tafter=%s", param)
+		}
+	}
+	limit, err := queryLimit(r, defaultCategoryItemsLimit, maxCategoryItemsLimit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	categories, err := item.Categories(ctx, svc.DB)
+	if err != nil {
+		return nil, 0, err
+	}
+	var slug = chi.URLParam(r, "slug")
+	var found *item.Category
+	for n := range categories {
+		if categories[n].Slug == slug {
+			found = &categories[n]
+			break
+		}
+	}
+	if found == nil {
+		return nil, 0, errors.Wrapf(item.ErrNotFound, "failed to categoryItems\tslug=%s", slug)
+	}
+
+	items, err := item.CategoryItems(ctx, svc.DB, item.Subtree(categories, found.Id), after, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := svc.withCatalog(ctx, items); err != nil {
+		return nil, 0, err
+	}
+
+	var nextAfter int
+	if len(items) == limit {
+		nextAfter = items[len(items)-1].Id
+	}
+	return items, nextAfter, nil
+}
+
+// PostCategoryHandler creates a category.
+func (svc *Service) PostCategoryHandler() http.HandlerFunc {
+	type (
+		Request struct {
+			// ParentId is the id of the parent category, or omitted for a
+			// root category.
+			ParentId  *int   `json:"parentId"`
+			Slug      string `json:"slug" validate:"required,max=64,regex=^[a-z0-9]+(-[a-z0-9]+)*$"`
+			Name      string `json:"name" validate:"required,max=255"`
+			SortOrder int    `json:"sortOrder"`
+		}
+		Response struct {
+			Category item.Category `json:"category"`
+		}
+	)
+	return describe(operation{
+		Id:       "PostCategoryHandler",
+		Summary:  "Create a category. Slugs are unique, lower case, and hyphen separated.",
+		Request:  Request{},
+		Response: Response{},
+		Status:   http.StatusCreated,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = r.Context()
+			req Request
+		)
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var c = item.Category{
+			ParentId:  req.ParentId,
+			Slug:      req.Slug,
+			Name:      req.Name,
+			SortOrder: req.SortOrder,
+		}
+		if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+			id, err := item.CreateCategory(ctx, tx, c)
+			if err != nil {
+				return err
+			}
+			c.Id = id
+			return outbox.Insert(ctx, tx, item.EventCategoryCreated, id, item.CategoryCreatedEvent{Category: c})
+		}); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		var resp = Response{
+			Category: c,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// PutItemCategoriesHandler replaces the categories of an item.
+func (svc *Service) PutItemCategoriesHandler() http.HandlerFunc {
+	type Request struct {
+		CategoryIds []int `json:"categoryIds"`
+	}
+	return describe(operation{
+		Id:      "PutItemCategoriesHandler",
+		Summary: "Replace the categories of an item.",
+		Request: Request{},
+		Status:  http.StatusNoContent,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = r.Context()
+			req Request
+		)
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+			if err := item.SetItemCategories(ctx, tx, id, req.CategoryIds); err != nil {
+				return err
+			}
+			return outbox.Insert(ctx, tx, item.EventItemCategoriesSet, id, item.ItemCategoriesSetEvent{ItemId: id, CategoryIds: req.CategoryIds})
+		}); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
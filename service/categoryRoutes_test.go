@@ -0,0 +1,42 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryRoutesForbidden(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		Body   string
+		UserId string
+		Status int
+	}{
+		{Name: "create anonymous", Method: http.MethodPost, Path: "/v1/admin/categories", Body: `{"slug": "tea", "name": "Tea"}`, Status: http.StatusUnauthorized},
+		{Name: "create customer", Method: http.MethodPost, Path: "/v2/admin/categories", Body: `{"slug": "tea", "name": "Tea"}`, UserId: "7", Status: http.StatusForbidden},
+		{Name: "assign customer", Method: http.MethodPut, Path: "/v1/admin/items/1/categories", Body: `{"categoryIds": [1]}`, UserId: "7", Status: http.StatusForbidden},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(test.Method, test.Path, strings.NewReader(test.Body))
+			)
+			if test.UserId != "" {
+				r.Header.Set(headerUserId, test.UserId)
+			}
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+		})
+	}
+}
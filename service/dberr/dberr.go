@@ -0,0 +1,43 @@
+// Package dberr translates db errors into the domain errors of the packages
+// that access the db.
+package dberr
+
+import (
+	"database/sql"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// MySQL server error numbers translated to domain errors.
+const (
+	mysqlErDupEntry        = 1062
+	mysqlErRowIsReferenced = 1451
+	mysqlErNoReferencedRow = 1452
+)
+
+// Classify translates err into the domain error it represents: notFound if
+// no rows were found, conflict if a unique key was violated, and invalid if
+// a foreign key was violated. Errors that do not represent a domain error
+// are returned unchanged.
+func Classify(err, notFound, conflict, invalid error) error {
+	if err == sql.ErrNoRows {
+		return errors.Wrap(notFound, err.Error())
+	}
+	if me, ok := err.(*mysql.MySQLError); ok {
+		switch me.Number {
+		case mysqlErDupEntry:
+			return errors.Wrap(conflict, me.Error())
+		case mysqlErRowIsReferenced, mysqlErNoReferencedRow:
+			return errors.Wrap(invalid, me.Error())
+		}
+	}
+	return err
+}
+
+// IsDuplicate reports whether err is the violation of a unique key, which
+// Classify translates to conflict.
+func IsDuplicate(err error) bool {
+	me, ok := err.(*mysql.MySQLError)
+	return ok && me.Number == mysqlErDupEntry
+}
@@ -0,0 +1,68 @@
+package dberr
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	var (
+		errNotFound = errors.New("not found")
+		errConflict = errors.New("conflict")
+		errInvalid  = errors.New("invalid")
+		errOther    = errors.New("other")
+	)
+	tests := []struct {
+		Name     string
+		Err      error
+		Expected error
+	}{
+		{
+			Name:     "No rows",
+			Err:      sql.ErrNoRows,
+			Expected: errNotFound,
+		},
+		{
+			Name:     "Duplicate entry",
+			Err:      &mysql.MySQLError{Number: mysqlErDupEntry},
+			Expected: errConflict,
+		},
+		{
+			Name:     "Row is referenced",
+			Err:      &mysql.MySQLError{Number: mysqlErRowIsReferenced},
+			Expected: errInvalid,
+		},
+		{
+			Name:     "No referenced row",
+			Err:      &mysql.MySQLError{Number: mysqlErNoReferencedRow},
+			Expected: errInvalid,
+		},
+		{
+			Name:     "Other MySQL error",
+			Err:      &mysql.MySQLError{Number: 1064},
+			Expected: &mysql.MySQLError{Number: 1064},
+		},
+		{
+			Name:     "Other error",
+			Err:      errOther,
+			Expected: errOther,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var err = Classify(test.Err, errNotFound, errConflict, errInvalid)
+			assert.Equal(t, test.Expected, errors.Cause(err))
+		})
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	assert.True(t, IsDuplicate(&mysql.MySQLError{Number: mysqlErDupEntry}))
+	assert.False(t, IsDuplicate(&mysql.MySQLError{Number: mysqlErNoReferencedRow}))
+	assert.False(t, IsDuplicate(sql.ErrNoRows))
+}
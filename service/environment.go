@@ -11,10 +11,21 @@ const (
 	// Service will listen on.
 	EnvVarHttpPort = "HTTP_PORT"
 
+	// EnvVarGrpcPort is the key to an env var that specifies the port the cart
+	// Service's gRPC server will listen on.
+	EnvVarGrpcPort = "GRPC_PORT"
+
 	// EnvVarEnvironment is the key to an env var that specifies the service's
 	// current environment.
 	EnvVarEnvironment = "ENVIRONMENT"
 
+	// EnvVarCORSAllowedOrigins is the key to an env var that specifies the
+	// comma separated origins permitted to make cross-origin requests and
+	// open cart session WebSockets, such as https://shop.example.com or
+	// https://*.example.com. * permits every origin to make cross-origin
+	// requests, but not to open WebSockets, which must be listed.
+	EnvVarCORSAllowedOrigins = "CORS_ALLOWED_ORIGINS"
+
 	// EnvVarDbConnStr is the key to an env var that specifies the
 	// database connection string.
 	EnvVarDbConnStr = "DB_CONN_STR"
@@ -27,21 +38,243 @@ const (
 	// possible max number of idle connections in the db connection
 	// pool.
 	EnvVarDbMaxIdleConns = "DB_MAX_IDLE_CONNS"
+
+	// EnvVarCurrency is the key to an env var that specifies the ISO 4217
+	// currency code item prices are denominated in.
+	EnvVarCurrency = "CURRENCY"
+
+	// EnvVarV1DeprecatedAt is the key to an env var that specifies when the v1
+	// API was deprecated, as an RFC 3339 timestamp.
+	EnvVarV1DeprecatedAt = "V1_DEPRECATED_AT"
+
+	// EnvVarV1SunsetAt is the key to an env var that specifies when the v1 API
+	// will stop being served, as an RFC 3339 timestamp.
+	EnvVarV1SunsetAt = "V1_SUNSET_AT"
+
+	// EnvVarGraphQLMaxDepth is the key to an env var that specifies the
+	// maximum selection depth of a GraphQL operation.
+	EnvVarGraphQLMaxDepth = "GRAPHQL_MAX_DEPTH"
+
+	// EnvVarGraphQLMaxComplexity is the key to an env var that specifies the
+	// maximum estimated complexity of a GraphQL operation.
+	EnvVarGraphQLMaxComplexity = "GRAPHQL_MAX_COMPLEXITY"
+
+	// EnvVarEventsReplaySize is the key to an env var that specifies how many
+	// of a cart's most recent events are retained for replay to reconnecting
+	// event stream clients.
+	EnvVarEventsReplaySize = "EVENTS_REPLAY_SIZE"
+
+	// EnvVarEventsRetention is the key to an env var that specifies how long
+	// the events of a cart without event stream clients are retained.
+	EnvVarEventsRetention = "EVENTS_RETENTION"
+
+	// EnvVarEventsKeepAlive is the key to an env var that specifies the
+	// interval at which keep-alive comments are written to event streams, and
+	// pings are sent to cart session WebSockets.
+	EnvVarEventsKeepAlive = "EVENTS_KEEPALIVE"
+
+	// EnvVarOutboxSinks is the key to an env var that specifies the comma
+	// separated sinks outbox events are relayed to: log, file, webhook and
+	// webhooks. The webhook sink POSTs every event to a single URL, whereas
+	// the webhooks sink delivers events to the webhooks registered through
+	// the /webhooks endpoints.
+	EnvVarOutboxSinks = "OUTBOX_SINKS"
+
+	// EnvVarOutboxFile is the key to an env var that specifies the path of
+	// the file the file sink appends outbox events to.
+	EnvVarOutboxFile = "OUTBOX_FILE"
+
+	// EnvVarOutboxWebhookURL is the key to an env var that specifies the URL
+	// the webhook sink POSTs outbox events to.
+	EnvVarOutboxWebhookURL = "OUTBOX_WEBHOOK_URL"
+
+	// EnvVarOutboxMaxAttempts is the key to an env var that specifies how
+	// many times the delivery of an outbox event is attempted before it is
+	// dead-lettered, so that it no longer holds back later events.
+	EnvVarOutboxMaxAttempts = "OUTBOX_MAX_ATTEMPTS"
+
+	// EnvVarOutboxInterval is the key to an env var that specifies how long
+	// the outbox relay waits between polls when no events are pending.
+	EnvVarOutboxInterval = "OUTBOX_INTERVAL"
+
+	// EnvVarOutboxBatchSize is the key to an env var that specifies the
+	// maximum number of outbox events relayed per poll.
+	EnvVarOutboxBatchSize = "OUTBOX_BATCH_SIZE"
+
+	// EnvVarOutboxRetention is the key to an env var that specifies how long
+	// delivered outbox events are kept before they are purged. A retention
+	// of 0 disables purging.
+	EnvVarOutboxRetention = "OUTBOX_RETENTION"
+
+	// EnvVarWebhookTimeout is the key to an env var that specifies how long a
+	// webhook is given to respond to a delivery.
+	EnvVarWebhookTimeout = "WEBHOOK_TIMEOUT"
+
+	// EnvVarWebhookMaxAttempts is the key to an env var that specifies how
+	// many times a webhook delivery is attempted before it is dead-lettered.
+	EnvVarWebhookMaxAttempts = "WEBHOOK_MAX_ATTEMPTS"
+
+	// EnvVarWebhookBackoff is the key to an env var that specifies the delay
+	// before the first retry of a failed webhook delivery. The delay doubles
+	// with each subsequent retry.
+	EnvVarWebhookBackoff = "WEBHOOK_BACKOFF"
+
+	// EnvVarWebhookMaxBackoff is the key to an env var that specifies the
+	// maximum delay between retries of a failed webhook delivery.
+	EnvVarWebhookMaxBackoff = "WEBHOOK_MAX_BACKOFF"
+
+	// EnvVarWebhookInterval is the key to an env var that specifies how long
+	// the webhook dispatcher waits between polls when no deliveries are due.
+	EnvVarWebhookInterval = "WEBHOOK_INTERVAL"
+
+	// EnvVarWebhookBatchSize is the key to an env var that specifies the
+	// maximum number of webhook deliveries attempted per poll.
+	EnvVarWebhookBatchSize = "WEBHOOK_BATCH_SIZE"
+
+	// EnvVarWebhookAllowPrivateHosts is the key to an env var that specifies
+	// whether webhooks may be registered with, and deliveries sent to, hosts
+	// that are not public, such as loopback, private or link-local
+	// addresses. It is meant for development and tests only.
+	EnvVarWebhookAllowPrivateHosts = "WEBHOOK_ALLOW_PRIVATE_HOSTS"
+
+	// EnvVarAbandonedCartIdle is the key to an env var that specifies how
+	// long a non-empty cart must go unmodified to be considered abandoned.
+	EnvVarAbandonedCartIdle = "ABANDONED_CART_IDLE"
+
+	// EnvVarAbandonedCartInterval is the key to an env var that specifies the
+	// interval at which abandoned carts are detected.
+	EnvVarAbandonedCartInterval = "ABANDONED_CART_INTERVAL"
+
+	// EnvVarAbandonedCartBatchSize is the key to an env var that specifies
+	// the maximum number of idle carts read at a time while detecting
+	// abandoned carts.
+	EnvVarAbandonedCartBatchSize = "ABANDONED_CART_BATCH_SIZE"
+
+	// EnvVarCartTTL is the key to an env var that specifies how long the
+	// cart of a user may go unmodified before it is purged. A TTL of 0
+	// disables purging user carts.
+	EnvVarCartTTL = "CART_TTL"
+
+	// EnvVarGuestCartTTL is the key to an env var that specifies how long
+	// the cart of a guest may go unmodified before it is purged. A TTL of 0
+	// disables purging guest carts.
+	EnvVarGuestCartTTL = "GUEST_CART_TTL"
+
+	// EnvVarCartPurgeInterval is the key to an env var that specifies the
+	// interval at which expired carts are purged.
+	EnvVarCartPurgeInterval = "CART_PURGE_INTERVAL"
+
+	// EnvVarCartPurgeBatchSize is the key to an env var that specifies the
+	// maximum number of cart lines deleted per transaction, and of expired
+	// carts read at a time, while purging.
+	EnvVarCartPurgeBatchSize = "CART_PURGE_BATCH_SIZE"
+
+	// EnvVarCartUndoWindow is the key to an env var that specifies how long
+	// a deleted cart item may be restored. Tombstones of cart items deleted
+	// earlier are purged with expired carts.
+	EnvVarCartUndoWindow = "CART_UNDO_WINDOW"
+
+	// EnvVarCartMaxLines is the key to an env var that specifies the maximum
+	// number of distinct lines a cart may hold. A maximum of 0 imposes no
+	// limit.
+	EnvVarCartMaxLines = "CART_MAX_LINES"
+
+	// EnvVarIdempotencyTTL is the key to an env var that specifies how long
+	// the response to a request made with an Idempotency-Key is replayed for
+	// retries.
+	EnvVarIdempotencyTTL = "IDEMPOTENCY_TTL"
+
+	// EnvVarIdempotencyLockTimeout is the key to an env var that specifies
+	// how long a request made with an Idempotency-Key holds the key before
+	// it is assumed to have been abandoned and may be retried.
+	EnvVarIdempotencyLockTimeout = "IDEMPOTENCY_LOCK_TIMEOUT"
+
+	// EnvVarIdempotencyWait is the key to an env var that specifies how long
+	// a retry waits for the response to a request in progress with the same
+	// Idempotency-Key before it is rejected.
+	EnvVarIdempotencyWait = "IDEMPOTENCY_WAIT"
+
+	// EnvVarBlobDir is the key to an env var that specifies the directory
+	// the local blob store keeps item images in.
+	EnvVarBlobDir = "BLOB_DIR"
+
+	// EnvVarBlobBaseURL is the key to an env var that specifies the URL
+	// beneath which blobs are served. The service serves blobs beneath
+	// /media itself.
+	EnvVarBlobBaseURL = "BLOB_BASE_URL"
+
+	// EnvVarMediaMaxBytes is the key to an env var that specifies the
+	// maximum size of an uploaded item image.
+	EnvVarMediaMaxBytes = "MEDIA_MAX_BYTES"
+
+	// EnvVarMediaThumbnailSizes is the key to an env var that specifies the
+	// comma separated sizes of the thumbnails generated for each uploaded
+	// item image. A thumbnail fits within a square of its size in pixels.
+	EnvVarMediaThumbnailSizes = "MEDIA_THUMBNAIL_SIZES"
+
+	// EnvVarCatalogImportBatchSize is the key to an env var that specifies
+	// the number of rows of a catalog import written per transaction.
+	EnvVarCatalogImportBatchSize = "CATALOG_IMPORT_BATCH_SIZE"
 )
 
 const (
 	prod = "prod"
 	dev  = "dev"
 
-	port    = ":8080"
-	connStr = "admin:password@tcp(localhost:3306)/shoppingcart-db?tls=false&timeout=30s"
+	port     = ":8080"
+	grpcPort = ":9090"
+	connStr  = "admin:password@tcp(localhost:3306)/shoppingcart-db?tls=false&timeout=30s"
+
+	currency     = "USD"
+	v1Deprecated = "2026-10-19T00:00:00Z"
+	v1Sunset     = "2027-10-19T00:00:00Z"
 )
 
 func ViperDefaults(v *viper.Viper) *viper.Viper {
 	v.SetDefault(EnvVarEnvironment, dev)
 	v.SetDefault(EnvVarHttpPort, port)
+	v.SetDefault(EnvVarGrpcPort, grpcPort)
+	v.SetDefault(EnvVarCORSAllowedOrigins, "*")
 	v.SetDefault(EnvVarDbConnStr, connStr)
 	v.SetDefault(EnvVarDbMaxOpenConns, 8)
 	v.SetDefault(EnvVarDbMaxIdleConns, 0)
+	v.SetDefault(EnvVarCurrency, currency)
+	v.SetDefault(EnvVarV1DeprecatedAt, v1Deprecated)
+	v.SetDefault(EnvVarV1SunsetAt, v1Sunset)
+	v.SetDefault(EnvVarGraphQLMaxDepth, 8)
+	v.SetDefault(EnvVarGraphQLMaxComplexity, 1000)
+	v.SetDefault(EnvVarEventsReplaySize, 64)
+	v.SetDefault(EnvVarEventsRetention, "5m")
+	v.SetDefault(EnvVarEventsKeepAlive, "15s")
+	v.SetDefault(EnvVarOutboxSinks, "log,webhooks")
+	v.SetDefault(EnvVarOutboxFile, "outbox.jsonl")
+	v.SetDefault(EnvVarOutboxMaxAttempts, 10)
+	v.SetDefault(EnvVarOutboxInterval, "1s")
+	v.SetDefault(EnvVarOutboxBatchSize, 100)
+	v.SetDefault(EnvVarOutboxRetention, "168h")
+	v.SetDefault(EnvVarWebhookTimeout, "10s")
+	v.SetDefault(EnvVarWebhookMaxAttempts, 8)
+	v.SetDefault(EnvVarWebhookBackoff, "10s")
+	v.SetDefault(EnvVarWebhookMaxBackoff, "1h")
+	v.SetDefault(EnvVarWebhookInterval, "1s")
+	v.SetDefault(EnvVarWebhookBatchSize, 50)
+	v.SetDefault(EnvVarWebhookAllowPrivateHosts, false)
+	v.SetDefault(EnvVarAbandonedCartIdle, "24h")
+	v.SetDefault(EnvVarAbandonedCartInterval, "15m")
+	v.SetDefault(EnvVarAbandonedCartBatchSize, 100)
+	v.SetDefault(EnvVarCartTTL, "720h")
+	v.SetDefault(EnvVarGuestCartTTL, "168h")
+	v.SetDefault(EnvVarCartPurgeInterval, "1h")
+	v.SetDefault(EnvVarCartPurgeBatchSize, 500)
+	v.SetDefault(EnvVarCartUndoWindow, "10m")
+	v.SetDefault(EnvVarCartMaxLines, 100)
+	v.SetDefault(EnvVarIdempotencyTTL, "24h")
+	v.SetDefault(EnvVarIdempotencyLockTimeout, "1m")
+	v.SetDefault(EnvVarIdempotencyWait, "5s")
+	v.SetDefault(EnvVarBlobDir, "blobs")
+	v.SetDefault(EnvVarBlobBaseURL, "/media")
+	v.SetDefault(EnvVarMediaMaxBytes, 10<<20)
+	v.SetDefault(EnvVarMediaThumbnailSizes, "128,512,1024")
+	v.SetDefault(EnvVarCatalogImportBatchSize, 500)
 	return v
 }
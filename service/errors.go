@@ -0,0 +1,112 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/tjper/shoppingcart-server/service/blob"
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/catalog"
+	"github.com/tjper/shoppingcart-server/service/idempotency"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/validate"
+	"github.com/tjper/shoppingcart-server/service/webhook"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// errMalformed indicates the request could not be parsed, such as a body
+	// that is not valid JSON or a non-numeric path parameter.
+	errMalformed = errors.New("malformed request")
+
+	// errInvalid indicates the request was parsed but is semantically
+	// invalid. Requests that fail field validation are reported with
+	// validate.Errors instead.
+	errInvalid = errors.New("invalid request")
+
+	// errUnauthenticated indicates the request did not identify the user
+	// making it.
+	errUnauthenticated = errors.New("unauthenticated")
+
+	// errForbidden indicates the user making the request may not access the
+	// resource requested.
+	errForbidden = errors.New("forbidden")
+
+	// errUnsupportedVersion indicates the request specified an API version
+	// the service does not serve.
+	errUnsupportedVersion = errors.New("unsupported api version")
+
+	// errPreconditionFailed indicates the resource the request would modify
+	// no longer matches the version the request was conditioned on.
+	errPreconditionFailed = errors.New("precondition failed")
+)
+
+// statusCode maps err to the HTTP status code that best describes it. Errors
+// that are not domain errors are treated as internal server errors.
+func statusCode(err error) int {
+	var cause = errors.Cause(err)
+	if _, ok := cause.(validate.Errors); ok {
+		return http.StatusUnprocessableEntity
+	}
+	switch cause {
+	case errMalformed, blob.ErrInvalidKey, catalog.ErrFormat:
+		return http.StatusBadRequest
+	case errUnauthenticated:
+		return http.StatusUnauthorized
+	case errForbidden:
+		return http.StatusForbidden
+	case cart.ErrNotFound, item.ErrNotFound, webhook.ErrNotFound, blob.ErrNotFound:
+		return http.StatusNotFound
+	case cart.ErrConflict, item.ErrConflict, idempotency.ErrInProgress:
+		return http.StatusConflict
+	case cart.ErrExpired:
+		return http.StatusGone
+	case errPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case errInvalid, cart.ErrInvalid, item.ErrInvalid, idempotency.ErrMismatch:
+		return http.StatusUnprocessableEntity
+	case errUnsupportedVersion:
+		return http.StatusNotAcceptable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ErrorResponse is the JSON body written to the client when a request fails.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes why a request failed.
+type ErrorBody struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+
+	// Fields lists each request field that failed validation, if any.
+	Fields []validate.FieldError `json:"fields,omitempty"`
+}
+
+// errorBody returns the ErrorBody that describes err to the client. The
+// details of internal errors are withheld.
+func errorBody(err error) ErrorBody {
+	var code = statusCode(err)
+	if code == http.StatusInternalServerError {
+		return ErrorBody{Status: code, Message: http.StatusText(code)}
+	}
+	if fields, ok := errors.Cause(err).(validate.Errors); ok {
+		return ErrorBody{Status: code, Message: "validation failed", Fields: fields}
+	}
+	return ErrorBody{Status: code, Message: errors.Cause(err).Error()}
+}
+
+// HandleError writes the status code and JSON error body that correspond to
+// err to the client.
+func (svc Service) HandleError(w http.ResponseWriter, err error) {
+	var body = errorBody(err)
+	if body.Status >= http.StatusInternalServerError {
+		svc.Zap.Error(err.Error())
+	} else {
+		svc.Zap.Debug(err.Error())
+	}
+	svc.writeError(w, err, body)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tjper/shoppingcart-server/service/blob"
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/catalog"
+	"github.com/tjper/shoppingcart-server/service/idempotency"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/validate"
+	"github.com/tjper/shoppingcart-server/service/webhook"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestService() *Service {
+	var svc = New(ViperDefaults(viper.New()))
+	svc.Zap = zap.NewNop()
+	return svc
+}
+
+// asAdmin sets the headers the gateway sets on the requests of an admin.
+func asAdmin(r *http.Request) *http.Request {
+	r.Header.Set(headerUserId, "1")
+	r.Header.Set(headerUserRoles, roleAdmin)
+	return r
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Err      error
+		Expected int
+	}{
+		{Name: "malformed", Err: errMalformed, Expected: http.StatusBadRequest},
+		{Name: "invalid", Err: errInvalid, Expected: http.StatusUnprocessableEntity},
+		{Name: "unauthenticated", Err: errUnauthenticated, Expected: http.StatusUnauthorized},
+		{Name: "forbidden", Err: errForbidden, Expected: http.StatusForbidden},
+		{Name: "cart not found", Err: cart.ErrNotFound, Expected: http.StatusNotFound},
+		{Name: "cart conflict", Err: cart.ErrConflict, Expected: http.StatusConflict},
+		{Name: "blob not found", Err: blob.ErrNotFound, Expected: http.StatusNotFound},
+		{Name: "blob invalid key", Err: blob.ErrInvalidKey, Expected: http.StatusBadRequest},
+		{Name: "catalog format", Err: catalog.ErrFormat, Expected: http.StatusBadRequest},
+		{Name: "cart expired", Err: cart.ErrExpired, Expected: http.StatusGone},
+		{Name: "precondition failed", Err: errPreconditionFailed, Expected: http.StatusPreconditionFailed},
+		{Name: "idempotency in progress", Err: idempotency.ErrInProgress, Expected: http.StatusConflict},
+		{Name: "idempotency mismatch", Err: idempotency.ErrMismatch, Expected: http.StatusUnprocessableEntity},
+		{Name: "cart invalid", Err: cart.ErrInvalid, Expected: http.StatusUnprocessableEntity},
+		{Name: "item not found", Err: item.ErrNotFound, Expected: http.StatusNotFound},
+		{Name: "webhook not found", Err: webhook.ErrNotFound, Expected: http.StatusNotFound},
+		{Name: "item conflict", Err: item.ErrConflict, Expected: http.StatusConflict},
+		{Name: "item invalid", Err: item.ErrInvalid, Expected: http.StatusUnprocessableEntity},
+		{
+			Name:     "wrapped cart not found",
+			Err:      errors.Wrap(errors.Wrap(cart.ErrNotFound, sql.ErrNoRows.Error()), "failed to DeleteCartItem"),
+			Expected: http.StatusNotFound,
+		},
+		{Name: "validation", Err: validate.Errors{{Field: "count", Message: "is required"}}, Expected: http.StatusUnprocessableEntity},
+		{Name: "unmapped", Err: errors.New("boom"), Expected: http.StatusInternalServerError},
+		{Name: "raw no rows", Err: sql.ErrNoRows, Expected: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Expected, statusCode(test.Err))
+		})
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	var svc = newTestService()
+
+	tests := []struct {
+		Name            string
+		Err             error
+		ExpectedStatus  int
+		ExpectedMessage string
+	}{
+		{
+			Name:            "not found",
+			Err:             errors.Wrap(cart.ErrNotFound, "failed to FindCartItem"),
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedMessage: cart.ErrNotFound.Error(),
+		},
+		{
+			Name:            "internal",
+			Err:             errors.New("dial tcp: connection refused"),
+			ExpectedStatus:  http.StatusInternalServerError,
+			ExpectedMessage: http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var w = httptest.NewRecorder()
+			svc.HandleError(w, test.Err)
+
+			require.Equal(t, test.ExpectedStatus, w.Code)
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			var resp ErrorResponse
+			require.Nil(t, json.NewDecoder(w.Body).Decode(&resp))
+			require.Equal(t, test.ExpectedStatus, resp.Error.Status)
+			require.Equal(t, test.ExpectedMessage, resp.Error.Message)
+		})
+	}
+}
+
+func TestCartHandlersRequestErrors(t *testing.T) {
+	var svc = newTestService()
+	svc.Router = chi.NewRouter()
+	svc.CartRoutes(svc.Router)
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Target         string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "POST malformed JSON",
+			Method:         http.MethodPost,
+			Target:         "/cart/item",
+			Body:           `{"itemId": 1,`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "POST invalid count",
+			Method:         http.MethodPost,
+			Target:         "/cart/item",
+			Body:           `{"itemId": 1, "userId": 1, "count": 0}`,
+			ExpectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:           "PUT malformed JSON",
+			Method:         http.MethodPut,
+			Target:         "/cart/item/1",
+			Body:           `count=1`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "PUT malformed id",
+			Method:         http.MethodPut,
+			Target:         "/cart/item/abc",
+			Body:           `{"itemId": 1, "userId": 1, "count": 1}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "GET malformed userId",
+			Method:         http.MethodGet,
+			Target:         "/cart/abc",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "DELETE zero id",
+			Method:         http.MethodDelete,
+			Target:         "/cart/item/0",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(test.Method, test.Target, strings.NewReader(test.Body))
+			)
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.ExpectedStatus, w.Code)
+		})
+	}
+}
+
+func TestHandleErrorValidationFields(t *testing.T) {
+	var (
+		svc = newTestService()
+		w   = httptest.NewRecorder()
+	)
+	svc.HandleError(w, validate.Struct(struct {
+		ItemId int `json:"itemId" validate:"required"`
+		Count  int `json:"count" validate:"min=1"`
+	}{}))
+
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var resp ErrorResponse
+	require.Nil(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, []validate.FieldError{
+		{Field: "itemId", Message: "is required"},
+		{Field: "count", Message: "must be greater than or equal to 1"},
+	}, resp.Error.Fields)
+}
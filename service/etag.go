@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+
+	"github.com/pkg/errors"
+)
+
+// cartItemETag returns the entity tag of a cart item at version.
+func cartItemETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// cartETag returns the entity tag of the cart at version v holding
+// cartItems. The time of the modification is included because a cart's
+// version restarts once it is purged, and the prices of the cart items
+// because they change as scheduled prices take effect, without modifying the
+// cart.
+func cartETag(v cart.Version, cartItems []cart.CartItem) string {
+	var h = fnv.New32a()
+	for _, ci := range cartItems {
+		fmt.Fprintf(h, "%d:%v;", ci.Id, ci.Item.Price)
+	}
+	return fmt.Sprintf(`"%d.%d.%08x"`, v.Version, v.ModifiedAt.UnixNano(), h.Sum32())
+}
+
+// etagMatches reports whether etag is in the comma separated list of entity
+// tags header. Weak tags match only if weak is true, per the weak comparison
+// of RFC 7232; "*" matches any etag.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified reports whether the If-None-Match header of r matches etag, in
+// which case the client's representation is current.
+func notModified(r *http.Request, etag string) bool {
+	var header = r.Header.Get("If-None-Match")
+	return header != "" && etagMatches(header, etag, true)
+}
+
+type ifMatchKey struct{}
+
+// withIfMatch returns a copy of ctx carrying the If-Match header of r, if
+// any, to be checked by checkIfMatch against the resource r modifies.
+func withIfMatch(ctx context.Context, r *http.Request) context.Context {
+	var header = r.Header.Get("If-Match")
+	if header == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ifMatchKey{}, header)
+}
+
+// checkIfMatch returns an error wrapping errPreconditionFailed if ctx carries
+// an If-Match header that etag, the entity tag of the resource about to be
+// modified, does not match. Modifications made without If-Match are
+// unconditional.
+func checkIfMatch(ctx context.Context, etag string) error {
+	header, ok := ctx.Value(ifMatchKey{}).(string)
+	if !ok || etagMatches(header, etag, false) {
+		return nil
+	}
+	return errors.Wrapf(errPreconditionFailed, "failed to checkIfMatch\tif-match=%s\tetag=%s", header, etag)
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/item"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Header   string
+		Weak     bool
+		Expected bool
+	}{
+		{Name: "match", Header: `"2"`, Expected: true},
+		{Name: "mismatch", Header: `"1"`, Expected: false},
+		{Name: "list", Header: `"1", "2"`, Expected: true},
+		{Name: "any", Header: `*`, Expected: true},
+		{Name: "weak strong comparison", Header: `W/"2"`, Expected: false},
+		{Name: "weak weak comparison", Header: `W/"2"`, Weak: true, Expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Expected, etagMatches(test.Header, `"2"`, test.Weak))
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	var r = httptest.NewRequest("PUT", "/cart/item/1", nil)
+	require.Nil(t, checkIfMatch(withIfMatch(context.Background(), r), cartItemETag(1)))
+
+	r.Header.Set("If-Match", cartItemETag(1))
+	var ctx = withIfMatch(context.Background(), r)
+	require.Nil(t, checkIfMatch(ctx, cartItemETag(1)))
+	require.Equal(t, errPreconditionFailed, errors.Cause(checkIfMatch(ctx, cartItemETag(2))))
+}
+
+func TestCartETag(t *testing.T) {
+	var (
+		v      = cart.Version{Version: 3, ModifiedAt: time.Unix(100, 0)}
+		before = []cart.CartItem{{Id: 1, Item: item.Item{Price: 2}}, {Id: 2, Item: item.Item{Price: 3}}}
+		after  = []cart.CartItem{{Id: 1, Item: item.Item{Price: 1.5}}, {Id: 2, Item: item.Item{Price: 3}}}
+	)
+	require.Equal(t, cartETag(v, before), cartETag(v, before))
+	require.NotEqual(t, cartETag(v, before), cartETag(v, after))
+	require.NotEqual(t, cartETag(v, before), cartETag(cart.Version{Version: 4, ModifiedAt: v.ModifiedAt}, before))
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/pkg/errors"
+)
+
+// graphqlListCost is the factor the cost of a list field's selections is
+// multiplied by, approximating the number of elements a list resolves to.
+const graphqlListCost = 10
+
+// queryCost measures the depth and complexity of a GraphQL operation.
+// Complexity counts every selected field once, except that the selections
+// of list fields are counted graphqlListCost times.
+type queryCost struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+}
+
+// measureQuery returns the depth and complexity of the operation named
+// operationName in doc. If operationName is empty, doc must contain a single
+// operation.
+func measureQuery(schema *graphql.Schema, doc *ast.Document, operationName string) (depth int, complexity int, err error) {
+	var (
+		qc = queryCost{
+			schema:    schema,
+			fragments: make(map[string]*ast.FragmentDefinition),
+		}
+		op *ast.OperationDefinition
+	)
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			qc.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				if op != nil {
+					return 0, 0, errors.Wrap(errMalformed, "failed to measureQuery, operationName required for multiple operations")
+				}
+				op = def
+			}
+		}
+	}
+	if op == nil {
+		return 0, 0, errors.Wrapf(errMalformed, "failed to measureQuery, unknown operation\toperationName=%s", operationName)
+	}
+
+	var root graphql.Type = schema.QueryType()
+	if op.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	depth, complexity = qc.selectionSet(op.SelectionSet, root, make(map[string]bool))
+	return depth, complexity, nil
+}
+
+func (qc queryCost) selectionSet(ss *ast.SelectionSet, parent graphql.Type, visiting map[string]bool) (depth int, complexity int) {
+	if ss == nil {
+		return 0, 0
+	}
+	for _, selection := range ss.Selections {
+		var d, c int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, c = qc.field(selection, parent, visiting)
+		case *ast.InlineFragment:
+			d, c = qc.selectionSet(selection.SelectionSet, qc.typeCondition(selection.TypeCondition, parent), visiting)
+		case *ast.FragmentSpread:
+			var name = selection.Name.Value
+			fragment, ok := qc.fragments[name]
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			d, c = qc.selectionSet(fragment.SelectionSet, qc.typeCondition(fragment.TypeCondition, parent), visiting)
+			delete(visiting, name)
+		}
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (qc queryCost) field(field *ast.Field, parent graphql.Type, visiting map[string]bool) (depth int, complexity int) {
+	var (
+		fieldType graphql.Type
+		isList    bool
+	)
+	if fields, ok := parent.(interface {
+		Fields() graphql.FieldDefinitionMap
+	}); ok {
+		if def, ok := fields.Fields()[field.Name.Value]; ok {
+			fieldType = def.Type
+		}
+	}
+	if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+		fieldType = nonNull.OfType
+	}
+	if list, ok := fieldType.(*graphql.List); ok {
+		fieldType, isList = list.OfType, true
+	}
+	if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+		fieldType = nonNull.OfType
+	}
+
+	depth, complexity = qc.selectionSet(field.SelectionSet, fieldType, visiting)
+	if isList {
+		complexity *= graphqlListCost
+	}
+	return depth + 1, complexity + 1
+}
+
+func (qc queryCost) typeCondition(named *ast.Named, parent graphql.Type) graphql.Type {
+	if named == nil {
+		return parent
+	}
+	return qc.schema.Type(named.Name.Value)
+}
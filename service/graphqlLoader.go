@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tjper/shoppingcart-server/service/item"
+
+	"github.com/pkg/errors"
+)
+
+// itemBatchFn retrieves the items with the ids passed in a single round
+// trip.
+type itemBatchFn func(ctx context.Context, ids []int) ([]item.Item, error)
+
+// itemLoader batches and caches the item lookups of a single GraphQL request.
+// Load registers an id and returns a thunk; the GraphQL executor resolves
+// thunks only after every field at the same depth has been resolved, so the
+// first thunk invoked retrieves every id registered at that depth at once.
+type itemLoader struct {
+	ctx   context.Context
+	batch itemBatchFn
+
+	mu      sync.Mutex
+	pending []int
+	cache   map[int]*item.Item
+}
+
+func newItemLoader(ctx context.Context, batch itemBatchFn) *itemLoader {
+	return &itemLoader{
+		ctx:   ctx,
+		batch: batch,
+		cache: make(map[int]*item.Item),
+	}
+}
+
+// Load registers id to be retrieved with the next batch, and returns a thunk
+// that resolves to the item.Item with said id.
+func (l *itemLoader) Load(id int) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[id]; !ok {
+		l.pending = append(l.pending, id)
+		l.cache[id] = nil
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if err := l.dispatch(); err != nil {
+			return nil, err
+		}
+		found := l.cache[id]
+		if found == nil {
+			return nil, errors.Wrapf(item.ErrNotFound, "failed to itemLoader.Load\tid=%v", id)
+		}
+		return *found, nil
+	}
+}
+
+// dispatch retrieves every pending id. l.mu must be held.
+func (l *itemLoader) dispatch() error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+	var ids = l.pending
+	l.pending = nil
+
+	items, err := l.batch(l.ctx, ids)
+	if err != nil {
+		for _, id := range ids {
+			delete(l.cache, id)
+		}
+		return err
+	}
+	for i := range items {
+		l.cache[items[i].Id] = &items[i]
+	}
+	return nil
+}
+
+type itemLoaderCtxKey struct{}
+
+// withItemLoader returns a copy of ctx carrying a new itemLoader.
+func (svc *Service) withItemLoader(ctx context.Context) context.Context {
+	var batch = func(ctx context.Context, ids []int) ([]item.Item, error) {
+		return item.FindItems(ctx, svc.DB, ids)
+	}
+	return context.WithValue(ctx, itemLoaderCtxKey{}, newItemLoader(ctx, batch))
+}
+
+// itemLoaderFrom returns the itemLoader carried by ctx.
+func itemLoaderFrom(ctx context.Context) *itemLoader {
+	return ctx.Value(itemLoaderCtxKey{}).(*itemLoader)
+}
@@ -0,0 +1,459 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/go-chi/chi"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/pkg/errors"
+)
+
+// GraphQLRoutes defines the GraphQL endpoint.
+func (svc *Service) GraphQLRoutes(r chi.Router) {
+	r.Post("/graphql", svc.PostGraphQLHandler())
+}
+
+// graphqlError is a resolver error reported with the HTTP status and code
+// that correspond to it in the error's extensions.
+type graphqlError struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (e graphqlError) Error() string                      { return e.message }
+func (e graphqlError) Extensions() map[string]interface{} { return e.extensions }
+
+// graphqlErr converts err to a graphqlError. Internal errors are logged and
+// their details withheld from the client.
+func (svc *Service) graphqlErr(err error) error {
+	var code = statusCode(err)
+	var extensions = map[string]interface{}{
+		"status": code,
+		"code":   strings.ToUpper(strings.Replace(http.StatusText(code), " ", "_", -1)),
+	}
+	if code == http.StatusInternalServerError {
+		svc.Zap.Error(err.Error())
+		return graphqlError{message: "internal error", extensions: extensions}
+	}
+	svc.Zap.Debug(err.Error())
+
+	var cause = errors.Cause(err)
+	if fields, ok := cause.(validate.Errors); ok {
+		extensions["fields"] = fields
+		return graphqlError{message: "validation failed", extensions: extensions}
+	}
+	return graphqlError{message: cause.Error(), extensions: extensions}
+}
+
+// graphqlLimitErr returns a graphqlError reporting that an operation
+// exceeds a configured limit.
+func graphqlLimitErr(format string, args ...interface{}) error {
+	return graphqlError{
+		message: fmt.Sprintf(format, args...),
+		extensions: map[string]interface{}{
+			"status": http.StatusUnprocessableEntity,
+			"code":   "UNPROCESSABLE_ENTITY",
+		},
+	}
+}
+
+// resolver wraps fn so that its errors, and the errors of the thunks it
+// returns, are converted by graphqlErr.
+func (svc *Service) resolver(fn graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		res, err := fn(p)
+		if err != nil {
+			return nil, svc.graphqlErr(err)
+		}
+		if thunk, ok := res.(func() (interface{}, error)); ok {
+			return func() (interface{}, error) {
+				res, err := thunk()
+				if err != nil {
+					return nil, svc.graphqlErr(err)
+				}
+				return res, nil
+			}, nil
+		}
+		return res, nil
+	}
+}
+
+// graphqlCart is the source value of the GraphQL Cart type.
+type graphqlCart struct {
+	cartV2
+	cartItems []cart.CartItem
+}
+
+// graphqlSchema builds the GraphQL schema served by PostGraphQLHandler.
+func (svc *Service) graphqlSchema() (graphql.Schema, error) {
+	var moneyType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Money",
+		Description: "An amount of a currency in the currency's minor unit, such as cents.",
+		Fields: graphql.Fields{
+			"amount":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"currency": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	var itemType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Item",
+		Description: "An item that may be purchased.",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"price":       &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+		},
+	})
+
+	var variantType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Variant",
+		Description: "A purchasable option of an item, such as a size or color.",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"sku":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"stock": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"price": &graphql.Field{
+				Type:        moneyType,
+				Description: "The price of the variant, if it overrides the price of its item.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var v = p.Source.(variantV2)
+					if v.Price == nil {
+						return nil, nil
+					}
+					return *v.Price, nil
+				},
+			},
+		},
+	})
+
+	var cartItemType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "CartItem",
+		Description: "An item that belongs to a cart.",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"count": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"item": &graphql.Field{
+				Type: graphql.NewNonNull(itemType),
+				Resolve: svc.resolver(func(p graphql.ResolveParams) (interface{}, error) {
+					var ci = p.Source.(cart.CartItem)
+					var load = itemLoaderFrom(p.Context).Load(ci.Item.Id)
+					return func() (interface{}, error) {
+						found, err := load()
+						if err != nil {
+							return nil, err
+						}
+						return svc.newItemV2(found.(item.Item)), nil
+					}, nil
+				}),
+			},
+			"variant": &graphql.Field{
+				Type:        variantType,
+				Description: "The variant of the item, if any.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var ci = p.Source.(cart.CartItem)
+					if ci.Variant == nil {
+						return nil, nil
+					}
+					return svc.newVariantV2(*ci.Variant), nil
+				},
+			},
+			"total": &graphql.Field{
+				Type:        graphql.NewNonNull(moneyType),
+				Description: "The price of the item, or of its variant if any, multiplied by count.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return svc.newCartItemV2(p.Source.(cart.CartItem)).Total, nil
+				},
+			},
+		},
+	})
+
+	var cartType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Cart",
+		Description: "A user's cart.",
+		Fields: graphql.Fields{
+			"userId": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(graphqlCart).UserId, nil
+				},
+			},
+			"cartItems": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(cartItemType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(graphqlCart).cartItems, nil
+				},
+			},
+			"itemCount": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The sum of the count of every cart item.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(graphqlCart).ItemCount, nil
+				},
+			},
+			"subtotal": &graphql.Field{
+				Type:        graphql.NewNonNull(moneyType),
+				Description: "The sum of the total of every cart item.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(graphqlCart).Subtotal, nil
+				},
+			},
+		},
+	})
+
+	var queryType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"items": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType))),
+				Resolve: svc.resolver(func(p graphql.ResolveParams) (interface{}, error) {
+					items, err := item.Items(p.Context, svc.DB)
+					if err != nil {
+						return nil, err
+					}
+					var res = make([]itemV2, 0, len(items))
+					for _, i := range items {
+						res = append(res, svc.newItemV2(i))
+					}
+					return res, nil
+				}),
+			},
+			"item": &graphql.Field{
+				Type: itemType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: svc.resolver(func(p graphql.ResolveParams) (interface{}, error) {
+					var load = itemLoaderFrom(p.Context).Load(p.Args["id"].(int))
+					return func() (interface{}, error) {
+						found, err := load()
+						if errors.Cause(err) == item.ErrNotFound {
+							return nil, nil
+						}
+						if err != nil {
+							return nil, err
+						}
+						return svc.newItemV2(found.(item.Item)), nil
+					}, nil
+				}),
+			},
+			"cart": &graphql.Field{
+				Type: graphql.NewNonNull(cartType),
+				Args: graphql.FieldConfigArgument{
+					"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: svc.resolver(func(p graphql.ResolveParams) (interface{}, error) {
+					var userId = p.Args["userId"].(int)
+					var v validate.Validator
+					v.Check("userId", validate.Required(userId))
+					if err := v.Err(); err != nil {
+						return nil, err
+					}
+
+					cartItems, err := cart.CartItems(p.Context, svc.DB, userId)
+					if err != nil {
+						return nil, err
+					}
+					return graphqlCart{
+						cartV2:    svc.newCartV2(userId, cartItems),
+						cartItems: cartItems,
+					}, nil
+				}),
+			},
+		},
+	})
+
+	var cartItemArgs = graphql.FieldConfigArgument{
+		"userId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+		"itemId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+		"count":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+
+		// variantId is required if the item has variants.
+		"variantId": &graphql.ArgumentConfig{Type: graphql.Int},
+	}
+	var cartItemRel = func(args map[string]interface{}) (cart.UserCartItemRel, error) {
+		var rel = cart.UserCartItemRel{
+			UserId: args["userId"].(int),
+			ItemId: args["itemId"].(int),
+			Count:  args["count"].(int),
+		}
+		if variantId, ok := args["variantId"].(int); ok {
+			rel.VariantId = variantId
+		}
+		var v validate.Validator
+		v.Check("userId", validate.Required(rel.UserId))
+		v.Check("itemId", validate.Required(rel.ItemId))
+		v.Check("count", validate.IntMin(rel.Count, 1))
+		return rel, v.Err()
+	}
+
+	var mutationType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"addCartItem": &graphql.Field{
+				Type:        graphql.NewNonNull(cartItemType),
+				Description: "Add an item to a user's cart, summing counts if the item is already in the cart.",
+				Args:        cartItemArgs,
+				Resolve: svc.resolver(func(p graphql.ResolveParams) (interface{}, error) {
+					rel, err := cartItemRel(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					cartItem, err := svc.addCartItem(p.Context, rel)
+					if err != nil {
+						return nil, err
+					}
+					return *cartItem, nil
+				}),
+			},
+			"updateCartItem": &graphql.Field{
+				Type:        graphql.NewNonNull(cartItemType),
+				Description: "Update a cart item.",
+				Args: graphql.FieldConfigArgument{
+					"id":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"userId":    cartItemArgs["userId"],
+					"itemId":    cartItemArgs["itemId"],
+					"count":     cartItemArgs["count"],
+					"variantId": cartItemArgs["variantId"],
+				},
+				Resolve: svc.resolver(func(p graphql.ResolveParams) (interface{}, error) {
+					rel, err := cartItemRel(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					cartItem, err := svc.putCartItem(p.Context, p.Args["id"].(int), rel)
+					if err != nil {
+						return nil, err
+					}
+					return *cartItem, nil
+				}),
+			},
+			"removeCartItem": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "Delete a cart item.",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: svc.resolver(func(p graphql.ResolveParams) (interface{}, error) {
+					if err := svc.deleteCartItem(p.Context, p.Args["id"].(int)); err != nil {
+						return nil, err
+					}
+					return true, nil
+				}),
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+		Types:    []graphql.Type{moneyType},
+	})
+}
+
+// PostGraphQLHandler executes a GraphQL operation against the cart and item
+// resources. Operations deeper or more complex than configured in viper are
+// rejected before execution.
+func (svc *Service) PostGraphQLHandler() http.HandlerFunc {
+	type (
+		Request struct {
+			Query         string                 `json:"query" validate:"required"`
+			OperationName string                 `json:"operationName"`
+			Variables     map[string]interface{} `json:"variables"`
+		}
+		Response struct {
+			Data   interface{}                `json:"data,omitempty"`
+			Errors []gqlerrors.FormattedError `json:"errors,omitempty"`
+		}
+	)
+
+	schema, err := svc.graphqlSchema()
+	if err != nil {
+		panic(err)
+	}
+	var (
+		maxDepth      = svc.Viper.GetInt(EnvVarGraphQLMaxDepth)
+		maxComplexity = svc.Viper.GetInt(EnvVarGraphQLMaxComplexity)
+	)
+
+	return describe(operation{
+		Id:       "PostGraphQLHandler",
+		Summary:  "Execute a GraphQL operation against the cart and item resources.",
+		Request:  Request{},
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var respond = func(resp Response) {
+			if err := json.NewEncoder(w).Encode(resp); err != nil {
+				svc.HandleError(w, err)
+			}
+		}
+
+		// respondErr responds with an error raised outside of execution,
+		// which gqlerrors.FormatError would strip the extensions of.
+		var respondErr = func(err error) {
+			var formatted = gqlerrors.FormatError(err)
+			if extended, ok := err.(gqlerrors.ExtendedError); ok {
+				formatted.Extensions = extended.Extensions()
+			}
+			respond(Response{Errors: []gqlerrors.FormattedError{formatted}})
+		}
+
+		doc, err := parser.Parse(parser.ParseParams{
+			Source: source.NewSource(&source.Source{
+				Body: []byte(req.Query),
+				Name: "GraphQL request",
+			}),
+		})
+		if err != nil {
+			respondErr(err)
+			return
+		}
+		if res := graphql.ValidateDocument(&schema, doc, nil); !res.IsValid {
+			respond(Response{Errors: res.Errors})
+			return
+		}
+
+		depth, complexity, err := measureQuery(&schema, doc, req.OperationName)
+		if err != nil {
+			respondErr(svc.graphqlErr(err))
+			return
+		}
+		if depth > maxDepth {
+			respondErr(graphqlLimitErr("query depth %d exceeds max depth %d", depth, maxDepth))
+			return
+		}
+		if complexity > maxComplexity {
+			respondErr(graphqlLimitErr("query complexity %d exceeds max complexity %d", complexity, maxComplexity))
+			return
+		}
+
+		var res = graphql.Execute(graphql.ExecuteParams{
+			Schema:        schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       svc.withItemLoader(r.Context()),
+		})
+		respond(Response{Data: res.Data, Errors: res.Errors})
+	})
+}
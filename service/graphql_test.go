@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tjper/shoppingcart-server/service/item"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemLoaderBatches(t *testing.T) {
+	var batches [][]int
+	var batch = func(ctx context.Context, ids []int) ([]item.Item, error) {
+		batches = append(batches, ids)
+		var items []item.Item
+		for _, id := range ids {
+			if id != 404 {
+				items = append(items, item.Item{Id: id, Name: "item", Price: 1.5})
+			}
+		}
+		return items, nil
+	}
+
+	var svc = newTestService()
+	schema, err := svc.graphqlSchema()
+	require.Nil(t, err)
+
+	var ctx = context.WithValue(context.Background(), itemLoaderCtxKey{}, newItemLoader(context.Background(), batch))
+	var res = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ a: item(id: 1) { id name } b: item(id: 2) { id } c: item(id: 1) { id } d: item(id: 404) { id } }`,
+		Context:       ctx,
+	})
+	require.Empty(t, res.Errors)
+	require.Len(t, batches, 1)
+	require.ElementsMatch(t, []int{1, 2, 404}, batches[0])
+
+	body, err := json.Marshal(res.Data)
+	require.Nil(t, err)
+	require.JSONEq(t, `{"a":{"id":1,"name":"item"},"b":{"id":2},"c":{"id":1},"d":null}`, string(body))
+}
+
+func TestMeasureQuery(t *testing.T) {
+	var svc = newTestService()
+	schema, err := svc.graphqlSchema()
+	require.Nil(t, err)
+
+	tests := []struct {
+		Name       string
+		Query      string
+		Operation  string
+		Depth      int
+		Complexity int
+		Err        bool
+	}{
+		{Name: "flat", Query: `{ item(id: 1) { id name } }`, Depth: 2, Complexity: 3},
+		{Name: "list", Query: `{ items { id name } }`, Depth: 2, Complexity: 21},
+		{
+			Name:       "nested list",
+			Query:      `{ cart(userId: 1) { cartItems { item { id } } } }`,
+			Depth:      4,
+			Complexity: 22,
+		},
+		{
+			Name:       "fragment",
+			Query:      `{ items { ...fields } } fragment fields on Item { id price { amount } }`,
+			Depth:      3,
+			Complexity: 31,
+		},
+		{
+			Name:       "named operation",
+			Query:      `query a { items { id } } query b { item(id: 1) { id } }`,
+			Operation:  "b",
+			Depth:      2,
+			Complexity: 2,
+		},
+		{Name: "ambiguous operation", Query: `query a { items { id } } query b { items { id } }`, Err: true},
+		{Name: "unknown operation", Query: `{ items { id } }`, Operation: "c", Err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: test.Query})
+			require.Nil(t, err)
+
+			depth, complexity, err := measureQuery(&schema, doc, test.Operation)
+			if test.Err {
+				require.NotNil(t, err)
+				require.Equal(t, http.StatusBadRequest, statusCode(err))
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, test.Depth, depth)
+			require.Equal(t, test.Complexity, complexity)
+		})
+	}
+}
+
+func TestPostGraphQLHandler(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Body     string
+		Status   int
+		Expected string
+	}{
+		{
+			Name:     "typename",
+			Body:     `{"query": "{ __typename }"}`,
+			Status:   http.StatusOK,
+			Expected: `{"data":{"__typename":"Query"}}`,
+		},
+		{
+			Name:   "too deep",
+			Body:   `{"query": "{ cart(userId: 1) { cartItems { item { price { amount } } } } }"}`,
+			Status: http.StatusOK,
+			Expected: `{"errors":[{"message":"query depth 5 exceeds max depth 4","locations":[],` +
+				`"extensions":{"code":"UNPROCESSABLE_ENTITY","status":422}}]}`,
+		},
+		{
+			Name:   "too complex",
+			Body:   `{"query": "{ items { id name description } }"}`,
+			Status: http.StatusOK,
+			Expected: `{"errors":[{"message":"query complexity 31 exceeds max complexity 30","locations":[],` +
+				`"extensions":{"code":"UNPROCESSABLE_ENTITY","status":422}}]}`,
+		},
+		{
+			Name:     "invalid field",
+			Body:     `{"query": "{ nope }"}`,
+			Status:   http.StatusOK,
+			Expected: `{"errors":[{"message":"Cannot query field \"nope\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+		{
+			Name:   "mutation validation",
+			Body:   `{"query": "mutation { addCartItem(userId: 0, itemId: 1, count: 0) { id } }"}`,
+			Status: http.StatusOK,
+			Expected: `{"errors":[{"message":"validation failed","locations":[{"line":1,"column":12}],` +
+				`"path":["addCartItem"],"extensions":{"code":"UNPROCESSABLE_ENTITY","status":422,"fields":[` +
+				`{"field":"userId","message":"is required"},{"field":"count","message":"must be greater than or equal to 1"}]}}]}`,
+		},
+		{
+			Name:   "update variant line validation",
+			Body:   `{"query": "mutation { updateCartItem(id: 1, userId: 1, itemId: 1, variantId: 2, count: 0) { id } }"}`,
+			Status: http.StatusOK,
+			Expected: `{"errors":[{"message":"validation failed","locations":[{"line":1,"column":12}],` +
+				`"path":["updateCartItem"],"extensions":{"code":"UNPROCESSABLE_ENTITY","status":422,"fields":[` +
+				`{"field":"count","message":"must be greater than or equal to 1"}]}}]}`,
+		},
+		{
+			Name:     "missing query",
+			Body:     `{}`,
+			Status:   http.StatusUnprocessableEntity,
+			Expected: `{"error":{"status":422,"message":"validation failed","fields":[{"field":"query","message":"is required"}]}}`,
+		},
+	}
+
+	var svc = newTestService()
+	svc.Viper.Set(EnvVarGraphQLMaxDepth, 4)
+	svc.Viper.Set(EnvVarGraphQLMaxComplexity, 30)
+	var h = svc.PostGraphQLHandler()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(test.Body))
+			)
+			h(w, r)
+			require.Equal(t, test.Status, w.Code)
+			require.JSONEq(t, test.Expected, w.Body.String())
+		})
+	}
+}
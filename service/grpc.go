@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tjper/shoppingcart-server/service/blob"
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/catalog"
+	"github.com/tjper/shoppingcart-server/service/idempotency"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/pb"
+	"github.com/tjper/shoppingcart-server/service/validate"
+	"github.com/tjper/shoppingcart-server/service/webhook"
+
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC service names reported by the health service.
+const (
+	grpcCartService = "shoppingcart.v1.CartService"
+	grpcItemService = "shoppingcart.v1.ItemService"
+)
+
+// WithGRPC returns a ServiceOption that initializes the Service.GRPC field
+// with the cart and item gRPC services, and the standard gRPC health service.
+func WithGRPC() ServiceOption {
+	return func(svc *Service) {
+		var srv = grpc.NewServer(grpc.UnaryInterceptor(chainUnaryInterceptors(
+			grpcActorInterceptor,
+			svc.grpcErrorInterceptor,
+		)))
+		pb.RegisterCartServiceServer(srv, &cartServer{svc: svc})
+		pb.RegisterItemServiceServer(srv, &itemServer{svc: svc})
+
+		svc.Health = health.NewServer()
+		for _, name := range []string{"", grpcCartService, grpcItemService} {
+			svc.Health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+		}
+		healthpb.RegisterHealthServer(srv, svc.Health)
+
+		svc.GRPC = srv
+	}
+}
+
+// ListenAndServeGRPC serves svc.GRPC on the port specified in viper.
+func (svc *Service) ListenAndServeGRPC() {
+	lis, err := net.Listen("tcp", svc.Viper.GetString(EnvVarGrpcPort))
+	if err != nil {
+		svc.Zap.Fatal(errors.Wrap(err, "gRPC server Listen").Error())
+	}
+
+	go func() {
+		var sigint = make(chan os.Signal, 1)
+		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+		<-sigint
+
+		svc.Health.Shutdown()
+		svc.GRPC.GracefulStop()
+	}()
+
+	if err := svc.GRPC.Serve(lis); err != nil {
+		svc.Zap.Fatal(errors.Wrap(err, "gRPC server Serve").Error())
+	}
+}
+
+// grpcCode maps err to the gRPC status code that best describes it. It is
+// the gRPC counterpart of statusCode.
+func grpcCode(err error) codes.Code {
+	var cause = errors.Cause(err)
+	if _, ok := cause.(validate.Errors); ok {
+		return codes.InvalidArgument
+	}
+	switch cause {
+	case errMalformed, errInvalid, blob.ErrInvalidKey, catalog.ErrFormat, cart.ErrInvalid, item.ErrInvalid, idempotency.ErrMismatch:
+		return codes.InvalidArgument
+	case errUnauthenticated:
+		return codes.Unauthenticated
+	case errForbidden:
+		return codes.PermissionDenied
+	case cart.ErrNotFound, item.ErrNotFound, webhook.ErrNotFound, blob.ErrNotFound:
+		return codes.NotFound
+	case cart.ErrConflict, item.ErrConflict:
+		return codes.AlreadyExists
+	case idempotency.ErrInProgress:
+		return codes.Aborted
+	case cart.ErrExpired, errPreconditionFailed:
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
+
+// grpcError converts err to a gRPC status error. Validation failures are
+// detailed with a BadRequest listing each field violation. Internal errors
+// are logged and their details withheld from the client.
+func (svc *Service) grpcError(err error) error {
+	var code = grpcCode(err)
+	if code == codes.Internal {
+		svc.Zap.Error(err.Error())
+		return status.Error(code, "internal error")
+	}
+	svc.Zap.Debug(err.Error())
+
+	var (
+		cause = errors.Cause(err)
+		st    = status.New(code, cause.Error())
+	)
+	if fields, ok := cause.(validate.Errors); ok {
+		var br = new(errdetails.BadRequest)
+		for _, fe := range fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fe.Field,
+				Description: fe.Message,
+			})
+		}
+		if detailed, err := st.WithDetails(br); err == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+// chainUnaryInterceptors returns a unary interceptor that calls
+// interceptors in order, the first outermost.
+func chainUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			var (
+				interceptor = interceptors[i]
+				next        = handler
+			)
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// grpcErrorInterceptor converts the errors returned by unary handlers to gRPC
+// status errors.
+func (svc *Service) grpcErrorInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, svc.grpcError(err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/blob"
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/catalog"
+	"github.com/tjper/shoppingcart-server/service/idempotency"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/pb"
+	"github.com/tjper/shoppingcart-server/service/validate"
+	"github.com/tjper/shoppingcart-server/service/webhook"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPCConn serves svc.GRPC over an in-memory listener and returns a
+// client connection to it.
+func newTestGRPCConn(t *testing.T, svc *Service) *grpc.ClientConn {
+	var lis = bufconn.Listen(1 << 20)
+	go svc.GRPC.Serve(lis)
+
+	conn, err := grpc.Dial(
+		"bufconn",
+		grpc.WithInsecure(),
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return lis.Dial()
+		}),
+	)
+	require.Nil(t, err)
+	return conn
+}
+
+func TestGRPCCode(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Err      error
+		Expected codes.Code
+	}{
+		{Name: "malformed", Err: errMalformed, Expected: codes.InvalidArgument},
+		{Name: "validation", Err: validate.Errors{{Field: "id", Message: "is required"}}, Expected: codes.InvalidArgument},
+		{Name: "cart not found", Err: errors.Wrap(cart.ErrNotFound, "failed to FindCartItem"), Expected: codes.NotFound},
+		{Name: "item not found", Err: item.ErrNotFound, Expected: codes.NotFound},
+		{Name: "webhook not found", Err: webhook.ErrNotFound, Expected: codes.NotFound},
+		{Name: "unauthenticated", Err: errUnauthenticated, Expected: codes.Unauthenticated},
+		{Name: "forbidden", Err: errForbidden, Expected: codes.PermissionDenied},
+		{Name: "cart conflict", Err: cart.ErrConflict, Expected: codes.AlreadyExists},
+		{Name: "blob not found", Err: blob.ErrNotFound, Expected: codes.NotFound},
+		{Name: "blob invalid key", Err: blob.ErrInvalidKey, Expected: codes.InvalidArgument},
+		{Name: "catalog format", Err: catalog.ErrFormat, Expected: codes.InvalidArgument},
+		{Name: "cart expired", Err: cart.ErrExpired, Expected: codes.FailedPrecondition},
+		{Name: "precondition failed", Err: errPreconditionFailed, Expected: codes.FailedPrecondition},
+		{Name: "idempotency in progress", Err: idempotency.ErrInProgress, Expected: codes.Aborted},
+		{Name: "idempotency mismatch", Err: idempotency.ErrMismatch, Expected: codes.InvalidArgument},
+		{Name: "cart invalid", Err: cart.ErrInvalid, Expected: codes.InvalidArgument},
+		{Name: "unmapped", Err: errors.New("boom"), Expected: codes.Internal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Expected, grpcCode(test.Err))
+		})
+	}
+}
+
+func TestGRPCValidation(t *testing.T) {
+	var svc = newTestService()
+	WithGRPC()(svc)
+	defer svc.GRPC.Stop()
+
+	var conn = newTestGRPCConn(t, svc)
+	defer conn.Close()
+
+	_, err := pb.NewCartServiceClient(conn).AddCartItem(
+		context.Background(),
+		&pb.AddCartItemRequest{UserId: 1},
+	)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+
+	require.Len(t, st.Details(), 1)
+	br, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Equal(t, []*errdetails.BadRequest_FieldViolation{
+		{Field: "itemId", Description: "is required"},
+		{Field: "count", Description: "must be greater than or equal to 1"},
+	}, br.FieldViolations)
+}
+
+func TestPbCartItem(t *testing.T) {
+	var price = 12.5
+	tests := []struct {
+		Name      string
+		CartItem  cart.CartItem
+		VariantId int64
+		Amount    int64
+	}{
+		{
+			Name:     "item",
+			CartItem: cart.CartItem{Id: 1, Count: 2, Item: item.Item{Id: 3, Price: 10}},
+			Amount:   2000,
+		},
+		{
+			Name: "variant",
+			CartItem: cart.CartItem{
+				Id:      1,
+				Count:   2,
+				Item:    item.Item{Id: 3, Price: price},
+				Variant: &item.Variant{Id: 4, ItemId: 3, SKU: "TEE-M", Price: &price},
+			},
+			VariantId: 4,
+			Amount:    2500,
+		},
+	}
+
+	var svc = newTestService()
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var actual = svc.pbCartItem(test.CartItem)
+			require.Equal(t, int64(test.CartItem.Id), actual.Id)
+			require.Equal(t, int64(test.CartItem.Item.Id), actual.Item.Id)
+			require.Equal(t, test.VariantId, actual.VariantId)
+			require.Equal(t, test.Amount, actual.Total.Amount)
+		})
+	}
+}
+
+func TestGRPCHealth(t *testing.T) {
+	var svc = newTestService()
+	WithGRPC()(svc)
+	defer svc.GRPC.Stop()
+
+	var conn = newTestGRPCConn(t, svc)
+	defer conn.Close()
+
+	var client = healthpb.NewHealthClient(conn)
+	for _, name := range []string{"", grpcCartService, grpcItemService} {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: name})
+		require.Nil(t, err)
+		require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	}
+
+	svc.Health.Shutdown()
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.Nil(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+}
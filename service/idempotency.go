@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/idempotency"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// headerIdempotencyKey is the header clients identify retries of a
+	// request with.
+	headerIdempotencyKey = "Idempotency-Key"
+
+	// headerIdempotentReplayed is set on responses replayed for a retry.
+	headerIdempotentReplayed = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLen is the maximum length of an idempotency key.
+	maxIdempotencyKeyLen = 255
+
+	// idempotencyPollInterval is the interval at which a retry polls for the
+	// response of a request in progress.
+	idempotencyPollInterval = 100 * time.Millisecond
+)
+
+// idempotentHeaders are the response headers replayed for a retry.
+var idempotentHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// idempotent makes next idempotent for requests with an Idempotency-Key
+// header. The first response to a request made with a key is recorded, and
+// retries of the request, identified by the key and the user making it, are
+// answered with it byte for byte. Retries made while the first request is in
+// progress wait for its response, up to the wait specified in viper. Reusing
+// a key for a different request is rejected. Server errors are not recorded,
+// so that the request may be retried.
+func (svc *Service) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var key = r.Header.Get(headerIdempotencyKey)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			svc.HandleError(w, errors.Wrapf(errMalformed, "failed to idempotent, key too long\tlen=%d", len(key)))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			svc.HandleError(w, errors.Wrap(errMalformed, err.Error()))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var (
+			ctx   = r.Context()
+			scope = actorFrom(ctx).UserId
+			hash  = idempotency.Hash(r.Method, r.URL.Path, body)
+		)
+		resp, err := svc.claimIdempotencyKey(ctx, scope, key, hash)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if resp != nil {
+			for _, name := range idempotentHeaders {
+				if value := resp.Header.Get(name); value != "" {
+					w.Header().Set(name, value)
+				}
+			}
+			w.Header().Set(headerIdempotentReplayed, "true")
+			w.WriteHeader(resp.StatusCode)
+			w.Write(resp.Body)
+			return
+		}
+
+		var (
+			rec       = &recordingResponseWriter{ResponseWriter: w}
+			completed bool
+		)
+		defer func() {
+			if completed {
+				return
+			}
+			// The request failed or panicked; release the key so that it may
+			// be retried. The request's context may be done, so it is not
+			// used.
+			if err := idempotency.Release(context.Background(), svc.DB, scope, key); err != nil {
+				svc.Zap.Error(err.Error())
+			}
+		}()
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+		var recorded = idempotency.Response{
+			StatusCode: rec.status,
+			Header:     http.Header{},
+			Body:       rec.body.Bytes(),
+		}
+		for _, name := range idempotentHeaders {
+			if value := w.Header().Get(name); value != "" {
+				recorded.Header.Set(name, value)
+			}
+		}
+		var expiresAt = time.Now().Add(svc.Viper.GetDuration(EnvVarIdempotencyTTL))
+		if err := idempotency.Complete(context.Background(), svc.DB, scope, key, recorded, expiresAt); err != nil {
+			svc.Zap.Error(err.Error())
+			return
+		}
+		completed = true
+	})
+}
+
+// claimIdempotencyKey claims the key of scope for the request hashed as
+// hash. If the key was claimed by a request that completed, its response is
+// returned; if the request is in progress, claimIdempotencyKey waits for it,
+// up to the wait specified in viper. A nil response and error indicates the
+// key was claimed, and the request should proceed.
+func (svc *Service) claimIdempotencyKey(ctx context.Context, scope int, key, hash string) (*idempotency.Response, error) {
+	var deadline = time.Now().Add(svc.Viper.GetDuration(EnvVarIdempotencyWait))
+	for {
+		var lockedUntil = time.Now().Add(svc.Viper.GetDuration(EnvVarIdempotencyLockTimeout))
+		claimed, err := idempotency.Claim(ctx, svc.DB, scope, key, hash, lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return nil, nil
+		}
+
+		rec, err := idempotency.Find(ctx, svc.DB, scope, key)
+		if errors.Cause(err) == idempotency.ErrNotFound {
+			// The claim was released; try to claim the key again.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rec.RequestHash != hash {
+			return nil, errors.Wrapf(idempotency.ErrMismatch, "failed to claimIdempotencyKey\tkey=%s", key)
+		}
+		if rec.Response != nil {
+			return rec.Response, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Wrapf(idempotency.ErrInProgress, "failed to claimIdempotencyKey\tkey=%s", key)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "failed to claimIdempotencyKey")
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// recordingResponseWriter records the status and body of a response as it
+// is written.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// PurgeIdempotencyKeys deletes the idempotency keys that have expired, in
+// batches of the size specified in viper, and returns the number deleted.
+func (svc *Service) PurgeIdempotencyKeys(ctx context.Context) (int, error) {
+	var (
+		now       = time.Now()
+		batchSize = svc.Viper.GetInt(EnvVarCartPurgeBatchSize)
+		purged    int
+	)
+	for {
+		n, err := idempotency.Purge(ctx, svc.DB, now, batchSize)
+		purged += n
+		if err != nil || n < batchSize {
+			return purged, err
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Package idempotency records the responses to requests made with an
+// Idempotency-Key, so that a retried request is answered with the response
+// to its first attempt rather than repeating its effects. A key is claimed
+// by the first request made with it, completed with that request's
+// response, and expires after a TTL, after which it may be claimed again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/dberr"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotFound indicates the requested idempotency key does not exist.
+	ErrNotFound = errors.New("idempotency: not found")
+
+	// ErrInProgress indicates the first request made with an idempotency key
+	// has not completed.
+	ErrInProgress = errors.New("idempotency: request in progress")
+
+	// ErrMismatch indicates an idempotency key was reused for a request that
+	// differs from the first request made with it.
+	ErrMismatch = errors.New("idempotency: key reused for a different request")
+)
+
+type Execer interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+type QueryRower interface {
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// Hash returns the hash identifying a request, so that requests reusing an
+// idempotency key may be compared with the first request made with it.
+func Hash(method, path string, body []byte) string {
+	var h = sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Response is a response recorded for an idempotency key.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Record is the state of an idempotency key.
+type Record struct {
+	RequestHash string
+
+	// Response is nil while the first request is in progress.
+	Response *Response
+}
+
+// Claim reserves the key of scope for the request hashed as requestHash
+// until expiresAt, unless the key is already reserved and has not expired.
+// Claim reports whether the key was reserved.
+func Claim(ctx context.Context, db Execer, scope int, key, requestHash string, expiresAt time.Time) (bool, error) {
+	var sql = `
+  INSERT INTO idempotency_key (scope, idem_key, request_hash, created_at, expires_at)
+  VALUES (?, ?, ?, UTC_TIMESTAMP(6), ?)
+  `
+	var args = []interface{}{scope, key, requestHash, expiresAt.UTC()}
+	_, err := db.ExecContext(ctx, sql, args...)
+	if err == nil {
+		return true, nil
+	}
+	if !dberr.IsDuplicate(err) {
+		return false, errors.Wrapf(err, "failed to Claim/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+
+	sql = `
+  UPDATE idempotency_key
+  SET request_hash = ?,
+      status_code = NULL,
+      header = NULL,
+      body = NULL,
+      created_at = UTC_TIMESTAMP(6),
+      expires_at = ?
+  WHERE scope = ?
+        AND idem_key = ?
+        AND expires_at < UTC_TIMESTAMP(6)
+  `
+	args = []interface{}{requestHash, expiresAt.UTC(), scope, key}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to Claim/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to Claim/RowsAffected\tsql=%s\targs=%v", sql, args)
+	}
+	return n == 1, nil
+}
+
+// Find retrieves the Record of the key of scope from the db. If the key does
+// not exist, an error wrapping ErrNotFound is returned.
+func Find(ctx context.Context, db QueryRower, scope int, key string) (*Record, error) {
+	var SQL = `
+  SELECT request_hash, status_code, header, body
+  FROM idempotency_key
+  WHERE scope = ?
+        AND idem_key = ?
+  `
+	var (
+		args       = []interface{}{scope, key}
+		rec        Record
+		statusCode *int
+		header     *string
+		body       []byte
+	)
+	if err := db.QueryRowContext(ctx, SQL, args...).Scan(&rec.RequestHash, &statusCode, &header, &body); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrapf(ErrNotFound, "failed to Find\targs=%v", args)
+		}
+		return nil, errors.Wrapf(err, "failed to Find/Scan\tSQL=%s\targs=%v", SQL, args)
+	}
+	if statusCode == nil {
+		return &rec, nil
+	}
+
+	rec.Response = &Response{StatusCode: *statusCode, Header: http.Header{}, Body: body}
+	if header != nil {
+		if err := json.Unmarshal([]byte(*header), &rec.Response.Header); err != nil {
+			return nil, errors.Wrapf(err, "failed to Find/Unmarshal\targs=%v", args)
+		}
+	}
+	return &rec, nil
+}
+
+// Complete records resp as the response to the request that claimed the key
+// of scope, and retains it until expiresAt.
+func Complete(ctx context.Context, db Execer, scope int, key string, resp Response, expiresAt time.Time) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return errors.Wrapf(err, "failed to Complete/Marshal\tscope=%v\tkey=%s", scope, key)
+	}
+
+	var sql = `
+  UPDATE idempotency_key
+  SET status_code = ?,
+      header = ?,
+      body = ?,
+      expires_at = ?
+  WHERE scope = ?
+        AND idem_key = ?
+  `
+	var args = []interface{}{resp.StatusCode, string(header), resp.Body, expiresAt.UTC(), scope, key}
+	if _, err := db.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to Complete/ExecContext\tsql=%s\tscope=%v\tkey=%s", sql, scope, key)
+	}
+	return nil
+}
+
+// Release gives up the claim on the key of scope by a request that did not
+// complete, so that a retry may claim it.
+func Release(ctx context.Context, db Execer, scope int, key string) error {
+	var sql = `
+  DELETE FROM idempotency_key
+  WHERE scope = ?
+        AND idem_key = ?
+        AND status_code IS NULL
+  `
+	var args = []interface{}{scope, key}
+	if _, err := db.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to Release/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// Purge deletes at most limit keys that expired before before, and returns
+// the number deleted.
+func Purge(ctx context.Context, db Execer, before time.Time, limit int) (int, error) {
+	var sql = `
+  DELETE FROM idempotency_key
+  WHERE expires_at < ?
+  ORDER BY expires_at
+  LIMIT ?
+  `
+	var args = []interface{}{before.UTC(), limit}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to Purge/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to Purge/RowsAffected\tsql=%s\targs=%v", sql, args)
+	}
+	return int(n), nil
+}
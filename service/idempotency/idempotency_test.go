@@ -0,0 +1,17 @@
+package idempotency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	var hash = Hash("POST", "/v1/cart/item", []byte(`{"itemId":1}`))
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, Hash("POST", "/v1/cart/item", []byte(`{"itemId":1}`)))
+
+	require.NotEqual(t, hash, Hash("POST", "/v1/cart/item", []byte(`{"itemId":2}`)))
+	require.NotEqual(t, hash, Hash("PUT", "/v1/cart/item", []byte(`{"itemId":1}`)))
+	require.NotEqual(t, hash, Hash("POST", "/v2/cart/item", []byte(`{"itemId":1}`)))
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+
+	"github.com/pkg/errors"
+)
+
+// headerUserId is the request header that identifies the user making a
+// request. It is expected to be set by the gateway that authenticates users.
+const headerUserId = "X-User-Id"
+
+// headerUserRoles is the request header that lists the comma separated roles
+// of the user making a request. Like X-User-Id, it is expected to be set by
+// the gateway that authenticates users.
+const headerUserRoles = "X-User-Roles"
+
+// roleAdmin is the role of the internal users permitted to use the admin
+// endpoints and manage webhooks.
+const roleAdmin = "admin"
+
+// roleGuest is the role of users the gateway identifies without an account,
+// such as shoppers who have not signed in. Their carts expire after the
+// guest cart TTL.
+const roleGuest = "guest"
+
+// hasRole reports whether roles, a comma separated list such as the value of
+// the X-User-Roles header, contains role.
+func hasRole(roles, role string) bool {
+	for _, r := range strings.Split(roles, ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// requestUserId returns the id of the user making r, read from the
+// X-User-Id header set by the gateway once it has authenticated r. This
+// includes WebSocket handshakes, which the gateway authenticates as it does
+// every other request.
+func requestUserId(r *http.Request) (int, error) {
+	var raw = r.Header.Get(headerUserId)
+	if raw == "" {
+		return 0, errors.Wrap(errUnauthenticated, "failed to requestUserId, user not identified")
+	}
+	userId, err := strconv.Atoi(raw)
+	if err != nil || userId <= 0 {
+		return 0, errors.Wrapf(errUnauthenticated, "failed to requestUserId\tuserId=%s", raw)
+	}
+	return userId, nil
+}
+
+// authorizeCart returns an error wrapping errForbidden unless userId owns
+// cartId or is one of its members.
+func (svc *Service) authorizeCart(ctx context.Context, cartId, userId int) error {
+	if cartId == userId {
+		return nil
+	}
+	member, err := cart.IsCartMember(ctx, svc.DB, cartId, userId)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return errors.Wrapf(errForbidden, "failed to authorizeCart\tcartId=%v\tuserId=%v", cartId, userId)
+	}
+	return nil
+}
+
+// authorizeAdmin returns an error wrapping errUnauthenticated unless the user
+// making r is identified by the X-User-Id header, and an error wrapping
+// errForbidden unless the user has the admin role.
+func authorizeAdmin(r *http.Request) error {
+	userId, err := requestUserId(r)
+	if err != nil {
+		return err
+	}
+	if hasRole(r.Header.Get(headerUserRoles), roleAdmin) {
+		return nil
+	}
+	return errors.Wrapf(errForbidden, "failed to authorizeAdmin\tuserId=%v", userId)
+}
+
+// requireAdmin is middleware that rejects the requests of users other than
+// admins; see authorizeAdmin.
+func (svc *Service) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizeAdmin(r); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,388 @@
+package item
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Category is a node of the catalog's category tree.
+type Category struct {
+	Id int `json:"id"`
+
+	// ParentId is the id of the category's parent, or nil if the category is
+	// a root.
+	ParentId *int `json:"parentId,omitempty"`
+
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	SortOrder int    `json:"sortOrder"`
+
+	// Children are the subcategories of the category, in sort order. They
+	// are only set by CategoryTree.
+	Children []Category `json:"children,omitempty"`
+}
+
+// CategoryRef identifies a category within a Breadcrumb.
+type CategoryRef struct {
+	Id   int    `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// Breadcrumb is the path from a root of the category tree to a category,
+// root first.
+type Breadcrumb []CategoryRef
+
+// Categories retrieves all categories from the db, in sort order.
+func Categories(ctx context.Context, db Queryer) ([]Category, error) {
+	var sql = `
+    SELECT
+      id,
+      parent_id,
+      slug,
+      name,
+      sort_order
+    FROM category
+    ORDER BY sort_order, name, id
+  `
+	rows, err := db.QueryContext(ctx, sql)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to Categories/QueryContext\tsql=%s", sql)
+	}
+	defer rows.Close()
+
+	var categories = make([]Category, 0)
+	for rows.Next() {
+		var c Category
+		if err := rows.Scan(
+			&c.Id,
+			&c.ParentId,
+			&c.Slug,
+			&c.Name,
+			&c.SortOrder,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to Categories/Scan\tsql=%s", sql)
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to Categories/Err\tsql=%s", sql)
+	}
+	return categories, nil
+}
+
+// CreateCategory creates c in the db and returns its id. If c.ParentId does
+// not exist, an error wrapping ErrInvalid is returned; if c.Slug is taken, an
+// error wrapping ErrConflict is returned.
+func CreateCategory(ctx context.Context, db ExecQueryer, c Category) (int, error) {
+	if c.ParentId != nil {
+		var sql = `SELECT id FROM category WHERE id = ?`
+		var id int
+		if err := db.QueryRowContext(ctx, sql, *c.ParentId).Scan(&id); err != nil {
+			err = classify(err)
+			if errors.Cause(err) == ErrNotFound {
+				err = errors.Wrap(ErrInvalid, err.Error())
+			}
+			return 0, errors.Wrapf(err, "failed to CreateCategory/Scan\tsql=%s\tparentId=%v", sql, *c.ParentId)
+		}
+	}
+
+	var sql = `
+    INSERT INTO category (parent_id, slug, name, sort_order)
+    VALUES (?, ?, ?, ?)
+  `
+	var args = []interface{}{c.ParentId, c.Slug, c.Name, c.SortOrder}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrapf(classify(err), "failed to CreateCategory/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to CreateCategory/LastInsertId\tres=%v", res)
+	}
+	return int(id), nil
+}
+
+// SetItemCategories replaces the categories of the item itemId in the db
+// with categoryIds. If the item does not exist, an error wrapping
+// ErrNotFound is returned; if a category does not exist, an error wrapping
+// ErrInvalid is returned. db should be a transaction.
+func SetItemCategories(ctx context.Context, db ExecQueryer, itemId int, categoryIds []int) error {
+	if _, err := FindItem(ctx, db, itemId); err != nil {
+		return errors.Wrap(err, "failed to SetItemCategories")
+	}
+
+	var sql = `DELETE FROM item_category WHERE item_id = ?`
+	if _, err := db.ExecContext(ctx, sql, itemId); err != nil {
+		return errors.Wrapf(err, "failed to SetItemCategories/ExecContext\tsql=%s\titemId=%v", sql, itemId)
+	}
+	if len(categoryIds) == 0 {
+		return nil
+	}
+
+	var (
+		args         = make([]interface{}, 0, len(categoryIds))
+		placeholders = make([]string, 0, len(categoryIds))
+	)
+	for _, id := range categoryIds {
+		args = append(args, id)
+		placeholders = append(placeholders, "?")
+	}
+	sql = `
+    INSERT IGNORE INTO item_category (item_id, category_id)
+    SELECT ?, id
+    FROM category
+    WHERE id IN (` + strings.Join(placeholders, ", ") + `)
+  `
+	args = append([]interface{}{itemId}, args...)
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to SetItemCategories/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to SetItemCategories/RowsAffected\tres=%v", res)
+	}
+	if int(n) != len(distinct(categoryIds)) {
+		return errors.Wrapf(ErrInvalid, "failed to SetItemCategories\tcategoryIds=%v", categoryIds)
+	}
+	return nil
+}
+
+// distinct returns ids without duplicates.
+func distinct(ids []int) []int {
+	var (
+		seen = make(map[int]bool, len(ids))
+		out  = make([]int, 0, len(ids))
+	)
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// ItemCategoryIds retrieves the ids of the categories of the items with the
+// ids passed from the db in a single query, keyed by item id. Items without
+// categories are absent from the result.
+func ItemCategoryIds(ctx context.Context, db Queryer, itemIds []int) (map[int][]int, error) {
+	var categoryIds = make(map[int][]int)
+	if len(itemIds) == 0 {
+		return categoryIds, nil
+	}
+
+	var (
+		args         = make([]interface{}, 0, len(itemIds))
+		placeholders = make([]string, 0, len(itemIds))
+	)
+	for _, id := range itemIds {
+		args = append(args, id)
+		placeholders = append(placeholders, "?")
+	}
+	var sql = `
+    SELECT item_id, category_id
+    FROM item_category
+    WHERE item_id IN (` + strings.Join(placeholders, ", ") + `)
+    ORDER BY item_id, category_id
+  `
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to ItemCategoryIds/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemId, categoryId int
+		if err := rows.Scan(&itemId, &categoryId); err != nil {
+			return nil, errors.Wrapf(err, "failed to ItemCategoryIds/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		categoryIds[itemId] = append(categoryIds[itemId], categoryId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to ItemCategoryIds/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return categoryIds, nil
+}
+
+// CategoryItems retrieves at most limit items with ids greater than after
+// that belong to any of the categories categoryIds from the db, ordered by
+// id.
+func CategoryItems(ctx context.Context, db Queryer, categoryIds []int, after, limit int) ([]Item, error) {
+	if len(categoryIds) == 0 {
+		return make([]Item, 0), nil
+	}
+
+	var (
+		args         = make([]interface{}, 0, len(categoryIds)+2)
+		placeholders = make([]string, 0, len(categoryIds))
+	)
+	for _, id := range categoryIds {
+		args = append(args, id)
+		placeholders = append(placeholders, "?")
+	}
+	args = append(args, after, limit)
+	var sql = `
+    SELECT
+      id,
+      name,
+      description,
+      ` + PriceSQL + `,
+      price,
+      min_per_order,
+      max_per_order,
+      step
+    FROM item
+    WHERE id IN (
+            SELECT item_id
+            FROM item_category
+            WHERE category_id IN (` + strings.Join(placeholders, ", ") + `)
+          )
+          AND id > ?
+    ORDER BY id
+    LIMIT ?
+  `
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to CategoryItems/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	var (
+		items       = make([]Item, 0, limit)
+		item        Item
+		price, base float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(
+			&item.Id,
+			&item.Name,
+			&item.Description,
+			&price,
+			&base,
+			&item.MinPerOrder,
+			&item.MaxPerOrder,
+			&item.Step,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to CategoryItems/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		item.setPrices(price, base)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to CategoryItems/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return items, nil
+}
+
+// CategoryTree arranges categories, as retrieved by Categories, into trees
+// and returns their roots in sort order. Categories whose parent is absent
+// are treated as roots.
+func CategoryTree(categories []Category) []Category {
+	var (
+		byId     = make(map[int]bool, len(categories))
+		children = make(map[int][]Category)
+		roots    = make([]Category, 0)
+	)
+	for _, c := range categories {
+		byId[c.Id] = true
+	}
+	for _, c := range categories {
+		if c.ParentId != nil && byId[*c.ParentId] {
+			children[*c.ParentId] = append(children[*c.ParentId], c)
+			continue
+		}
+		roots = append(roots, c)
+	}
+
+	var build func(c Category, depth int) Category
+	build = func(c Category, depth int) Category {
+		// depth guards against cycles, which CreateCategory does not create.
+		if depth > len(categories) {
+			return c
+		}
+		for _, child := range children[c.Id] {
+			c.Children = append(c.Children, build(child, depth+1))
+		}
+		return c
+	}
+	for n := range roots {
+		roots[n] = build(roots[n], 0)
+	}
+	return roots
+}
+
+// Subtree returns the id of the category id and the ids of its descendants
+// among categories.
+func Subtree(categories []Category, id int) []int {
+	var children = make(map[int][]int)
+	for _, c := range categories {
+		if c.ParentId != nil {
+			children[*c.ParentId] = append(children[*c.ParentId], c.Id)
+		}
+	}
+
+	var (
+		ids  = []int{id}
+		seen = map[int]bool{id: true}
+	)
+	for n := 0; n < len(ids); n++ {
+		for _, child := range children[ids[n]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
+
+// BreadcrumbOf returns the path from the root of the category tree to the
+// category id among categories.
+func BreadcrumbOf(categories []Category, id int) Breadcrumb {
+	var byId = make(map[int]Category, len(categories))
+	for _, c := range categories {
+		byId[c.Id] = c
+	}
+
+	// The length of path guards against cycles, which CreateCategory does
+	// not create.
+	var path = make(Breadcrumb, 0)
+	c, ok := byId[id]
+	for ok && len(path) < len(categories) {
+		path = append(Breadcrumb{{Id: c.Id, Slug: c.Slug, Name: c.Name}}, path...)
+		if c.ParentId == nil {
+			break
+		}
+		c, ok = byId[*c.ParentId]
+	}
+	return path
+}
+
+// WithBreadcrumbs sets the Breadcrumbs of each of items from the db.
+func WithBreadcrumbs(ctx context.Context, db Queryer, items []Item) error {
+	var ids = make([]int, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.Id)
+	}
+	categoryIds, err := ItemCategoryIds(ctx, db, ids)
+	if err != nil {
+		return errors.Wrap(err, "failed to WithBreadcrumbs")
+	}
+	if len(categoryIds) == 0 {
+		return nil
+	}
+	categories, err := Categories(ctx, db)
+	if err != nil {
+		return errors.Wrap(err, "failed to WithBreadcrumbs")
+	}
+	for n := range items {
+		for _, id := range categoryIds[items[n].Id] {
+			items[n].Breadcrumbs = append(items[n].Breadcrumbs, BreadcrumbOf(categories, id))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package item
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(i int) *int { return &i }
+
+// testCategories is the tree:
+//
+//	apparel
+//	  shirts
+//	    tees
+//	  pants
+//	home
+var testCategories = []Category{
+	{Id: 1, Slug: "apparel", Name: "Apparel"},
+	{Id: 4, Slug: "home", Name: "Home", SortOrder: 1},
+	{Id: 3, ParentId: intPtr(1), Slug: "pants", Name: "Pants"},
+	{Id: 2, ParentId: intPtr(1), Slug: "shirts", Name: "Shirts"},
+	{Id: 5, ParentId: intPtr(2), Slug: "tees", Name: "Tees"},
+}
+
+func TestCategoryTree(t *testing.T) {
+	var tree = CategoryTree(testCategories)
+	require.Len(t, tree, 2)
+	require.Equal(t, "apparel", tree[0].Slug)
+	require.Equal(t, "home", tree[1].Slug)
+	require.Len(t, tree[0].Children, 2)
+	require.Equal(t, "pants", tree[0].Children[0].Slug)
+	require.Equal(t, "shirts", tree[0].Children[1].Slug)
+	require.Equal(t, "tees", tree[0].Children[1].Children[0].Slug)
+	require.Empty(t, tree[1].Children)
+}
+
+func TestSubtree(t *testing.T) {
+	tests := []struct {
+		Name string
+		Id   int
+		Exp  []int
+	}{
+		{Name: "root", Id: 1, Exp: []int{1, 3, 2, 5}},
+		{Name: "inner", Id: 2, Exp: []int{2, 5}},
+		{Name: "leaf", Id: 5, Exp: []int{5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Exp, Subtree(testCategories, test.Id))
+		})
+	}
+}
+
+func TestBreadcrumbOf(t *testing.T) {
+	tests := []struct {
+		Name string
+		Id   int
+		Exp  []string
+	}{
+		{Name: "root", Id: 4, Exp: []string{"home"}},
+		{Name: "leaf", Id: 5, Exp: []string{"apparel", "shirts", "tees"}},
+		{Name: "unknown", Id: 9, Exp: []string{}},
+	}
+
+	var categories = append([]Category{
+		{Id: 6, ParentId: intPtr(7), Slug: "b"},
+		{Id: 7, ParentId: intPtr(6), Slug: "a"},
+	}, testCategories...)
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var slugs = make([]string, 0)
+			for _, ref := range BreadcrumbOf(categories, test.Id) {
+				slugs = append(slugs, ref.Slug)
+			}
+			require.Equal(t, test.Exp, slugs)
+		})
+	}
+
+	// A cycle is cut short rather than followed forever.
+	require.Len(t, BreadcrumbOf(categories, 7), len(categories))
+}
@@ -0,0 +1,25 @@
+package item
+
+import (
+	"github.com/tjper/shoppingcart-server/service/dberr"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotFound indicates the requested item does not exist.
+	ErrNotFound = errors.New("item: not found")
+
+	// ErrConflict indicates the item conflicts with the current state of the
+	// db.
+	ErrConflict = errors.New("item: conflict")
+
+	// ErrInvalid indicates the item is semantically invalid.
+	ErrInvalid = errors.New("item: invalid")
+)
+
+// classify translates db errors into the domain error they represent. Errors
+// that do not represent a domain error are returned unchanged.
+func classify(err error) error {
+	return dberr.Classify(err, ErrNotFound, ErrConflict, ErrInvalid)
+}
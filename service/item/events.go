@@ -0,0 +1,42 @@
+package item
+
+// Domain event types recorded in the outbox when the catalog changes. Item
+// and price events are recorded with the item's id as their aggregate id,
+// and category events with the category's id.
+const (
+	EventItemCreated = "ItemCreated"
+	EventItemUpdated = "ItemUpdated"
+
+	EventItemPriceScheduled = "ItemPriceScheduled"
+	EventItemPriceCancelled = "ItemPriceCancelled"
+
+	EventCategoryCreated   = "CategoryCreated"
+	EventItemCategoriesSet = "ItemCategoriesSet"
+)
+
+// ItemEvent is the payload of ItemCreated and ItemUpdated events.
+type ItemEvent struct {
+	Item Item `json:"item"`
+}
+
+// ItemPriceScheduledEvent is the payload of ItemPriceScheduled events.
+type ItemPriceScheduledEvent struct {
+	ScheduledPrice ScheduledPrice `json:"scheduledPrice"`
+}
+
+// ItemPriceCancelledEvent is the payload of ItemPriceCancelled events.
+type ItemPriceCancelledEvent struct {
+	ItemId  int `json:"itemId"`
+	PriceId int `json:"priceId"`
+}
+
+// CategoryCreatedEvent is the payload of CategoryCreated events.
+type CategoryCreatedEvent struct {
+	Category Category `json:"category"`
+}
+
+// ItemCategoriesSetEvent is the payload of ItemCategoriesSet events.
+type ItemCategoriesSetEvent struct {
+	ItemId      int   `json:"itemId"`
+	CategoryIds []int `json:"categoryIds"`
+}
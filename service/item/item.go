@@ -3,6 +3,10 @@ package item
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tjper/shoppingcart-server/service/validate"
 
 	"github.com/pkg/errors"
 )
@@ -11,12 +15,64 @@ type Queryer interface {
 	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
 }
 
+type QueryRower interface {
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+type Execer interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+type ExecQueryer interface {
+	Execer
+	Queryer
+	QueryRower
+}
+
 // Item is an item that may be purchased.
 type Item struct {
 	Id          int     `json:"id"`
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
+
+	// RegularPrice is the price of the item while a scheduled price is in
+	// effect that replaces it, such as a sale; see PriceSQL. Otherwise, it is
+	// nil, and Price is the regular price.
+	RegularPrice *float64 `json:"regularPrice,omitempty"`
+
+	// MinPerOrder, MaxPerOrder and Step limit the count of the item's cart
+	// lines; see CheckCount. Zero imposes no limit.
+	MinPerOrder int `json:"minPerOrder,omitempty"`
+	MaxPerOrder int `json:"maxPerOrder,omitempty"`
+	Step        int `json:"step,omitempty"`
+
+	// Variants are the purchasable options of the item. Items with variants
+	// are added to carts by variant.
+	Variants []Variant `json:"variants,omitempty"`
+
+	// Breadcrumbs are the paths from the roots of the category tree to each
+	// category of the item.
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs,omitempty"`
+
+	// Media are the images of the item, in sort order.
+	Media []Media `json:"media,omitempty"`
+}
+
+// CheckCount returns a validate.Check that fails unless count is at least
+// i.MinPerOrder, at most i.MaxPerOrder, and a multiple of i.Step.
+func (i Item) CheckCount(count int) validate.Check {
+	return func() string {
+		switch {
+		case i.MinPerOrder > 0 && count < i.MinPerOrder:
+			return fmt.Sprintf("must be at least %d for this item", i.MinPerOrder)
+		case i.MaxPerOrder > 0 && count > i.MaxPerOrder:
+			return fmt.Sprintf("must be at most %d for this item", i.MaxPerOrder)
+		case i.Step > 1 && count%i.Step != 0:
+			return fmt.Sprintf("must be a multiple of %d for this item", i.Step)
+		}
+		return ""
+	}
 }
 
 // Items retrieves all items from the db.
@@ -26,7 +82,11 @@ func Items(ctx context.Context, db Queryer) ([]Item, error) {
       id,
       name,
       description,
-      price
+      ` + PriceSQL + `,
+      price,
+      min_per_order,
+      max_per_order,
+      step
     FROM item
   `
 	rows, err := db.QueryContext(ctx, sql)
@@ -36,18 +96,24 @@ func Items(ctx context.Context, db Queryer) ([]Item, error) {
 	defer rows.Close()
 
 	var (
-		items = make([]Item, 0)
-		item  Item
+		items       = make([]Item, 0)
+		item        Item
+		price, base float64
 	)
 	for rows.Next() {
 		if err := rows.Scan(
 			&item.Id,
 			&item.Name,
 			&item.Description,
-			&item.Price,
+			&price,
+			&base,
+			&item.MinPerOrder,
+			&item.MaxPerOrder,
+			&item.Step,
 		); err != nil {
 			return nil, errors.Wrapf(err, "failed to Items/Scan\tsql=%s", sql)
 		}
+		item.setPrices(price, base)
 		items = append(items, item)
 	}
 	if err := rows.Err(); err != nil {
@@ -55,3 +121,181 @@ func Items(ctx context.Context, db Queryer) ([]Item, error) {
 	}
 	return items, nil
 }
+
+// FindItem retrieves the Item with the id passed from the db. If the item does
+// not exist, an error wrapping ErrNotFound is returned.
+func FindItem(ctx context.Context, db QueryRower, id int) (*Item, error) {
+	var sql = `
+    SELECT
+      id,
+      name,
+      description,
+      ` + PriceSQL + `,
+      price,
+      min_per_order,
+      max_per_order,
+      step
+    FROM item
+    WHERE id = ?
+  `
+	var (
+		item        Item
+		price, base float64
+	)
+	if err := db.QueryRowContext(ctx, sql, id).Scan(
+		&item.Id,
+		&item.Name,
+		&item.Description,
+		&price,
+		&base,
+		&item.MinPerOrder,
+		&item.MaxPerOrder,
+		&item.Step,
+	); err != nil {
+		return nil, errors.Wrapf(classify(err), "failed to FindItem\tsql=%s\tid=%v", sql, id)
+	}
+	item.setPrices(price, base)
+	return &item, nil
+}
+
+// FindItems retrieves the Items with the ids passed from the db in a single
+// query. Ids that do not exist are omitted from the result, so callers must
+// not assume the result is the same length as ids.
+func FindItems(ctx context.Context, db Queryer, ids []int) ([]Item, error) {
+	if len(ids) == 0 {
+		return make([]Item, 0), nil
+	}
+
+	var (
+		args         = make([]interface{}, 0, len(ids))
+		placeholders = make([]string, 0, len(ids))
+	)
+	for _, id := range ids {
+		args = append(args, id)
+		placeholders = append(placeholders, "?")
+	}
+	var sql = `
+    SELECT
+      id,
+      name,
+      description,
+      ` + PriceSQL + `,
+      price,
+      min_per_order,
+      max_per_order,
+      step
+    FROM item
+    WHERE id IN (` + strings.Join(placeholders, ", ") + `)
+  `
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to FindItems/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	var (
+		items       = make([]Item, 0, len(ids))
+		item        Item
+		price, base float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(
+			&item.Id,
+			&item.Name,
+			&item.Description,
+			&price,
+			&base,
+			&item.MinPerOrder,
+			&item.MaxPerOrder,
+			&item.Step,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to FindItems/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		item.setPrices(price, base)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to FindItems/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return items, nil
+}
+
+// EachItem calls fn with each item in the db, in id order, without holding
+// them all in memory. The Price of each is its base price, regardless of any
+// scheduled price in effect. If fn returns an error, EachItem stops and returns it.
+func EachItem(ctx context.Context, db Queryer, fn func(Item) error) error {
+	var sql = `
+    SELECT
+      id,
+      name,
+      description,
+      price,
+      min_per_order,
+      max_per_order,
+      step
+    FROM item
+    ORDER BY id
+  `
+	rows, err := db.QueryContext(ctx, sql)
+	if err != nil {
+		return errors.Wrapf(err, "failed to EachItem/QueryContext\tsql=%s", sql)
+	}
+	defer rows.Close()
+
+	var item Item
+	for rows.Next() {
+		if err := rows.Scan(
+			&item.Id,
+			&item.Name,
+			&item.Description,
+			&item.Price,
+			&item.MinPerOrder,
+			&item.MaxPerOrder,
+			&item.Step,
+		); err != nil {
+			return errors.Wrapf(err, "failed to EachItem/Scan\tsql=%s", sql)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrapf(err, "failed to EachItem/Err\tsql=%s", sql)
+	}
+	return nil
+}
+
+// UpsertItem inserts i into the db, or updates the item with i.Id if it
+// exists, and returns the item's id. An i.Id of 0 always inserts a new item.
+// i.Price is the base price of the item, and a change of it is recorded; see
+// BasePrices.
+func UpsertItem(ctx context.Context, db Execer, i Item) (int, error) {
+	var sql = `
+    INSERT INTO item (id, name, description, price, min_per_order, max_per_order, step)
+    VALUES (NULLIF(?, 0), ?, ?, ?, ?, ?, ?)
+    ON DUPLICATE KEY UPDATE
+      name = VALUES(name),
+      description = VALUES(description),
+      price = VALUES(price),
+      min_per_order = VALUES(min_per_order),
+      max_per_order = VALUES(max_per_order),
+      step = VALUES(step)
+  `
+	var args = []interface{}{i.Id, i.Name, i.Description, i.Price, i.MinPerOrder, i.MaxPerOrder, i.Step}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrapf(classify(err), "failed to UpsertItem/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	var id = i.Id
+	if id == 0 {
+		lastId, err := res.LastInsertId()
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to UpsertItem/LastInsertId\tsql=%s\targs=%v", sql, args)
+		}
+		id = int(lastId)
+	}
+	if err := recordBasePrice(ctx, db, id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,49 @@
+package item
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemCheckCount(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Item  Item
+		Count int
+		Exp   string
+	}{
+		{Name: "no limits", Item: Item{}, Count: 7, Exp: ""},
+		{Name: "below min", Item: Item{MinPerOrder: 2}, Count: 1, Exp: "must be at least 2 for this item"},
+		{Name: "at min", Item: Item{MinPerOrder: 2}, Count: 2, Exp: ""},
+		{Name: "above max", Item: Item{MaxPerOrder: 5}, Count: 6, Exp: "must be at most 5 for this item"},
+		{Name: "at max", Item: Item{MaxPerOrder: 5}, Count: 5, Exp: ""},
+		{Name: "off step", Item: Item{Step: 6}, Count: 8, Exp: "must be a multiple of 6 for this item"},
+		{Name: "on step", Item: Item{Step: 6}, Count: 12, Exp: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Exp, test.Item.CheckCount(test.Count)())
+		})
+	}
+}
+
+func TestVariantCheckStock(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Stock int
+		Count int
+		Exp   string
+	}{
+		{Name: "in stock", Stock: 3, Count: 3, Exp: ""},
+		{Name: "out of stock", Stock: 0, Count: 1, Exp: "must be at most 0, the stock of this variant"},
+		{Name: "exceeds stock", Stock: 3, Count: 4, Exp: "must be at most 3, the stock of this variant"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Exp, Variant{Stock: test.Stock}.CheckStock(test.Count)())
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package item
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Media is an image of an item, stored in a blob store.
+type Media struct {
+	Id     int `json:"id"`
+	ItemId int `json:"itemId"`
+
+	// URL is the URL of the original image; see SetURLs.
+	URL         string `json:"url"`
+	ContentType string `json:"contentType"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	AltText     string `json:"altText"`
+	SortOrder   int    `json:"sortOrder"`
+
+	// Thumbnails are scaled copies of the image, smallest first.
+	Thumbnails []Thumbnail `json:"thumbnails"`
+
+	// Key identifies the original image in the blob store.
+	Key string `json:"-"`
+}
+
+// Thumbnail is a copy of a Media image scaled to fit within a Size by Size
+// square.
+type Thumbnail struct {
+	Size   int    `json:"size"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+
+	// Key identifies the thumbnail in the blob store.
+	Key string `json:"-"`
+}
+
+// thumbnailRow is the JSON encoding of a Thumbnail in the thumbnails column
+// of item_media.
+type thumbnailRow struct {
+	Size   int    `json:"size"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Key    string `json:"key"`
+}
+
+// SetURLs sets the URLs of m and its thumbnails from their keys by url.
+func (m *Media) SetURLs(url func(key string) string) {
+	m.URL = url(m.Key)
+	for n := range m.Thumbnails {
+		m.Thumbnails[n].URL = url(m.Thumbnails[n].Key)
+	}
+}
+
+// Keys returns the keys of m and its thumbnails.
+func (m Media) Keys() []string {
+	var keys = []string{m.Key}
+	for _, t := range m.Thumbnails {
+		keys = append(keys, t.Key)
+	}
+	return keys
+}
+
+// CreateMedia creates m in the db and returns its id.
+func CreateMedia(ctx context.Context, db Execer, m Media) (int, error) {
+	var rows = make([]thumbnailRow, 0, len(m.Thumbnails))
+	for _, t := range m.Thumbnails {
+		rows = append(rows, thumbnailRow{Size: t.Size, Width: t.Width, Height: t.Height, Key: t.Key})
+	}
+	thumbnails, err := json.Marshal(rows)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to CreateMedia/Marshal\tthumbnails=%v", rows)
+	}
+
+	var sql = `
+    INSERT INTO item_media (item_id, blob_key, content_type, width, height, alt_text, sort_order, thumbnails)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+  `
+	var args = []interface{}{m.ItemId, m.Key, m.ContentType, m.Width, m.Height, m.AltText, m.SortOrder, thumbnails}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrapf(classify(err), "failed to CreateMedia/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to CreateMedia/LastInsertId\tres=%v", res)
+	}
+	return int(id), nil
+}
+
+// FindMedia retrieves the Media with the id passed from the db. If the media
+// does not exist, an error wrapping ErrNotFound is returned. Its URLs are
+// not set.
+func FindMedia(ctx context.Context, db QueryRower, id int) (*Media, error) {
+	var sql = `
+    SELECT
+      id,
+      item_id,
+      blob_key,
+      content_type,
+      width,
+      height,
+      alt_text,
+      sort_order,
+      thumbnails
+    FROM item_media
+    WHERE id = ?
+  `
+	var (
+		m          Media
+		thumbnails []byte
+	)
+	if err := db.QueryRowContext(ctx, sql, id).Scan(
+		&m.Id,
+		&m.ItemId,
+		&m.Key,
+		&m.ContentType,
+		&m.Width,
+		&m.Height,
+		&m.AltText,
+		&m.SortOrder,
+		&thumbnails,
+	); err != nil {
+		return nil, errors.Wrapf(classify(err), "failed to FindMedia\tsql=%s\tid=%v", sql, id)
+	}
+	if err := m.setThumbnails(thumbnails); err != nil {
+		return nil, errors.Wrapf(err, "failed to FindMedia\tid=%v", id)
+	}
+	return &m, nil
+}
+
+// setThumbnails sets the Thumbnails of m from the thumbnails column of
+// item_media.
+func (m *Media) setThumbnails(thumbnails []byte) error {
+	var rows []thumbnailRow
+	if err := json.Unmarshal(thumbnails, &rows); err != nil {
+		return errors.Wrapf(err, "failed to Media.setThumbnails/Unmarshal\tid=%v", m.Id)
+	}
+	m.Thumbnails = make([]Thumbnail, 0, len(rows))
+	for _, r := range rows {
+		m.Thumbnails = append(m.Thumbnails, Thumbnail{Size: r.Size, Width: r.Width, Height: r.Height, Key: r.Key})
+	}
+	return nil
+}
+
+// UpdateMedia sets the alt text and sort order of the media id in the db.
+func UpdateMedia(ctx context.Context, db Execer, id int, altText string, sortOrder int) error {
+	var sql = `
+    UPDATE item_media
+    SET alt_text = ?,
+        sort_order = ?
+    WHERE id = ?
+  `
+	var args = []interface{}{altText, sortOrder, id}
+	if _, err := db.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(classify(err), "failed to UpdateMedia/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// DeleteMedia deletes the media id from the db. Its blobs are not deleted.
+func DeleteMedia(ctx context.Context, db Execer, id int) error {
+	var sql = `DELETE FROM item_media WHERE id = ?`
+	if _, err := db.ExecContext(ctx, sql, id); err != nil {
+		return errors.Wrapf(err, "failed to DeleteMedia/ExecContext\tsql=%s\tid=%v", sql, id)
+	}
+	return nil
+}
+
+// ItemMedia retrieves the Media of the items with the ids passed from the db
+// in a single query, keyed by item id and in sort order. Items without media
+// are absent from the result. Their URLs are not set.
+func ItemMedia(ctx context.Context, db Queryer, itemIds []int) (map[int][]Media, error) {
+	var media = make(map[int][]Media)
+	if len(itemIds) == 0 {
+		return media, nil
+	}
+
+	var (
+		args         = make([]interface{}, 0, len(itemIds))
+		placeholders = make([]string, 0, len(itemIds))
+	)
+	for _, id := range itemIds {
+		args = append(args, id)
+		placeholders = append(placeholders, "?")
+	}
+	var sql = `
+    SELECT
+      id,
+      item_id,
+      blob_key,
+      content_type,
+      width,
+      height,
+      alt_text,
+      sort_order,
+      thumbnails
+    FROM item_media
+    WHERE item_id IN (` + strings.Join(placeholders, ", ") + `)
+    ORDER BY item_id, sort_order, id
+  `
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to ItemMedia/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			m          Media
+			thumbnails []byte
+		)
+		if err := rows.Scan(
+			&m.Id,
+			&m.ItemId,
+			&m.Key,
+			&m.ContentType,
+			&m.Width,
+			&m.Height,
+			&m.AltText,
+			&m.SortOrder,
+			&thumbnails,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to ItemMedia/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		if err := m.setThumbnails(thumbnails); err != nil {
+			return nil, errors.Wrap(err, "failed to ItemMedia")
+		}
+		media[m.ItemId] = append(media[m.ItemId], m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to ItemMedia/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return media, nil
+}
+
+// WithMedia sets the Media of each of items from the db, with URLs set by
+// url.
+func WithMedia(ctx context.Context, db Queryer, items []Item, url func(key string) string) error {
+	var ids = make([]int, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.Id)
+	}
+	media, err := ItemMedia(ctx, db, ids)
+	if err != nil {
+		return errors.Wrap(err, "failed to WithMedia")
+	}
+	for n := range items {
+		items[n].Media = media[items[n].Id]
+		for m := range items[n].Media {
+			items[n].Media[m].SetURLs(url)
+		}
+	}
+	return nil
+}
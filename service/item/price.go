@@ -0,0 +1,339 @@
+package item
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// PriceSQL is a SQL expression of the price in effect now of the item of the
+// row named item: the scheduled price that took effect last of those in
+// effect, or item.price if none is. PriceAt resolves prices the same way.
+const PriceSQL = `COALESCE(
+      (
+        SELECT item_price.price
+        FROM item_price
+        WHERE item_price.item_id = item.id
+              AND item_price.valid_from <= UTC_TIMESTAMP(6)
+              AND (item_price.valid_to IS NULL OR item_price.valid_to > UTC_TIMESTAMP(6))
+        ORDER BY item_price.valid_from DESC, item_price.id DESC
+        LIMIT 1
+      ),
+      item.price
+    )`
+
+// ScheduledPrice is a price of an item in effect for a period, such as a sale.
+type ScheduledPrice struct {
+	Id     int     `json:"id"`
+	ItemId int     `json:"itemId"`
+	Price  float64 `json:"price"`
+
+	// ValidFrom is when the price takes effect. ValidTo is when it ceases to
+	// be, or nil if it remains in effect indefinitely.
+	ValidFrom time.Time  `json:"validFrom"`
+	ValidTo   *time.Time `json:"validTo,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// In reports whether p is in effect at t.
+func (p ScheduledPrice) In(t time.Time) bool {
+	return !p.ValidFrom.After(t) && (p.ValidTo == nil || p.ValidTo.After(t))
+}
+
+// BasePrice is a change of the price of an item that applies while no
+// scheduled price is in effect. It is in effect from ValidFrom until the
+// next change.
+type BasePrice struct {
+	ItemId    int       `json:"itemId"`
+	Price     float64   `json:"price"`
+	ValidFrom time.Time `json:"validFrom"`
+}
+
+// setPrices sets i.Price to price, the price in effect, and i.RegularPrice
+// to base if a scheduled price is in effect that differs from it.
+func (i *Item) setPrices(price, base float64) {
+	i.Price, i.RegularPrice = price, nil
+	if price != base {
+		i.RegularPrice = &base
+	}
+}
+
+// PriceAt resolves the price of an item at t as PriceSQL does, from the
+// scheduled prices and base price changes of the item, each in any order.
+// current is the base price to assume if no change is recorded at or before
+// t, such as the item's price if its changes were not recorded. If a
+// scheduled price applies, it is returned too.
+func PriceAt(scheduled []ScheduledPrice, base []BasePrice, current float64, t time.Time) (float64, *ScheduledPrice) {
+	var applied *ScheduledPrice
+	for n := range scheduled {
+		var p = &scheduled[n]
+		if !p.In(t) {
+			continue
+		}
+		if applied == nil || p.ValidFrom.After(applied.ValidFrom) ||
+			(p.ValidFrom.Equal(applied.ValidFrom) && p.Id > applied.Id) {
+			applied = p
+		}
+	}
+	if applied != nil {
+		return applied.Price, applied
+	}
+
+	var last *BasePrice
+	for n := range base {
+		var b = &base[n]
+		if b.ValidFrom.After(t) {
+			continue
+		}
+		if last == nil || !b.ValidFrom.Before(last.ValidFrom) {
+			last = b
+		}
+	}
+	if last != nil {
+		return last.Price, nil
+	}
+	return current, nil
+}
+
+// ItemPrice is the price of an item at a point in time.
+type ItemPrice struct {
+	ItemId int       `json:"itemId"`
+	At     time.Time `json:"at"`
+	Price  float64   `json:"price"`
+
+	// ScheduledPrice is the scheduled price that applied, if any.
+	ScheduledPrice *ScheduledPrice `json:"scheduledPrice,omitempty"`
+}
+
+// PricesAt resolves the prices of the items with the ids passed, not of
+// their variants, at t from the db, as PriceAt does. It is the price-at-time
+// API that snapshots are priced by: prices may be neither scheduled nor
+// cancelled in the past, and base price changes are appended as they are
+// made, so the price resolved for a past t does not change however prices
+// change later. Times before base prices were recorded resolve to the
+// current base price, and are not covered by that guarantee. Ids that do not
+// exist are omitted from the result.
+func PricesAt(ctx context.Context, db Queryer, ids []int, t time.Time) (map[int]ItemPrice, error) {
+	items, err := FindItems(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		prices = make(map[int]ItemPrice, len(items))
+		to     = t.Add(time.Microsecond)
+	)
+	for _, i := range items {
+		var current = i.Price
+		if i.RegularPrice != nil {
+			current = *i.RegularPrice
+		}
+		scheduled, err := ScheduledPrices(ctx, db, i.Id, t, to)
+		if err != nil {
+			return nil, err
+		}
+		base, err := BasePrices(ctx, db, i.Id, t, to)
+		if err != nil {
+			return nil, err
+		}
+		var p = ItemPrice{ItemId: i.Id, At: t.UTC()}
+		p.Price, p.ScheduledPrice = PriceAt(scheduled, base, current, t)
+		prices[i.Id] = p
+	}
+	return prices, nil
+}
+
+// ScheduledPrices retrieves the scheduled prices of itemId in effect at any
+// time in [from, to), ordered by when they take effect. A zero from or to
+// leaves the range unbounded on that side.
+func ScheduledPrices(ctx context.Context, db Queryer, itemId int, from, to time.Time) ([]ScheduledPrice, error) {
+	var sql = `
+    SELECT
+      id,
+      item_id,
+      price,
+      valid_from,
+      valid_to,
+      created_at
+    FROM item_price
+    WHERE item_id = ?
+          AND (? OR valid_to IS NULL OR valid_to > ?)
+          AND (? OR valid_from < ?)
+    ORDER BY valid_from, id
+  `
+	var args = []interface{}{itemId, from.IsZero(), from.UTC(), to.IsZero(), to.UTC()}
+
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to ScheduledPrices/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	var prices = make([]ScheduledPrice, 0)
+	for rows.Next() {
+		var (
+			p                    ScheduledPrice
+			validFrom, createdAt mysql.NullTime
+			validTo              mysql.NullTime
+		)
+		if err := rows.Scan(
+			&p.Id,
+			&p.ItemId,
+			&p.Price,
+			&validFrom,
+			&validTo,
+			&createdAt,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to ScheduledPrices/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		p.ValidFrom, p.CreatedAt = validFrom.Time, createdAt.Time
+		if validTo.Valid {
+			p.ValidTo = &validTo.Time
+		}
+		prices = append(prices, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to ScheduledPrices/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return prices, nil
+}
+
+// BasePrices retrieves the base price changes of itemId in effect at any time
+// in [from, to), oldest first: those made in the range, and the one in effect
+// at from. A zero from or to leaves the range unbounded on that side.
+func BasePrices(ctx context.Context, db Queryer, itemId int, from, to time.Time) ([]BasePrice, error) {
+	var sql = `
+    SELECT
+      item_id,
+      price,
+      valid_from
+    FROM item_base_price
+    WHERE item_id = ?
+          AND (? OR valid_from < ?)
+          AND (
+            ?
+            OR valid_from >= ?
+            OR id = (
+              SELECT id
+              FROM item_base_price
+              WHERE item_id = ?
+                    AND valid_from < ?
+              ORDER BY valid_from DESC, id DESC
+              LIMIT 1
+            )
+          )
+    ORDER BY valid_from, id
+  `
+	var args = []interface{}{itemId, to.IsZero(), to.UTC(), from.IsZero(), from.UTC(), itemId, from.UTC()}
+
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to BasePrices/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	var prices = make([]BasePrice, 0)
+	for rows.Next() {
+		var (
+			p         BasePrice
+			validFrom mysql.NullTime
+		)
+		if err := rows.Scan(&p.ItemId, &p.Price, &validFrom); err != nil {
+			return nil, errors.Wrapf(err, "failed to BasePrices/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		p.ValidFrom = validFrom.Time
+		prices = append(prices, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to BasePrices/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return prices, nil
+}
+
+// recordBasePrice appends the price of the item id to its base price changes
+// in the db, unless it is the price last recorded.
+func recordBasePrice(ctx context.Context, db Execer, id int) error {
+	var sql = `
+    INSERT INTO item_base_price (item_id, price, valid_from)
+    SELECT id, price, UTC_TIMESTAMP(6)
+    FROM item
+    WHERE id = ?
+          AND NOT price <=> (
+            SELECT price
+            FROM item_base_price
+            WHERE item_id = ?
+            ORDER BY valid_from DESC, id DESC
+            LIMIT 1
+          )
+  `
+	if _, err := db.ExecContext(ctx, sql, id, id); err != nil {
+		return errors.Wrapf(err, "failed to recordBasePrice\tsql=%s\tid=%v", sql, id)
+	}
+	return nil
+}
+
+// CreateScheduledPrice inserts p into the db and returns its id. p.Id and
+// p.CreatedAt are ignored. If the item does not exist, an error wrapping
+// ErrInvalid is returned.
+func CreateScheduledPrice(ctx context.Context, db ExecQueryer, p ScheduledPrice) (int, error) {
+	if _, err := FindItem(ctx, db, p.ItemId); err != nil {
+		if errors.Cause(err) == ErrNotFound {
+			err = errors.Wrap(ErrInvalid, err.Error())
+		}
+		return 0, err
+	}
+
+	var sql = `
+    INSERT INTO item_price (item_id, price, valid_from, valid_to, created_at)
+    VALUES (?, ?, ?, ?, UTC_TIMESTAMP(6))
+  `
+	var validTo *time.Time
+	if p.ValidTo != nil {
+		var t = p.ValidTo.UTC()
+		validTo = &t
+	}
+	var args = []interface{}{p.ItemId, p.Price, p.ValidFrom.UTC(), validTo}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrapf(classify(err), "failed to CreateScheduledPrice/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to CreateScheduledPrice/LastInsertId\tsql=%s\targs=%v", sql, args)
+	}
+	return int(id), nil
+}
+
+// DeleteScheduledPrice deletes the scheduled price id of itemId from the db,
+// provided it has not taken effect, so that the prices that applied are
+// never rewritten. If the price does not exist, an error wrapping
+// ErrNotFound is returned; if it has taken effect, an error wrapping
+// ErrConflict is returned.
+func DeleteScheduledPrice(ctx context.Context, db ExecQueryer, itemId, id int) error {
+	var sql = `
+    DELETE FROM item_price
+    WHERE id = ?
+          AND item_id = ?
+          AND valid_from > UTC_TIMESTAMP(6)
+  `
+	res, err := db.ExecContext(ctx, sql, id, itemId)
+	if err != nil {
+		return errors.Wrapf(err, "failed to DeleteScheduledPrice/ExecContext\tsql=%s\tid=%v", sql, id)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to DeleteScheduledPrice/RowsAffected\tsql=%s\tid=%v", sql, id)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var exists = `SELECT id FROM item_price WHERE id = ? AND item_id = ?`
+	if err := db.QueryRowContext(ctx, exists, id, itemId).Scan(&id); err != nil {
+		return errors.Wrapf(classify(err), "failed to DeleteScheduledPrice\tsql=%s\tid=%v", exists, id)
+	}
+	return errors.Wrapf(ErrConflict, "failed to DeleteScheduledPrice: price has taken effect\tid=%v", id)
+}
@@ -0,0 +1,61 @@
+package item
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceAt(t *testing.T) {
+	var (
+		day0    = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		day     = func(n int) time.Time { return day0.AddDate(0, 0, n) }
+		dayPtr  = func(n int) *time.Time { t := day(n); return &t }
+		base    = []BasePrice{{Price: 10, ValidFrom: day(0)}, {Price: 12, ValidFrom: day(5)}}
+		forever = ScheduledPrice{Id: 1, Price: 11, ValidFrom: day(20)}
+		sale    = ScheduledPrice{Id: 2, Price: 8, ValidFrom: day(2), ValidTo: dayPtr(4)}
+		flash   = ScheduledPrice{Id: 3, Price: 6, ValidFrom: day(3), ValidTo: dayPtr(30)}
+		tied    = ScheduledPrice{Id: 4, Price: 7, ValidFrom: day(3), ValidTo: dayPtr(4)}
+	)
+	tests := []struct {
+		Name      string
+		Scheduled []ScheduledPrice
+		At        time.Time
+		Price     float64
+		Applied   int
+	}{
+		{Name: "before records", At: day(-1), Price: 15},
+		{Name: "base", At: day(1), Price: 10},
+		{Name: "later base", At: day(6), Price: 12},
+		{Name: "base change at t", At: day(5), Price: 12},
+		{Name: "sale", Scheduled: []ScheduledPrice{sale}, At: day(2), Price: 8, Applied: 2},
+		{Name: "sale ended", Scheduled: []ScheduledPrice{sale}, At: day(4), Price: 10},
+		{Name: "last to take effect", Scheduled: []ScheduledPrice{flash, sale}, At: day(3), Price: 6, Applied: 3},
+		{Name: "ties by id", Scheduled: []ScheduledPrice{tied, flash}, At: day(3), Price: 7, Applied: 4},
+		{Name: "open ended", Scheduled: []ScheduledPrice{forever, flash}, At: day(40), Price: 11, Applied: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			price, applied := PriceAt(test.Scheduled, base, 15, test.At)
+			require.Equal(t, test.Price, price)
+			if test.Applied == 0 {
+				require.Nil(t, applied)
+				return
+			}
+			require.Equal(t, test.Applied, applied.Id)
+		})
+	}
+}
+
+func TestSetPrices(t *testing.T) {
+	var i Item
+	i.setPrices(8, 10)
+	require.Equal(t, 8.0, i.Price)
+	require.Equal(t, 10.0, *i.RegularPrice)
+
+	i.setPrices(10, 10)
+	require.Equal(t, 10.0, i.Price)
+	require.Nil(t, i.RegularPrice)
+}
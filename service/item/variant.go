@@ -0,0 +1,150 @@
+package item
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/pkg/errors"
+)
+
+// Variant is a purchasable option of an item, such as a size or color.
+type Variant struct {
+	Id     int    `json:"id"`
+	ItemId int    `json:"itemId"`
+	SKU    string `json:"sku"`
+
+	// Attributes distinguish the variant from the other variants of its
+	// item, e.g. {"size": "M", "color": "red"}.
+	Attributes map[string]string `json:"attributes"`
+
+	// Price overrides the price of the variant's item, unless nil.
+	Price *float64 `json:"price,omitempty"`
+
+	// Stock is the number of units available. Carts do not reserve stock.
+	Stock int `json:"stock"`
+}
+
+// CheckStock returns a validate.Check that fails if count exceeds v.Stock.
+func (v Variant) CheckStock(count int) validate.Check {
+	return func() string {
+		if count > v.Stock {
+			return fmt.Sprintf("must be at most %d, the stock of this variant", v.Stock)
+		}
+		return ""
+	}
+}
+
+// FindVariant retrieves the Variant with the id passed from the db. If the
+// variant does not exist, an error wrapping ErrNotFound is returned.
+func FindVariant(ctx context.Context, db QueryRower, id int) (*Variant, error) {
+	var sql = `
+    SELECT
+      id,
+      item_id,
+      sku,
+      attributes,
+      price,
+      stock
+    FROM item_variant
+    WHERE id = ?
+  `
+	var (
+		v          Variant
+		attributes []byte
+	)
+	if err := db.QueryRowContext(ctx, sql, id).Scan(
+		&v.Id,
+		&v.ItemId,
+		&v.SKU,
+		&attributes,
+		&v.Price,
+		&v.Stock,
+	); err != nil {
+		return nil, errors.Wrapf(classify(err), "failed to FindVariant\tsql=%s\tid=%v", sql, id)
+	}
+	if err := json.Unmarshal(attributes, &v.Attributes); err != nil {
+		return nil, errors.Wrapf(err, "failed to FindVariant/Unmarshal\tid=%v", id)
+	}
+	return &v, nil
+}
+
+// ItemVariants retrieves the Variants of the items with the ids passed from
+// the db in a single query, keyed by item id and ordered by variant id. Items
+// without variants are absent from the result.
+func ItemVariants(ctx context.Context, db Queryer, itemIds []int) (map[int][]Variant, error) {
+	var variants = make(map[int][]Variant)
+	if len(itemIds) == 0 {
+		return variants, nil
+	}
+
+	var (
+		args         = make([]interface{}, 0, len(itemIds))
+		placeholders = make([]string, 0, len(itemIds))
+	)
+	for _, id := range itemIds {
+		args = append(args, id)
+		placeholders = append(placeholders, "?")
+	}
+	var sql = `
+    SELECT
+      id,
+      item_id,
+      sku,
+      attributes,
+      price,
+      stock
+    FROM item_variant
+    WHERE item_id IN (` + strings.Join(placeholders, ", ") + `)
+    ORDER BY id
+  `
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to ItemVariants/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			v          Variant
+			attributes []byte
+		)
+		if err := rows.Scan(
+			&v.Id,
+			&v.ItemId,
+			&v.SKU,
+			&attributes,
+			&v.Price,
+			&v.Stock,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to ItemVariants/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		if err := json.Unmarshal(attributes, &v.Attributes); err != nil {
+			return nil, errors.Wrapf(err, "failed to ItemVariants/Unmarshal\tid=%v", v.Id)
+		}
+		variants[v.ItemId] = append(variants[v.ItemId], v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to ItemVariants/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return variants, nil
+}
+
+// WithVariants sets the Variants of each of items from the db.
+func WithVariants(ctx context.Context, db Queryer, items []Item) error {
+	var ids = make([]int, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.Id)
+	}
+	variants, err := ItemVariants(ctx, db, ids)
+	if err != nil {
+		return errors.Wrap(err, "failed to WithVariants")
+	}
+	for n := range items {
+		items[n].Variants = variants[items[n].Id]
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/pb"
+	"github.com/tjper/shoppingcart-server/service/validate"
+)
+
+// itemServer implements pb.ItemServiceServer over the item package.
+type itemServer struct {
+	svc *Service
+}
+
+// pbItem converts i to its protobuf representation.
+func (svc *Service) pbItem(i item.Item) *pb.Item {
+	var v2 = svc.newItemV2(i)
+	return &pb.Item{
+		Id:          int64(v2.Id),
+		Name:        v2.Name,
+		Description: v2.Description,
+		Price:       &pb.Money{Amount: v2.Price.Amount, Currency: v2.Price.Currency},
+	}
+}
+
+// ListItems retrieves all items.
+func (s *itemServer) ListItems(ctx context.Context, req *pb.ListItemsRequest) (*pb.ListItemsResponse, error) {
+	items, err := item.Items(ctx, s.svc.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp = &pb.ListItemsResponse{
+		Items: make([]*pb.Item, 0, len(items)),
+	}
+	for _, i := range items {
+		resp.Items = append(resp.Items, s.svc.pbItem(i))
+	}
+	return resp, nil
+}
+
+// GetItem retrieves an item.
+func (s *itemServer) GetItem(ctx context.Context, req *pb.GetItemRequest) (*pb.GetItemResponse, error) {
+	var v validate.Validator
+	v.Check("id", validate.Required(req.Id))
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	found, err := item.FindItem(ctx, s.svc.DB, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetItemResponse{Item: s.svc.pbItem(*found)}, nil
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/media"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// errNoBlobStore indicates the service was initialized without a blob
+// store, so item images may not be stored.
+var errNoBlobStore = errors.New("no blob store")
+
+// MediaRoutes defines the REST endpoint serving the blobs of the service's
+// blob store.
+func (svc *Service) MediaRoutes(r chi.Router) {
+	r.Get("/media/*", svc.GetMediaHandler())
+}
+
+// ItemMediaRoutes defines the item media resource REST endpoints. They are
+// restricted to admins.
+func (svc *Service) ItemMediaRoutes(r chi.Router) {
+	r.Use(svc.requireAdmin)
+	r.Post("/admin/items/{id}/media", svc.PostItemMediaHandler())
+	r.Put("/admin/items/{id}/media/{mediaId}", svc.PutItemMediaHandler())
+	r.Delete("/admin/items/{id}/media/{mediaId}", svc.DeleteItemMediaHandler())
+}
+
+// GetMediaHandler serves a blob, such as an item image.
+func (svc *Service) GetMediaHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "GetMediaHandler",
+		Summary: "Retrieve a blob, such as an item image or thumbnail, by its key. Blobs never change, so may be cached indefinitely.",
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+		if svc.Blobs == nil {
+			svc.HandleError(w, errNoBlobStore)
+			return
+		}
+
+		var key = chi.URLParam(r, "*")
+		rc, err := svc.Blobs.Open(ctx, key)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		defer rc.Close()
+
+		w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(key)))
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if _, err := io.Copy(w, rc); err != nil {
+			svc.Zap.Info("failed to GetMediaHandler/Copy", zap.String("key", key), zap.Error(err))
+		}
+	})
+}
+
+// PostItemMediaHandler uploads an image of an item.
+func (svc *Service) PostItemMediaHandler() http.HandlerFunc {
+	type Response struct {
+		Media item.Media `json:"media"`
+	}
+	return describe(operation{
+		Id:       "PostItemMediaHandler",
+		Summary:  "Upload a JPEG, PNG or GIF image of an item as the file field of a multipart/form-data request, with optional altText and sortOrder fields. Thumbnails are generated in each configured size.",
+		Response: Response{},
+		Status:   http.StatusCreated,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		itemId, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var maxBytes = svc.Viper.GetInt64(EnvVarMediaMaxBytes)
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes+1<<20)
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			svc.HandleError(w, errors.Wrapf(errMalformed, "failed to PostItemMedia/ParseMultipartForm\terr=%v", err))
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			svc.HandleError(w, errors.Wrapf(errMalformed, "failed to PostItemMedia/FormFile\terr=%v", err))
+			return
+		}
+		defer file.Close()
+		b, err := ioutil.ReadAll(io.LimitReader(file, maxBytes+1))
+		if err != nil {
+			svc.HandleError(w, errors.Wrapf(errMalformed, "failed to PostItemMedia/ReadAll\terr=%v", err))
+			return
+		}
+
+		var (
+			v         validate.Validator
+			altText   = r.FormValue("altText")
+			sortOrder int
+		)
+		v.Check("file", validate.IntMax(len(b), int(maxBytes)))
+		v.Check("altText", validate.MaxLen(altText, 255))
+		if param := r.FormValue("sortOrder"); param != "" {
+			sortOrder, err = strconv.Atoi(param)
+			if err != nil {
+				v.Check("sortOrder", fail("must be an integer"))
+			}
+		}
+		if err := v.Err(); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		m, err := svc.createItemMedia(ctx, itemId, b, altText, sortOrder)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		var resp = Response{
+			Media: *m,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// PutItemMediaHandler updates the alt text and sort order of an item image.
+func (svc *Service) PutItemMediaHandler() http.HandlerFunc {
+	type (
+		Request struct {
+			AltText   string `json:"altText" validate:"max=255"`
+			SortOrder int    `json:"sortOrder"`
+		}
+		Response struct {
+			Media item.Media `json:"media"`
+		}
+	)
+	return describe(operation{
+		Id:       "PutItemMediaHandler",
+		Summary:  "Update the alt text and sort order of an item image.",
+		Request:  Request{},
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = r.Context()
+			req Request
+		)
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		itemId, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		mediaId, err := urlParamId(r, "mediaId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var m *item.Media
+		if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+			var err error
+			if m, err = findItemMedia(ctx, tx, itemId, mediaId); err != nil {
+				return err
+			}
+			m.AltText, m.SortOrder = req.AltText, req.SortOrder
+			return item.UpdateMedia(ctx, tx, mediaId, m.AltText, m.SortOrder)
+		}); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if svc.Blobs != nil {
+			m.SetURLs(svc.Blobs.URL)
+		}
+
+		var resp = Response{
+			Media: *m,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// DeleteItemMediaHandler deletes an item image and its thumbnails.
+func (svc *Service) DeleteItemMediaHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "DeleteItemMediaHandler",
+		Summary: "Delete an item image and its thumbnails.",
+		Status:  http.StatusNoContent,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+		itemId, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		mediaId, err := urlParamId(r, "mediaId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var m *item.Media
+		if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+			var err error
+			if m, err = findItemMedia(ctx, tx, itemId, mediaId); err != nil {
+				return err
+			}
+			return item.DeleteMedia(ctx, tx, mediaId)
+		}); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		svc.deleteBlobs(ctx, m.Keys())
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// findItemMedia retrieves the media id of the item itemId. If the media
+// does not exist or is not of the item, an error wrapping item.ErrNotFound
+// is returned.
+func findItemMedia(ctx context.Context, db item.QueryRower, itemId, id int) (*item.Media, error) {
+	m, err := item.FindMedia(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.ItemId != itemId {
+		return nil, errors.Wrapf(item.ErrNotFound, "failed to findItemMedia\titemId=%v\tid=%v", itemId, id)
+	}
+	return m, nil
+}
+
+// createItemMedia stores the image b of the item itemId and its thumbnails
+// in the blob store, records them in the db, and returns the resulting
+// media. If b is not a supported image, an error wrapping errInvalid is
+// returned.
+func (svc *Service) createItemMedia(ctx context.Context, itemId int, b []byte, altText string, sortOrder int) (*item.Media, error) {
+	if svc.Blobs == nil {
+		return nil, errNoBlobStore
+	}
+	sizes, err := svc.thumbnailSizes()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := item.FindItem(ctx, svc.DB, itemId); err != nil {
+		return nil, err
+	}
+
+	img, contentType, err := media.Decode(b)
+	if err != nil {
+		return nil, errors.Wrap(errInvalid, err.Error())
+	}
+	prefix, err := mediaKeyPrefix(itemId)
+	if err != nil {
+		return nil, err
+	}
+	var m = item.Media{
+		ItemId:      itemId,
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		AltText:     altText,
+		SortOrder:   sortOrder,
+		Key:         prefix + "/original" + media.Extension(contentType),
+	}
+
+	var stored []string
+	if err := func() error {
+		if err := svc.Blobs.Put(ctx, m.Key, bytes.NewReader(b)); err != nil {
+			return err
+		}
+		stored = append(stored, m.Key)
+
+		var thumbnailType = media.ThumbnailType(contentType)
+		for _, size := range sizes {
+			var (
+				thumb = media.Thumbnail(img, size)
+				buf   bytes.Buffer
+			)
+			if err := media.Encode(&buf, thumb, thumbnailType); err != nil {
+				return err
+			}
+			var t = item.Thumbnail{
+				Size:   size,
+				Width:  thumb.Bounds().Dx(),
+				Height: thumb.Bounds().Dy(),
+				Key:    fmt.Sprintf("%s/%d%s", prefix, size, media.Extension(thumbnailType)),
+			}
+			if err := svc.Blobs.Put(ctx, t.Key, &buf); err != nil {
+				return err
+			}
+			stored = append(stored, t.Key)
+			m.Thumbnails = append(m.Thumbnails, t)
+		}
+
+		m.Id, err = item.CreateMedia(ctx, svc.DB, m)
+		return err
+	}(); err != nil {
+		svc.deleteBlobs(ctx, stored)
+		return nil, err
+	}
+
+	m.SetURLs(svc.Blobs.URL)
+	return &m, nil
+}
+
+// thumbnailSizes parses the thumbnail sizes specified in viper, and returns
+// them in ascending order.
+func (svc *Service) thumbnailSizes() ([]int, error) {
+	var sizes []int
+	for _, param := range strings.Split(svc.Viper.GetString(EnvVarMediaThumbnailSizes), ",") {
+		if param = strings.TrimSpace(param); param == "" {
+			continue
+		}
+		size, err := strconv.Atoi(param)
+		if err != nil || size < 1 {
+			return nil, errors.Errorf("failed to thumbnailSizes\tsize=%s", param)
+		}
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+	return sizes, nil
+}
+
+// mediaKeyPrefix returns a new, random prefix for the blob keys of an image
+// of the item itemId and its thumbnails.
+func mediaKeyPrefix(itemId int) (string, error) {
+	var b = make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to mediaKeyPrefix/Read")
+	}
+	return fmt.Sprintf("items/%d/%s", itemId, hex.EncodeToString(b)), nil
+}
+
+// deleteBlobs deletes the blobs keys from the blob store. Failures are
+// logged rather than returned, as they leave only unreferenced blobs.
+func (svc *Service) deleteBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := svc.Blobs.Delete(ctx, key); err != nil {
+			svc.Zap.Error("failed to deleteBlobs", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// withCartMedia sets the Media of the item of each of cartItems, as the cart
+// endpoints return them, if the service has a blob store to serve them.
+func (svc *Service) withCartMedia(ctx context.Context, cartItems []cart.CartItem) error {
+	if svc.Blobs == nil {
+		return nil
+	}
+	var items = make([]item.Item, 0, len(cartItems))
+	for _, ci := range cartItems {
+		items = append(items, ci.Item)
+	}
+	if err := item.WithMedia(ctx, svc.DB, items, svc.Blobs.URL); err != nil {
+		return err
+	}
+	for n := range cartItems {
+		cartItems[n].Item.Media = items[n].Media
+	}
+	return nil
+}
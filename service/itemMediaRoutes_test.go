@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tjper/shoppingcart-server/service/blob"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMediaHandler(t *testing.T) {
+	root, err := ioutil.TempDir("", "blobs")
+	require.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	var svc = newTestService()
+	svc.Blobs, err = blob.NewFS(root, "/media")
+	require.Nil(t, err)
+	WithRouters(svc.Routes())(svc)
+	require.Nil(t, svc.Blobs.Put(context.Background(), "items/1/a/original.png", strings.NewReader("png")))
+
+	tests := []struct {
+		Name        string
+		Path        string
+		Status      int
+		ContentType string
+	}{
+		{Name: "found", Path: "/media/items/1/a/original.png", Status: http.StatusOK, ContentType: "image/png"},
+		{Name: "not found", Path: "/media/items/1/b/original.png", Status: http.StatusNotFound},
+		{Name: "parent", Path: "/media/items/../../secret", Status: http.StatusBadRequest},
+		{Name: "temporary", Path: "/media/items/1/a/original.png.tmp", Status: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var w = httptest.NewRecorder()
+			svc.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.Path, nil))
+			require.Equal(t, test.Status, w.Code)
+			if test.ContentType != "" {
+				require.Equal(t, test.ContentType, w.Header().Get("Content-Type"))
+				require.Equal(t, "png", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestThumbnailSizes(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Sizes    string
+		Expected []int
+		Err      bool
+	}{
+		{Name: "sorted", Sizes: "1024, 128,512", Expected: []int{128, 512, 1024}},
+		{Name: "none", Sizes: "", Expected: nil},
+		{Name: "invalid", Sizes: "128,big", Err: true},
+		{Name: "zero", Sizes: "0", Err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var svc = newTestService()
+			svc.Viper.Set(EnvVarMediaThumbnailSizes, test.Sizes)
+			sizes, err := svc.thumbnailSizes()
+			if test.Err {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, test.Expected, sizes)
+		})
+	}
+}
+
+func TestItemMediaRoutesForbidden(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		UserId string
+		Status int
+	}{
+		{Name: "upload anonymous", Method: http.MethodPost, Path: "/v1/admin/items/1/media", Status: http.StatusUnauthorized},
+		{Name: "upload customer", Method: http.MethodPost, Path: "/v2/admin/items/1/media", UserId: "7", Status: http.StatusForbidden},
+		{Name: "update customer", Method: http.MethodPut, Path: "/v1/admin/items/1/media/1", UserId: "7", Status: http.StatusForbidden},
+		{Name: "delete customer", Method: http.MethodDelete, Path: "/v1/admin/items/1/media/1", UserId: "7", Status: http.StatusForbidden},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(test.Method, test.Path, nil)
+			)
+			if test.UserId != "" {
+				r.Header.Set(headerUserId, test.UserId)
+			}
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/outbox"
+	"github.com/tjper/shoppingcart-server/service/validate"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+// maxScheduledPrice bounds the price of a scheduled price.
+const maxScheduledPrice = 1e9
+
+// ItemPriceRoutes defines the item price resource REST endpoints. Prices are
+// scheduled and cancelled by admins.
+func (svc *Service) ItemPriceRoutes(r chi.Router) {
+	r.Get("/items/{id}/price", svc.GetItemPriceHandler())
+	r.Get("/items/{id}/prices", svc.GetItemPricesHandler())
+	r.Group(func(r chi.Router) {
+		r.Use(svc.requireAdmin)
+		r.Post("/admin/items/{id}/prices", svc.PostItemPriceHandler())
+		r.Delete("/admin/items/{id}/prices/{priceId}", svc.DeleteItemPriceHandler())
+	})
+}
+
+// GetItemPriceHandler resolves the price of an item at a point in time.
+func (svc *Service) GetItemPriceHandler() http.HandlerFunc {
+	type Response struct {
+		At    time.Time `json:"at"`
+		Price float64   `json:"price"`
+
+		// ScheduledPrice is the scheduled price that applied, if any.
+		ScheduledPrice *item.ScheduledPrice `json:"scheduledPrice,omitempty"`
+	}
+	return describe(operation{
+		Id:       "GetItemPriceHandler",
+		Summary:  "Resolve the price of an item, not of its variants, at the RFC 3339 at query parameter, or now if absent. The price resolved for a past time does not change, as prices are neither scheduled nor cancelled in the past; cart snapshots are priced the same way. Times before base prices were recorded resolve to the current base price.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		at, err := queryTime(r, "at")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if at.IsZero() {
+			at = time.Now()
+		}
+
+		prices, err := item.PricesAt(ctx, svc.DB, []int{id}, at)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		p, ok := prices[id]
+		if !ok {
+			svc.HandleError(w, errors.Wrapf(item.ErrNotFound, "failed to GetItemPrice\tid=%v", id))
+			return
+		}
+
+		var resp = Response{
+			At:             p.At,
+			Price:          p.Price,
+			ScheduledPrice: p.ScheduledPrice,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// GetItemPricesHandler retrieves the price history and schedule of an item.
+func (svc *Service) GetItemPricesHandler() http.HandlerFunc {
+	type Response struct {
+		// Price is the price in effect now, and RegularPrice the base price
+		// it replaces, if a scheduled price is in effect.
+		Price        float64  `json:"price"`
+		RegularPrice *float64 `json:"regularPrice,omitempty"`
+
+		BasePrices      []item.BasePrice      `json:"basePrices"`
+		ScheduledPrices []item.ScheduledPrice `json:"scheduledPrices"`
+	}
+	return describe(operation{
+		Id:       "GetItemPricesHandler",
+		Summary:  "Retrieve the base price changes and scheduled prices of an item in effect at any time from the RFC 3339 from (inclusive) to the to (exclusive) query parameters, each optional. Of the scheduled prices in effect at a time, the one that took effect last applies; while none is, the base price does.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		from, err := queryTime(r, "from")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		to, err := queryTime(r, "to")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		found, err := item.FindItem(ctx, svc.DB, id)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		base, err := item.BasePrices(ctx, svc.DB, id, from, to)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		scheduled, err := item.ScheduledPrices(ctx, svc.DB, id, from, to)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Price:           found.Price,
+			RegularPrice:    found.RegularPrice,
+			BasePrices:      base,
+			ScheduledPrices: scheduled,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// PostItemPriceHandler schedules a price of an item.
+func (svc *Service) PostItemPriceHandler() http.HandlerFunc {
+	type (
+		Request struct {
+			Price float64 `json:"price"`
+
+			// ValidFrom is when the price takes effect, or omitted for now.
+			// ValidTo is when it ceases to be, or omitted for never.
+			ValidFrom *time.Time `json:"validFrom"`
+			ValidTo   *time.Time `json:"validTo"`
+		}
+		Response struct {
+			ScheduledPrice item.ScheduledPrice `json:"scheduledPrice"`
+		}
+	)
+	return describe(operation{
+		Id:       "PostItemPriceHandler",
+		Summary:  "Schedule a price of an item from validFrom, or now, until validTo, or indefinitely. Prices may not be scheduled in the past, so that the prices that applied are never rewritten.",
+		Request:  Request{},
+		Response: Response{},
+		Status:   http.StatusCreated,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = r.Context()
+			req Request
+		)
+		itemId, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var (
+			now = time.Now()
+			p   = item.ScheduledPrice{ItemId: itemId, Price: req.Price, ValidFrom: now, ValidTo: req.ValidTo}
+			v   validate.Validator
+		)
+		if req.ValidFrom != nil {
+			p.ValidFrom = *req.ValidFrom
+		}
+		v.Check("price", validate.FloatRange(p.Price, 0, maxScheduledPrice))
+		v.Check("validFrom", func() string {
+			// A second of leeway allows for clock skew with the client.
+			if p.ValidFrom.Before(now.Add(-time.Second)) {
+				return "must not be in the past"
+			}
+			return ""
+		})
+		v.Check("validTo", func() string {
+			if p.ValidTo != nil && !p.ValidTo.After(p.ValidFrom) {
+				return "must be after validFrom"
+			}
+			return ""
+		})
+		if err := v.Err(); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+			id, err := item.CreateScheduledPrice(ctx, tx, p)
+			if err != nil {
+				return err
+			}
+			p.Id, p.CreatedAt, p.ValidFrom = id, now.UTC(), p.ValidFrom.UTC()
+			if p.ValidTo != nil {
+				var validTo = p.ValidTo.UTC()
+				p.ValidTo = &validTo
+			}
+			return outbox.Insert(ctx, tx, item.EventItemPriceScheduled, itemId, item.ItemPriceScheduledEvent{ScheduledPrice: p})
+		}); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		var resp = Response{
+			ScheduledPrice: p,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// DeleteItemPriceHandler cancels a scheduled price of an item.
+func (svc *Service) DeleteItemPriceHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "DeleteItemPriceHandler",
+		Summary: "Cancel a scheduled price of an item that has not taken effect. Prices that have taken effect may not be cancelled; schedule another price to replace them.",
+		Status:  http.StatusNoContent,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		itemId, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		id, err := urlParamId(r, "priceId")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		if err := svc.inTx(ctx, func(tx *sql.Tx) error {
+			if err := item.DeleteScheduledPrice(ctx, tx, itemId, id); err != nil {
+				return err
+			}
+			return outbox.Insert(ctx, tx, item.EventItemPriceCancelled, itemId, item.ItemPriceCancelledEvent{ItemId: itemId, PriceId: id})
+		}); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
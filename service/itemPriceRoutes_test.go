@@ -0,0 +1,74 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemPriceRoutesInvalid(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		Body   string
+		Status int
+	}{
+		{Name: "price malformed at", Method: http.MethodGet, Path: "/v1/items/1/price?at=noon", Status: http.StatusBadRequest},
+		{Name: "prices malformed id", Method: http.MethodGet, Path: "/v2/items/abc/prices", Status: http.StatusBadRequest},
+		{Name: "prices malformed from", Method: http.MethodGet, Path: "/v1/items/1/prices?from=yesterday", Status: http.StatusBadRequest},
+		{Name: "schedule malformed body", Method: http.MethodPost, Path: "/v1/admin/items/1/prices", Body: `{"price": "cheap"}`, Status: http.StatusBadRequest},
+		{Name: "schedule negative price", Method: http.MethodPost, Path: "/v1/admin/items/1/prices", Body: `{"price": -1}`, Status: http.StatusUnprocessableEntity},
+		{Name: "schedule in the past", Method: http.MethodPost, Path: "/v1/admin/items/1/prices", Body: `{"price": 1, "validFrom": "2020-01-01T00:00:00Z"}`, Status: http.StatusUnprocessableEntity},
+		{Name: "schedule ends before start", Method: http.MethodPost, Path: "/v2/admin/items/1/prices", Body: `{"price": 1, "validFrom": "2999-01-02T00:00:00Z", "validTo": "2999-01-01T00:00:00Z"}`, Status: http.StatusUnprocessableEntity},
+		{Name: "cancel malformed price id", Method: http.MethodDelete, Path: "/v1/admin/items/1/prices/abc", Status: http.StatusBadRequest},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = asAdmin(httptest.NewRequest(test.Method, test.Path, strings.NewReader(test.Body)))
+			)
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+		})
+	}
+}
+
+func TestItemPriceRoutesForbidden(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		UserId string
+		Status int
+	}{
+		{Name: "schedule anonymous", Method: http.MethodPost, Path: "/v1/admin/items/1/prices", Status: http.StatusUnauthorized},
+		{Name: "schedule customer", Method: http.MethodPost, Path: "/v2/admin/items/1/prices", UserId: "7", Status: http.StatusForbidden},
+		{Name: "cancel customer", Method: http.MethodDelete, Path: "/v1/admin/items/1/prices/1", UserId: "7", Status: http.StatusForbidden},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(test.Method, test.Path, strings.NewReader(`{"price": 1}`))
+			)
+			if test.UserId != "" {
+				r.Header.Set(headerUserId, test.UserId)
+			}
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+		})
+	}
+}
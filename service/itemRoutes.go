@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -19,12 +20,20 @@ func (svc *Service) GetItemsHandler() http.HandlerFunc {
 	type Response struct {
 		Items []item.Item `json:"items"`
 	}
-	return func(w http.ResponseWriter, r *http.Request) {
+	return describe(operation{
+		Id:       "GetItemsHandler",
+		Summary:  "Retrieve all items with their variants and category breadcrumbs.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
 		var ctx = r.Context()
 
 		items, err := item.Items(ctx, svc.DB)
 		if err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+			svc.HandleError(w, err)
+			return
+		}
+		if err := svc.withCatalog(ctx, items); err != nil {
+			svc.HandleError(w, err)
 			return
 		}
 
@@ -32,8 +41,24 @@ func (svc *Service) GetItemsHandler() http.HandlerFunc {
 			Items: items,
 		}
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			svc.Error(w, err, http.StatusInternalServerError)
+			svc.HandleError(w, err)
 			return
 		}
+	})
+}
+
+// withCatalog sets the variants, breadcrumbs and, if the service has a blob
+// store to serve them, media of each of items, as the item endpoints return
+// them.
+func (svc *Service) withCatalog(ctx context.Context, items []item.Item) error {
+	if err := item.WithVariants(ctx, svc.DB, items); err != nil {
+		return err
+	}
+	if err := item.WithBreadcrumbs(ctx, svc.DB, items); err != nil {
+		return err
+	}
+	if svc.Blobs == nil {
+		return nil
 	}
+	return item.WithMedia(ctx, svc.DB, items, svc.Blobs.URL)
 }
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/money"
+
+	"github.com/go-chi/chi"
+)
+
+// ItemRoutesV2 defines the v2 item resource REST endpoints.
+func (svc *Service) ItemRoutesV2(r chi.Router) {
+	r.Get("/items", svc.GetItemsV2Handler())
+}
+
+// itemV2 is the v2 representation of an item.Item, with its price as Money.
+type itemV2 struct {
+	Id          int         `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+
+	// RegularPrice is the price replaced by a scheduled price in effect,
+	// such as a sale, or nil if none is.
+	RegularPrice *money.Money `json:"regularPrice,omitempty"`
+
+	// MinPerOrder, MaxPerOrder and Step limit the count of the item's cart
+	// lines. Zero imposes no limit.
+	MinPerOrder int `json:"minPerOrder,omitempty"`
+	MaxPerOrder int `json:"maxPerOrder,omitempty"`
+	Step        int `json:"step,omitempty"`
+
+	Variants    []variantV2       `json:"variants,omitempty"`
+	Breadcrumbs []item.Breadcrumb `json:"breadcrumbs,omitempty"`
+	Media       []item.Media      `json:"media,omitempty"`
+}
+
+// newItemV2 converts i to its v2 representation.
+func (svc *Service) newItemV2(i item.Item) itemV2 {
+	var iv2 = itemV2{
+		Id:          i.Id,
+		Name:        i.Name,
+		Description: i.Description,
+		Price:       money.FromFloat(i.Price, svc.Viper.GetString(EnvVarCurrency)),
+		MinPerOrder: i.MinPerOrder,
+		MaxPerOrder: i.MaxPerOrder,
+		Step:        i.Step,
+		Breadcrumbs: i.Breadcrumbs,
+		Media:       i.Media,
+	}
+	if i.RegularPrice != nil {
+		var regular = money.FromFloat(*i.RegularPrice, svc.Viper.GetString(EnvVarCurrency))
+		iv2.RegularPrice = &regular
+	}
+	for _, v := range i.Variants {
+		iv2.Variants = append(iv2.Variants, svc.newVariantV2(v))
+	}
+	return iv2
+}
+
+// variantV2 is the v2 representation of an item.Variant, with its price as
+// Money.
+type variantV2 struct {
+	Id         int               `json:"id"`
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+
+	// Price overrides the price of the variant's item, unless nil.
+	Price *money.Money `json:"price,omitempty"`
+
+	Stock int `json:"stock"`
+}
+
+// newVariantV2 converts v to its v2 representation.
+func (svc *Service) newVariantV2(v item.Variant) variantV2 {
+	var vv2 = variantV2{
+		Id:         v.Id,
+		SKU:        v.SKU,
+		Attributes: v.Attributes,
+		Stock:      v.Stock,
+	}
+	if v.Price != nil {
+		var price = money.FromFloat(*v.Price, svc.Viper.GetString(EnvVarCurrency))
+		vv2.Price = &price
+	}
+	return vv2
+}
+
+// GetItemsV2Handler retrieves all item resources from the service.
+func (svc *Service) GetItemsV2Handler() http.HandlerFunc {
+	type Response struct {
+		Items []itemV2 `json:"items"`
+	}
+	return describe(operation{
+		Id:       "GetItemsV2Handler",
+		Summary:  "Retrieve all items with their variants and category breadcrumbs.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		items, err := item.Items(ctx, svc.DB)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if err := svc.withCatalog(ctx, items); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Items: make([]itemV2, 0, len(items)),
+		}
+		for _, i := range items {
+			resp.Items = append(resp.Items, svc.newItemV2(i))
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+	})
+}
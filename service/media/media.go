@@ -0,0 +1,183 @@
+// Package media decodes uploaded images and generates their thumbnails.
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// The content types of the supported image formats.
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	GIF  = "image/gif"
+)
+
+const (
+	// jpegQuality is the quality JPEG thumbnails are encoded at.
+	jpegQuality = 85
+
+	// maxPixels bounds the size of the images decoded, so that a small
+	// file may not claim a vast image.
+	maxPixels = 50000000
+)
+
+// ErrUnsupported indicates an image is not of a supported format, or is
+// corrupt.
+var ErrUnsupported = errors.New("media: unsupported image")
+
+// Decode decodes the image b, and returns it and its content type. If b is
+// not a JPEG, PNG or GIF image, an error wrapping ErrUnsupported is
+// returned, as it is if the image has more than 50 megapixels.
+func Decode(b []byte) (image.Image, string, error) {
+	var contentType = http.DetectContentType(b)
+	var (
+		decode       func(io.Reader) (image.Image, error)
+		decodeConfig func(io.Reader) (image.Config, error)
+	)
+	switch contentType {
+	case JPEG:
+		decode, decodeConfig = jpeg.Decode, jpeg.DecodeConfig
+	case PNG:
+		decode, decodeConfig = png.Decode, png.DecodeConfig
+	case GIF:
+		decode, decodeConfig = gif.Decode, gif.DecodeConfig
+	default:
+		return nil, "", errors.Wrapf(ErrUnsupported, "failed to Decode\tcontentType=%s", contentType)
+	}
+	config, err := decodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, "", errors.Wrapf(ErrUnsupported, "failed to Decode/DecodeConfig\tcontentType=%s\terr=%v", contentType, err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, "", errors.Wrapf(ErrUnsupported, "failed to Decode\twidth=%d\theight=%d", config.Width, config.Height)
+	}
+	img, err := decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, "", errors.Wrapf(ErrUnsupported, "failed to Decode\tcontentType=%s\terr=%v", contentType, err)
+	}
+	return img, contentType, nil
+}
+
+// Extension returns the file name extension of contentType, such as ".jpg".
+func Extension(contentType string) string {
+	switch contentType {
+	case JPEG:
+		return ".jpg"
+	case PNG:
+		return ".png"
+	case GIF:
+		return ".gif"
+	}
+	return ""
+}
+
+// ThumbnailType returns the content type thumbnails of images of
+// contentType are encoded as. Images that may be transparent have PNG
+// thumbnails; others have JPEG thumbnails.
+func ThumbnailType(contentType string) string {
+	switch contentType {
+	case PNG, GIF:
+		return PNG
+	}
+	return JPEG
+}
+
+// Encode encodes img to w as contentType, which must be JPEG or PNG.
+func Encode(w io.Writer, img image.Image, contentType string) error {
+	var err error
+	switch contentType {
+	case JPEG:
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case PNG:
+		err = png.Encode(w, img)
+	default:
+		return errors.Wrapf(ErrUnsupported, "failed to Encode\tcontentType=%s", contentType)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to Encode\tcontentType=%s", contentType)
+	}
+	return nil
+}
+
+// Thumbnail returns img scaled down to fit within a size by size square,
+// preserving its aspect ratio. Images that already fit are not scaled up.
+func Thumbnail(img image.Image, size int) image.Image {
+	var (
+		w, h   = img.Bounds().Dx(), img.Bounds().Dy()
+		tw, th = w, h
+	)
+	switch {
+	case w <= size && h <= size:
+	case w >= h:
+		tw, th = size, max(1, h*size/w)
+	default:
+		tw, th = max(1, w*size/h), size
+	}
+	return resize(toNRGBA(img), tw, th)
+}
+
+// toNRGBA converts img to an *image.NRGBA whose bounds start at the origin.
+func toNRGBA(img image.Image) *image.NRGBA {
+	var b = img.Bounds()
+	if nrgba, ok := img.(*image.NRGBA); ok && b.Min == (image.Point{}) {
+		return nrgba
+	}
+	var dst = image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// resize scales src to w by h with a box filter: each destination pixel is
+// the average of the source pixels it covers, weighted by their alpha.
+func resize(src *image.NRGBA, w, h int) *image.NRGBA {
+	var (
+		dst    = image.NewNRGBA(image.Rect(0, 0, w, h))
+		sw, sh = src.Bounds().Dx(), src.Bounds().Dy()
+	)
+	for y := 0; y < h; y++ {
+		var y0, y1 = y * sh / h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			var (
+				x0, x1        = x * sw / w, max((x+1)*sw/w, x*sw/w+1)
+				r, g, b, a, n uint64
+			)
+			for sy := y0; sy < y1; sy++ {
+				var i = src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					var pa = uint64(src.Pix[i+3])
+					r += uint64(src.Pix[i]) * pa
+					g += uint64(src.Pix[i+1]) * pa
+					b += uint64(src.Pix[i+2]) * pa
+					a += pa
+					n++
+					i += 4
+				}
+			}
+
+			var o = dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[o] = uint8(r / a)
+				dst.Pix[o+1] = uint8(g / a)
+				dst.Pix[o+2] = uint8(b / a)
+			}
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,64 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Width, Height int
+		Size          int
+		ExpW, ExpH    int
+	}{
+		{Name: "landscape", Width: 400, Height: 200, Size: 100, ExpW: 100, ExpH: 50},
+		{Name: "portrait", Width: 200, Height: 400, Size: 100, ExpW: 50, ExpH: 100},
+		{Name: "square", Width: 300, Height: 300, Size: 128, ExpW: 128, ExpH: 128},
+		{Name: "sliver", Width: 1000, Height: 2, Size: 100, ExpW: 100, ExpH: 1},
+		{Name: "fits", Width: 64, Height: 32, Size: 128, ExpW: 64, ExpH: 32},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var img = image.NewNRGBA(image.Rect(0, 0, test.Width, test.Height))
+			var thumb = Thumbnail(img, test.Size)
+			require.Equal(t, test.ExpW, thumb.Bounds().Dx())
+			require.Equal(t, test.ExpH, thumb.Bounds().Dy())
+		})
+	}
+}
+
+func TestThumbnailAverages(t *testing.T) {
+	// A 2x1 image of opaque black and transparent white scales to a single
+	// half transparent black pixel; the transparent pixel contributes no
+	// color.
+	var img = image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{A: 255})
+	img.Set(1, 0, color.NRGBA{R: 255, G: 255, B: 255})
+
+	var thumb = Thumbnail(img, 1)
+	require.Equal(t, color.NRGBA{A: 127}, thumb.At(0, 0))
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 3, 2))))
+
+	img, contentType, err := Decode(buf.Bytes())
+	require.Nil(t, err)
+	require.Equal(t, PNG, contentType)
+	require.Equal(t, 3, img.Bounds().Dx())
+
+	_, _, err = Decode([]byte("not an image"))
+	require.Equal(t, ErrUnsupported, errors.Cause(err))
+
+	_, _, err = Decode(buf.Bytes()[:20])
+	require.Equal(t, ErrUnsupported, errors.Cause(err))
+}
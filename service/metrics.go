@@ -0,0 +1,56 @@
+package service
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// Names of the counters of Service.Metrics.
+const (
+	metricCartsPurged           = "cartsPurged"
+	metricCartLinesPurged       = "cartLinesPurged"
+	metricTombstonesPurged      = "tombstonesPurged"
+	metricIdempotencyKeysPurged = "idempotencyKeysPurged"
+	metricOutboxEventsPurged    = "outboxEventsPurged"
+)
+
+// MetricsRoutes defines the REST endpoint that reports the Service's
+// metrics. It is restricted to admins.
+func (svc *Service) MetricsRoutes(r chi.Router) {
+	r.Use(svc.requireAdmin)
+	r.Get("/metrics", svc.GetMetricsHandler())
+}
+
+// metrics returns the counters of svc.Metrics by name.
+func (svc *Service) metrics() map[string]int64 {
+	var counters = make(map[string]int64)
+	svc.Metrics.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			counters[kv.Key] = v.Value()
+		}
+	})
+	return counters
+}
+
+// GetMetricsHandler reports the counters of the work done by the Service's
+// background jobs.
+func (svc *Service) GetMetricsHandler() http.HandlerFunc {
+	type Response struct {
+		Counters map[string]int64 `json:"counters"`
+	}
+	return describe(operation{
+		Id:       "GetMetricsHandler",
+		Summary:  "Report the running totals of the work done by background jobs since the server started, such as the carts, cart lines, tombstones, idempotency keys and outbox events purged.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var resp = Response{
+			Counters: svc.metrics(),
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMetricsHandler(t *testing.T) {
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+	svc.Metrics.Add(metricCartsPurged, 2)
+	svc.Metrics.Add(metricCartLinesPurged, 5)
+	svc.Metrics.Add(metricCartsPurged, 1)
+
+	var (
+		w = httptest.NewRecorder()
+		r = asAdmin(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	)
+	svc.Router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Counters map[string]int64 `json:"counters"`
+	}
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, map[string]int64{metricCartsPurged: 3, metricCartLinesPurged: 5}, resp.Counters)
+
+	// Metrics are reported to admins only.
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	r.Header.Set(headerUserId, "7")
+	svc.Router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -2,24 +2,27 @@ package service
 
 import (
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
 )
 
-func defaultMiddleware() chi.Middlewares {
+func (svc *Service) defaultMiddleware() chi.Middlewares {
 	var cors = cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedOrigins:   svc.allowedOrigins(),
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Accept-Version", "Authorization", "Content-Type", "Idempotency-Key", "If-Match", "If-None-Match", "Last-Event-ID", "X-CSRF-Token", "X-User-Id", "X-User-Roles"},
+		ExposedHeaders:   []string{"Deprecation", "ETag", "Idempotent-Replayed", "Link", "Sunset"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	})
 	return chi.Chain(
 		middleware.RequestID,
 		middleware.RealIP,
+		actorMiddleware,
 		middleware.Logger,
 		middleware.Recoverer,
 		contentTypeJsonMiddleware,
@@ -33,3 +36,61 @@ func contentTypeJsonMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// allowedOrigins returns the origins permitted to make cross-origin requests,
+// as specified in viper.
+func (svc *Service) allowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(svc.Viper.GetString(EnvVarCORSAllowedOrigins), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// checkOrigin reports whether the WebSocket handshake r may be accepted.
+// Handshakes without an Origin header are not made by browsers, and are
+// accepted, as are same-origin handshakes; cross-origin handshakes are
+// accepted from the origins permitted by the CORS middleware. A bare *,
+// which permits every origin to make cross-origin requests, is not honoured,
+// as browsers send credentials with every handshake; storefronts must be
+// listed.
+func (svc *Service) checkOrigin(r *http.Request) bool {
+	var origin = r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	var listed []string
+	for _, allowed := range svc.allowedOrigins() {
+		if allowed != "*" {
+			listed = append(listed, allowed)
+		}
+	}
+	return originAllowed(listed, origin)
+}
+
+// originAllowed reports whether origin matches one of allowed, each an
+// origin such as https://example.com, a pattern with a single * wildcard
+// such as https://*.example.com, or * to allow every origin. Origins are
+// compared case-insensitively, as the CORS middleware compares them.
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if a == "*" || a == origin {
+			return true
+		}
+		if i := strings.IndexByte(a, '*'); i >= 0 {
+			var prefix, suffix = a[:i], a[i+1:]
+			if len(origin) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
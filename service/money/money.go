@@ -0,0 +1,54 @@
+// Package money represents monetary amounts as integer minor units, avoiding
+// the rounding errors of floating point arithmetic.
+package money
+
+import (
+	"fmt"
+	"math"
+)
+
+// Money is an amount of a currency in the currency's minor unit, such as
+// cents. Money assumes currencies with two decimal minor units.
+type Money struct {
+	// Amount is the amount in minor units.
+	Amount int64 `json:"amount"`
+
+	// Currency is the ISO 4217 currency code, such as USD.
+	Currency string `json:"currency"`
+}
+
+// FromFloat converts a decimal amount in major units, such as 1.99 dollars,
+// to Money, rounding to the nearest minor unit.
+func FromFloat(amount float64, currency string) Money {
+	return Money{
+		Amount:   int64(math.Round(amount * 100)),
+		Currency: currency,
+	}
+}
+
+// Float returns m in major units, such as 1.99 dollars.
+func (m Money) Float() float64 {
+	return float64(m.Amount) / 100
+}
+
+// Mul returns m multiplied by n.
+func (m Money) Mul(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// Add returns the sum of m and o. The currency of m is kept; callers must
+// not add amounts of differing currencies.
+func (m Money) Add(o Money) Money {
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}
+}
+
+// String formats m in major units followed by its currency, such as
+// "1.99 USD".
+func (m Money) String() string {
+	var sign = ""
+	var amount = m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, m.Currency)
+}
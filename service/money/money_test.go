@@ -0,0 +1,26 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromFloat(t *testing.T) {
+	require.Equal(t, Money{Amount: 14900, Currency: "USD"}, FromFloat(149, "USD"))
+	require.Equal(t, Money{Amount: 30, Currency: "USD"}, FromFloat(0.1+0.2, "USD"))
+	require.Equal(t, Money{Amount: 1999, Currency: "USD"}, FromFloat(19.99, "USD"))
+}
+
+func TestArithmetic(t *testing.T) {
+	var price = FromFloat(19.99, "USD")
+	require.Equal(t, Money{Amount: 5997, Currency: "USD"}, price.Mul(3))
+	require.Equal(t, Money{Amount: 2098, Currency: "USD"}, price.Add(FromFloat(0.99, "USD")))
+	require.Equal(t, 19.99, price.Float())
+}
+
+func TestString(t *testing.T) {
+	require.Equal(t, "19.99 USD", FromFloat(19.99, "USD").String())
+	require.Equal(t, "0.05 USD", FromFloat(0.05, "USD").String())
+	require.Equal(t, "-1.50 USD", FromFloat(-1.5, "USD").String())
+}
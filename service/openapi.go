@@ -0,0 +1,193 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tjper/shoppingcart-server/service/openapi"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+const (
+	apiTitle   = "shoppingcart"
+	apiVersion = "1.0.0"
+)
+
+// operation documents the contract of a handler in the OpenAPI document.
+type operation struct {
+	// Id uniquely identifies the operation, typically the handler name.
+	Id      string
+	Summary string
+
+	// Request is a value of the handler's request body type, or nil if the
+	// handler does not read a body.
+	Request interface{}
+
+	// Response is a value of the handler's response body type, or nil if the
+	// handler does not write a body.
+	Response interface{}
+
+	// Status is the status code written on success. Defaults to 200.
+	Status int
+}
+
+// operations maps the code pointer of each described handler to its
+// operation. Every handler returned by a given constructor shares a code
+// pointer, and so shares an operation.
+var operations sync.Map
+
+// describe records op as the documentation of h and returns h.
+func describe(op operation, h http.HandlerFunc) http.HandlerFunc {
+	operations.Store(reflect.ValueOf(h).Pointer(), op)
+	return h
+}
+
+// lookupOperation returns the operation recorded for h by describe.
+func lookupOperation(h http.Handler) (operation, bool) {
+	var v = reflect.ValueOf(h)
+	if v.Kind() != reflect.Func {
+		return operation{}, false
+	}
+	op, ok := operations.Load(v.Pointer())
+	if !ok {
+		return operation{}, false
+	}
+	return op.(operation), true
+}
+
+// OpenAPIRoutes defines the REST endpoints that describe the service.
+func (svc *Service) OpenAPIRoutes(r chi.Router) {
+	r.Get("/openapi.json", svc.GetOpenAPIHandler())
+}
+
+// OpenAPI derives an OpenAPI document from the routes registered on
+// svc.Router. An error is returned if a route's handler was not described.
+func (svc *Service) OpenAPI() (*openapi.Document, error) {
+	var doc = &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info:    openapi.Info{Title: apiTitle, Version: apiVersion},
+		Paths:   make(map[string]openapi.PathItem),
+	}
+
+	var walkFn = func(method, route string, h http.Handler, _ ...func(http.Handler) http.Handler) error {
+		op, ok := lookupOperation(h)
+		if !ok {
+			return errors.Errorf("failed to OpenAPI, undescribed handler\tmethod=%s\troute=%s", method, route)
+		}
+
+		var path, params = openAPIPath(route)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(openapi.PathItem)
+		}
+		var o = op.openAPI(params)
+		for _, version := range []string{versionV1, versionV2} {
+			if strings.HasPrefix(route, "/"+version+"/") {
+				o.OperationId = version + "." + o.OperationId
+			}
+		}
+		doc.Paths[path][strings.ToLower(method)] = o
+		return nil
+	}
+	if err := chi.Walk(svc.Router, walkFn); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// openAPI converts op to an openapi.Operation with the path params passed.
+func (op operation) openAPI(params []string) *openapi.Operation {
+	var o = &openapi.Operation{
+		OperationId: op.Id,
+		Summary:     op.Summary,
+		Responses:   make(map[string]openapi.Response),
+	}
+	for _, param := range params {
+		var schema = &openapi.Schema{Type: "string"}
+		if param == "id" || strings.HasSuffix(param, "Id") {
+			schema = &openapi.Schema{Type: "integer", Format: "int32"}
+		}
+		o.Parameters = append(o.Parameters, openapi.Parameter{
+			Name:     param,
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+	}
+	if op.Request != nil {
+		o.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content:  jsonContent(op.Request),
+		}
+	}
+
+	var status = op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	var success = openapi.Response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		success.Content = jsonContent(op.Response)
+	}
+	o.Responses[strconv.Itoa(status)] = success
+	o.Responses["default"] = openapi.Response{
+		Description: "Error",
+		Content:     jsonContent(ErrorResponse{}),
+	}
+	return o
+}
+
+func jsonContent(v interface{}) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{
+		"application/json": {Schema: openapi.SchemaOf(v)},
+	}
+}
+
+// routeParam matches chi route params, such as {id} or {id:[0-9]+}.
+var routeParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// openAPIPath converts a chi route into an OpenAPI path and returns the
+// names of its path params.
+func openAPIPath(route string) (string, []string) {
+	// Mounted sub-routers are walked as /prefix/*/route.
+	route = strings.Replace(route, "/*/", "/", -1)
+
+	var params []string
+	for _, match := range routeParam.FindAllStringSubmatch(route, -1) {
+		params = append(params, match[1])
+	}
+	return routeParam.ReplaceAllString(route, "{$1}"), params
+}
+
+// GetOpenAPIHandler serves the service's OpenAPI document.
+func (svc *Service) GetOpenAPIHandler() http.HandlerFunc {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+	return describe(operation{
+		Id:       "GetOpenAPIHandler",
+		Summary:  "Retrieve the OpenAPI document describing the service.",
+		Response: map[string]interface{}{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var doc *openapi.Document
+			if doc, err = svc.OpenAPI(); err != nil {
+				return
+			}
+			body, err = json.Marshal(doc)
+		})
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		w.Write(body)
+	})
+}
@@ -0,0 +1,238 @@
+// Package openapi defines the subset of the OpenAPI 3 document model used to
+// describe the service, and derives JSON schemas from Go types.
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI specification version documents conform to.
+const Version = "3.0.3"
+
+// Document is the root object of an OpenAPI document.
+type Document struct {
+	OpenAPI string              `json:"openapi"`
+	Info    Info                `json:"info"`
+	Paths   map[string]PathItem `json:"paths"`
+}
+
+// Info provides metadata about the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lower case HTTP methods to the Operation served for a path.
+type PathItem map[string]*Operation
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationId string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes a single request body.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a single response from an API operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the schema of a request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema describes a JSON value.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// SchemaOf derives the Schema of the JSON encoding of v. Struct fields are
+// named by their json tags, and constraints, including whether a field is
+// required, are read from their validate tags; see the validate package.
+// Where a struct type recurs within itself, such as a tree node's children,
+// it is described as an object of unspecified properties.
+func SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	return schemaOf(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+// schemaOf derives the Schema of t. expanding holds the struct types whose
+// schemas enclose t, so that recursion terminates.
+func schemaOf(t reflect.Type, expanding map[reflect.Type]bool) *Schema {
+	switch t.String() {
+	case "time.Time":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "json.RawMessage":
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		var s = schemaOf(t.Elem(), expanding)
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), expanding)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), expanding)}
+	case reflect.Struct:
+		if expanding[t] {
+			return &Schema{Type: "object"}
+		}
+		expanding[t] = true
+		defer delete(expanding, t)
+		return structSchema(t, expanding)
+	}
+	return &Schema{}
+}
+
+func structSchema(t reflect.Type, expanding map[reflect.Type]bool) *Schema {
+	var s = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		var f = t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		var name = strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			var embedded = structSchema(f.Type, expanding)
+			for prop, ps := range embedded.Properties {
+				s.Properties[prop] = ps
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		var (
+			fs       = schemaOf(f.Type, expanding)
+			required = constrain(fs, f.Tag.Get("validate"))
+		)
+		s.Properties[name] = fs
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// constrain applies the rules of a validate tag to s. constrain reports
+// whether the tag declares the field required.
+func constrain(s *Schema, tag string) bool {
+	var required bool
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		var name, param = part, ""
+		if i := strings.Index(part, "="); i >= 0 {
+			name, param = part[:i], part[i+1:]
+		}
+		switch name {
+		case "required":
+			required = true
+		case "min", "max", "len":
+			constrainSize(s, name, param)
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "regex":
+			s.Pattern = param
+		}
+	}
+	return required
+}
+
+func constrainSize(s *Schema, rule, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	var i = int(n)
+	switch s.Type {
+	case "string":
+		if rule == "min" || rule == "len" {
+			s.MinLength = &i
+		}
+		if rule == "max" || rule == "len" {
+			s.MaxLength = &i
+		}
+	case "array":
+		if rule == "min" || rule == "len" {
+			s.MinItems = &i
+		}
+		if rule == "max" || rule == "len" {
+			s.MaxItems = &i
+		}
+	case "integer", "number":
+		if rule == "min" {
+			s.Minimum = &n
+		}
+		if rule == "max" {
+			s.Maximum = &n
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+	testutil "github.com/tjper/testing"
+)
+
+var golden = flag.Bool("golden", false, "overwrite the existing golden files")
+
+// TestOpenAPI fails when the OpenAPI document derived from the served routes
+// drifts from the committed golden document. Run with -golden after an
+// intended API change to update it.
+func TestOpenAPI(t *testing.T) {
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	doc, err := svc.OpenAPI()
+	require.Nil(t, err)
+
+	actual, err := json.MarshalIndent(doc, "", "  ")
+	require.Nil(t, err)
+
+	if *golden {
+		testutil.GoldenUpdate(t, actual)
+	}
+	expected := testutil.GoldenGet(t)
+
+	require.Equal(t, string(expected), string(actual))
+}
+
+func TestOpenAPIUndescribedHandler(t *testing.T) {
+	var svc = newTestService()
+	WithRouters(Routes{
+		Root: []func(chi.Router){
+			func(r chi.Router) {
+				r.Get("/undescribed", func(w http.ResponseWriter, r *http.Request) {})
+			},
+		},
+	})(svc)
+
+	_, err := svc.OpenAPI()
+	require.NotNil(t, err)
+}
+
+func TestGetOpenAPIHandler(t *testing.T) {
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	var (
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	)
+	svc.Router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc map[string]interface{}
+	require.Nil(t, json.NewDecoder(w.Body).Decode(&doc))
+	require.Contains(t, doc["paths"], "/v1/cart/{userId}")
+	require.Contains(t, doc["paths"], "/v2/cart/{userId}")
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/outbox"
+	"github.com/tjper/shoppingcart-server/service/webhook"
+)
+
+// webhookSinkTimeout bounds each delivery of the webhook outbox sink.
+const webhookSinkTimeout = 10 * time.Second
+
+// WithOutbox returns a ServiceOption that initializes the Service.Outbox
+// field with a relay delivering outbox events to the sinks specified in
+// viper. WithOutbox must follow WithDB and WithZap.
+func WithOutbox() ServiceOption {
+	return func(svc *Service) {
+		var sinks []outbox.Sink
+		for _, name := range strings.Split(svc.Viper.GetString(EnvVarOutboxSinks), ",") {
+			switch name = strings.TrimSpace(name); name {
+			case "":
+			case "log":
+				sinks = append(sinks, outbox.LogSink{Logger: svc.Zap})
+			case "file":
+				sink, err := outbox.NewFileSink(svc.Viper.GetString(EnvVarOutboxFile))
+				if err != nil {
+					panic(err)
+				}
+				sinks = append(sinks, sink)
+			case "webhook":
+				sinks = append(sinks, outbox.WebhookSink{
+					URL:    svc.Viper.GetString(EnvVarOutboxWebhookURL),
+					Client: &http.Client{Timeout: webhookSinkTimeout},
+				})
+			case "webhooks":
+				sinks = append(sinks, webhook.Fanout{DB: svc.DB})
+			default:
+				panic("switch does not handle outbox sink \"" + name + "\"")
+			}
+		}
+
+		svc.Outbox = outbox.NewRelay(
+			outbox.SQLStore{DB: svc.DB},
+			sinks,
+			svc.Viper.GetInt(EnvVarOutboxMaxAttempts),
+			svc.Viper.GetDuration(EnvVarOutboxInterval),
+			svc.Viper.GetInt(EnvVarOutboxBatchSize),
+			svc.Zap,
+		)
+	}
+}
+
+// RelayOutbox relays outbox events with svc.Outbox until the process is
+// signaled to stop.
+func (svc *Service) RelayOutbox() {
+	svc.Outbox.Run(signalContext())
+}
+
+// PurgeOutbox deletes the outbox events delivered more than retention ago,
+// in batches of the size specified in viper, and returns the number deleted.
+func (svc *Service) PurgeOutbox(ctx context.Context, retention time.Duration) (int, error) {
+	var (
+		before    = time.Now().Add(-retention)
+		batchSize = svc.Viper.GetInt(EnvVarCartPurgeBatchSize)
+		purged    int
+	)
+	for {
+		n, err := outbox.Purge(ctx, svc.DB, before, batchSize)
+		purged += n
+		if err != nil || n < batchSize {
+			return purged, err
+		}
+	}
+}
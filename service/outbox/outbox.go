@@ -0,0 +1,174 @@
+// Package outbox implements a transactional outbox. Domain events are
+// recorded in the outbox table in the same transaction as the changes they
+// describe, and a Relay delivers them to Sinks after the transaction
+// commits. Events are delivered at least once: an event is marked delivered
+// only after every sink has accepted it, so sinks must tolerate duplicates,
+// which they may detect by Event.Id. An event that exhausts its delivery
+// attempts is dead-lettered, and is no longer relayed.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+type Execer interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+// Event is a domain event recorded in the outbox.
+type Event struct {
+	Id int64 `json:"id"`
+
+	// Type names the kind of event, such as "CartItemAdded".
+	Type string `json:"type"`
+
+	// AggregateId identifies the aggregate the event occurred to, such as
+	// the cart of a user.
+	AggregateId int `json:"aggregateId"`
+
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"createdAt"`
+
+	// Attempts is the number of failed attempts to deliver the event.
+	Attempts int `json:"-"`
+}
+
+// Insert records an event of type typ that occurred to aggregateId, with
+// payload encoded as JSON, in the db. db is typically the *sql.Tx the
+// change the event describes is made in.
+func Insert(ctx context.Context, db Execer, typ string, aggregateId int, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrapf(err, "failed to Insert/Marshal\ttype=%s", typ)
+	}
+
+	var sql = `
+  INSERT INTO outbox (type, aggregate_id, payload, created_at)
+  VALUES (?, ?, ?, UTC_TIMESTAMP(6))
+  `
+	var args = []interface{}{typ, aggregateId, string(data)}
+	if _, err := db.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to Insert/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// Store is the storage the Relay reads undelivered events from and records
+// their delivery in.
+type Store interface {
+	// Pending retrieves at most limit events that are neither delivered nor
+	// dead, oldest first.
+	Pending(ctx context.Context, limit int) ([]Event, error)
+
+	// MarkDelivered records that the event id was delivered to every sink.
+	MarkDelivered(ctx context.Context, id int64) error
+
+	// MarkFailed records a failed attempt to deliver the event id, and
+	// dead-letters the event if dead.
+	MarkFailed(ctx context.Context, id int64, cause error, dead bool) error
+}
+
+// SQLStore is a Store backed by the outbox table.
+type SQLStore struct {
+	DB *sql.DB
+}
+
+// Pending implements Store.
+func (s SQLStore) Pending(ctx context.Context, limit int) ([]Event, error) {
+	var sql = `
+  SELECT
+    id,
+    type,
+    aggregate_id,
+    payload,
+    created_at,
+    attempts
+  FROM outbox
+  WHERE delivered_at IS NULL
+        AND dead_at IS NULL
+  ORDER BY id
+  LIMIT ?
+  `
+
+	rows, err := s.DB.QueryContext(ctx, sql, limit)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to Pending/QueryContext\tsql=%s\tlimit=%v", sql, limit)
+	}
+	defer rows.Close()
+
+	var events = make([]Event, 0)
+	for rows.Next() {
+		var (
+			ev        Event
+			payload   string
+			createdAt mysql.NullTime
+		)
+		if err := rows.Scan(&ev.Id, &ev.Type, &ev.AggregateId, &payload, &createdAt, &ev.Attempts); err != nil {
+			return nil, errors.Wrapf(err, "failed to Pending/Scan\tsql=%s\tlimit=%v", sql, limit)
+		}
+		ev.Payload = json.RawMessage(payload)
+		ev.CreatedAt = createdAt.Time
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to Pending/Err\tsql=%s\tlimit=%v", sql, limit)
+	}
+	return events, nil
+}
+
+// MarkDelivered implements Store.
+func (s SQLStore) MarkDelivered(ctx context.Context, id int64) error {
+	var sql = `
+  UPDATE outbox
+  SET delivered_at = UTC_TIMESTAMP(6),
+      attempts = attempts + 1
+  WHERE id = ?
+  `
+	if _, err := s.DB.ExecContext(ctx, sql, id); err != nil {
+		return errors.Wrapf(err, "failed to MarkDelivered/ExecContext\tsql=%s\tid=%v", sql, id)
+	}
+	return nil
+}
+
+// MarkFailed implements Store.
+func (s SQLStore) MarkFailed(ctx context.Context, id int64, cause error, dead bool) error {
+	var sql = `
+  UPDATE outbox
+  SET attempts = attempts + 1,
+      last_error = ?,
+      dead_at = IF(?, UTC_TIMESTAMP(6), NULL)
+  WHERE id = ?
+  `
+	var args = []interface{}{cause.Error(), dead, id}
+	if _, err := s.DB.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to MarkFailed/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// Purge deletes at most limit events delivered before before, and returns
+// the number deleted. Undelivered events, dead or not, are never deleted.
+func Purge(ctx context.Context, db Execer, before time.Time, limit int) (int, error) {
+	var sql = `
+  DELETE FROM outbox
+  WHERE delivered_at < ?
+  ORDER BY delivered_at, id
+  LIMIT ?
+  `
+	var args = []interface{}{before.UTC(), limit}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to Purge/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to Purge/RowsAffected\tsql=%s\targs=%v", sql, args)
+	}
+	return int(n), nil
+}
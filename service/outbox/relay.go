@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Relay delivers the events in a Store to Sinks, oldest first.
+type Relay struct {
+	store       Store
+	sinks       []Sink
+	maxAttempts int
+	interval    time.Duration
+	batchSize   int
+	logger      *zap.Logger
+}
+
+// NewRelay returns a Relay that delivers the events in store to every sink
+// in sinks, reading at most batchSize events at a time. An event is
+// dead-lettered after maxAttempts failed attempts. When no events are
+// pending, or delivery fails, the Relay waits interval before trying again.
+func NewRelay(store Store, sinks []Sink, maxAttempts int, interval time.Duration, batchSize int, logger *zap.Logger) *Relay {
+	return &Relay{
+		store:       store,
+		sinks:       sinks,
+		maxAttempts: maxAttempts,
+		interval:    interval,
+		batchSize:   batchSize,
+		logger:      logger,
+	}
+}
+
+// Run relays events until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	var timer = time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n, err := r.Relay(ctx)
+		if err != nil {
+			r.logger.Error(err.Error())
+		}
+
+		// A full batch suggests more events are pending.
+		if err == nil && n == r.batchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(r.interval)
+		}
+	}
+}
+
+// Relay delivers a single batch of pending events, and returns the number
+// delivered. Delivery stops at the first event a sink fails to accept, so
+// that events are delivered in order; the event is retried by the next
+// call, unless it has exhausted its attempts, in which case it is
+// dead-lettered and delivery continues with the next event.
+func (r *Relay) Relay(ctx context.Context) (int, error) {
+	events, err := r.store.Pending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int
+	for _, ev := range events {
+		if err := r.deliver(ctx, ev); err != nil {
+			var (
+				attempts = ev.Attempts + 1
+				dead     = attempts >= r.maxAttempts
+			)
+			if markErr := r.store.MarkFailed(ctx, ev.Id, err, dead); markErr != nil {
+				r.logger.Error(markErr.Error())
+				return delivered, err
+			}
+			if !dead {
+				return delivered, err
+			}
+			r.logger.Warn("outbox event dead-lettered",
+				zap.Int64("id", ev.Id),
+				zap.String("type", ev.Type),
+				zap.Int("attempts", attempts),
+				zap.Error(err),
+			)
+			continue
+		}
+		if err := r.store.MarkDelivered(ctx, ev.Id); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// deliver delivers ev to every sink. A sink that accepted ev is delivered
+// it again if another sink fails.
+func (r *Relay) deliver(ctx context.Context, ev Event) error {
+	for _, sink := range r.sinks {
+		if err := sink.Deliver(ctx, ev); err != nil {
+			return errors.Wrapf(err, "failed to Relay/Deliver\tid=%v\ttype=%s", ev.Id, ev.Type)
+		}
+	}
+	return nil
+}
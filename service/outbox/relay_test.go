@@ -0,0 +1,183 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu        sync.Mutex
+	events    []Event
+	delivered map[int64]bool
+	dead      map[int64]bool
+	attempts  map[int64]int
+}
+
+func newMemStore(n int) *memStore {
+	var s = &memStore{delivered: make(map[int64]bool), dead: make(map[int64]bool), attempts: make(map[int64]int)}
+	for i := 1; i <= n; i++ {
+		s.events = append(s.events, Event{Id: int64(i), Type: "CartItemAdded", AggregateId: 1})
+	}
+	return s
+}
+
+func (s *memStore) Pending(ctx context.Context, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []Event
+	for _, ev := range s.events {
+		if !s.delivered[ev.Id] && !s.dead[ev.Id] && len(pending) < limit {
+			ev.Attempts = s.attempts[ev.Id]
+			pending = append(pending, ev)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memStore) MarkDelivered(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delivered[id] = true
+	s.attempts[id]++
+	return nil
+}
+
+func (s *memStore) MarkFailed(ctx context.Context, id int64, cause error, dead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[id]++
+	s.dead[id] = dead
+	return nil
+}
+
+// localSink records the ids of the events delivered to it, failing the
+// event fail once, and the event poison always.
+type localSink struct {
+	mu     sync.Mutex
+	ids    []int64
+	fail   int64
+	poison int64
+}
+
+func (s *localSink) Deliver(ctx context.Context, ev Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ev.Id == s.fail {
+		s.fail = 0
+		return errors.New("unavailable")
+	}
+	if ev.Id == s.poison {
+		return errors.New("rejected")
+	}
+	s.ids = append(s.ids, ev.Id)
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	var (
+		store = newMemStore(5)
+		sink  = new(localSink)
+		relay = NewRelay(store, []Sink{sink}, 3, time.Minute, 2, zap.NewNop())
+	)
+
+	for _, expected := range []int{2, 2, 1, 0} {
+		n, err := relay.Relay(context.Background())
+		require.Nil(t, err)
+		require.Equal(t, expected, n)
+	}
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, sink.ids)
+}
+
+func TestRelayAtLeastOnce(t *testing.T) {
+	var (
+		store = newMemStore(3)
+		first = new(localSink)
+		sink  = &localSink{fail: 2}
+		relay = NewRelay(store, []Sink{first, sink}, 3, time.Minute, 10, zap.NewNop())
+	)
+
+	// Delivery stops at the failed event, so that order is preserved.
+	n, err := relay.Relay(context.Background())
+	require.NotNil(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []int64{1}, sink.ids)
+	require.False(t, store.delivered[2])
+
+	n, err = relay.Relay(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []int64{1, 2, 3}, sink.ids)
+	require.Equal(t, 2, store.attempts[2])
+
+	// The sink that accepted the failed event is delivered it again.
+	require.Equal(t, []int64{1, 2, 2, 3}, first.ids)
+}
+
+func TestRelayDeadLetter(t *testing.T) {
+	var (
+		store = newMemStore(3)
+		sink  = &localSink{poison: 2}
+		relay = NewRelay(store, []Sink{sink}, 3, time.Minute, 10, zap.NewNop())
+	)
+
+	// The poison event holds back later events until it exhausts its
+	// attempts.
+	n, err := relay.Relay(context.Background())
+	require.NotNil(t, err)
+	require.Equal(t, 1, n)
+	n, err = relay.Relay(context.Background())
+	require.NotNil(t, err)
+	require.Equal(t, 0, n)
+	require.False(t, store.dead[2])
+	require.Equal(t, []int64{1}, sink.ids)
+
+	// Once dead-lettered, it no longer does.
+	n, err = relay.Relay(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 1, n)
+	require.True(t, store.dead[2])
+	require.Equal(t, 3, store.attempts[2])
+	require.Equal(t, []int64{1, 3}, sink.ids)
+
+	n, err = relay.Relay(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 0, n)
+}
+
+func TestRelayRun(t *testing.T) {
+	var (
+		store     = newMemStore(5)
+		delivered = make(chan int64, 5)
+		sink      = SinkFunc(func(ctx context.Context, ev Event) error {
+			delivered <- ev.Id
+			return nil
+		})
+		relay = NewRelay(store, []Sink{sink}, 3, time.Hour, 2, zap.NewNop())
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var done = make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+
+	// Full batches are relayed without waiting for the interval.
+	for i := int64(1); i <= 5; i++ {
+		require.Equal(t, i, <-delivered)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after ctx was canceled")
+	}
+}
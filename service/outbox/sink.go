@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Sink is a destination events are delivered to. Deliver returns nil only
+// once the sink has durably accepted ev; otherwise ev is redelivered.
+type Sink interface {
+	Deliver(ctx context.Context, ev Event) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, ev Event) error
+
+// Deliver implements Sink.
+func (f SinkFunc) Deliver(ctx context.Context, ev Event) error {
+	return f(ctx, ev)
+}
+
+// LogSink delivers events by logging them.
+type LogSink struct {
+	Logger *zap.Logger
+}
+
+// Deliver implements Sink.
+func (s LogSink) Deliver(ctx context.Context, ev Event) error {
+	s.Logger.Info("outbox event",
+		zap.Int64("id", ev.Id),
+		zap.String("type", ev.Type),
+		zap.Int("aggregateId", ev.AggregateId),
+		zap.ByteString("payload", ev.Payload),
+		zap.Time("createdAt", ev.CreatedAt),
+	)
+	return nil
+}
+
+// FileSink delivers events by appending them to a file as JSON lines.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink returns a FileSink appending to the file at path, which is
+// created if necessary.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to NewFileSink/OpenFile\tpath=%s", path)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Deliver implements Sink. The file is synced before Deliver returns.
+func (s *FileSink) Deliver(ctx context.Context, ev Event) error {
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return errors.Wrapf(err, "failed to FileSink.Deliver/Marshal\tid=%v", ev.Id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrapf(err, "failed to FileSink.Deliver/Write\tid=%v", ev.Id)
+	}
+	if err := s.file.Sync(); err != nil {
+		return errors.Wrapf(err, "failed to FileSink.Deliver/Sync\tid=%v", ev.Id)
+	}
+	return nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// WebhookSink delivers events by POSTing them as JSON to URL. Any response
+// status other than 2xx is a failed delivery. The Outbox-Event-Id header
+// carries the event id, so that receivers may discard duplicates.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// Deliver implements Sink.
+func (s WebhookSink) Deliver(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return errors.Wrapf(err, "failed to WebhookSink.Deliver/Marshal\tid=%v", ev.Id)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "failed to WebhookSink.Deliver/NewRequest\turl=%s", s.URL)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Outbox-Event-Id", strconv.FormatInt(ev.Id, 10))
+
+	var client = s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to WebhookSink.Deliver/Do\turl=%s\tid=%v", s.URL, ev.Id)
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("failed to WebhookSink.Deliver\turl=%s\tid=%v\tstatus=%v", s.URL, ev.Id, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testEvent = Event{
+	Id:          7,
+	Type:        "CartItemRemoved",
+	AggregateId: 1,
+	Payload:     json.RawMessage(`{"userId":1,"cartItemId":3}`),
+	CreatedAt:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+const testEventJSON = `{"id":7,"type":"CartItemRemoved","aggregateId":1,` +
+	`"payload":{"userId":1,"cartItemId":3},"createdAt":"2020-01-02T03:04:05Z"}`
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "events.jsonl")
+	sink, err := NewFileSink(path)
+	require.Nil(t, err)
+	defer sink.Close()
+
+	require.Nil(t, sink.Deliver(context.Background(), testEvent))
+	require.Nil(t, sink.Deliver(context.Background(), testEvent))
+
+	actual, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	require.Equal(t, testEventJSON+"\n"+testEventJSON+"\n", string(actual))
+}
+
+func TestWebhookSink(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Status int
+		Err    bool
+	}{
+		{Name: "ok", Status: http.StatusOK},
+		{Name: "accepted", Status: http.StatusAccepted},
+		{Name: "error", Status: http.StatusInternalServerError, Err: true},
+		{Name: "redirect", Status: http.StatusNotModified, Err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				require.Nil(t, err)
+				require.JSONEq(t, testEventJSON, string(body))
+				require.Equal(t, "7", r.Header.Get("Outbox-Event-Id"))
+				require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				w.WriteHeader(test.Status)
+			}))
+			defer ts.Close()
+
+			err := WebhookSink{URL: ts.URL}.Deliver(context.Background(), testEvent)
+			if test.Err {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
@@ -0,0 +1,776 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: cart.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// CartItem is an item that belongs to a cart.
+type CartItem struct {
+	Id    int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Count int64 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Item  *Item `protobuf:"bytes,3,opt,name=item,proto3" json:"item,omitempty"`
+	// total is the item price multiplied by count.
+	Total *Money `protobuf:"bytes,4,opt,name=total,proto3" json:"total,omitempty"`
+	// variant_id is the id of the variant of the item, or 0 if none.
+	VariantId            int64    `protobuf:"varint,5,opt,name=variant_id,json=variantId,proto3" json:"variant_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CartItem) Reset()         { *m = CartItem{} }
+func (m *CartItem) String() string { return proto.CompactTextString(m) }
+func (*CartItem) ProtoMessage()    {}
+func (*CartItem) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{0}
+}
+
+func (m *CartItem) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CartItem.Unmarshal(m, b)
+}
+func (m *CartItem) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CartItem.Marshal(b, m, deterministic)
+}
+func (m *CartItem) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CartItem.Merge(m, src)
+}
+func (m *CartItem) XXX_Size() int {
+	return xxx_messageInfo_CartItem.Size(m)
+}
+func (m *CartItem) XXX_DiscardUnknown() {
+	xxx_messageInfo_CartItem.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CartItem proto.InternalMessageInfo
+
+func (m *CartItem) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *CartItem) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *CartItem) GetItem() *Item {
+	if m != nil {
+		return m.Item
+	}
+	return nil
+}
+
+func (m *CartItem) GetTotal() *Money {
+	if m != nil {
+		return m.Total
+	}
+	return nil
+}
+
+func (m *CartItem) GetVariantId() int64 {
+	if m != nil {
+		return m.VariantId
+	}
+	return 0
+}
+
+// Cart is a user's cart.
+type Cart struct {
+	UserId    int64       `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	CartItems []*CartItem `protobuf:"bytes,2,rep,name=cart_items,json=cartItems,proto3" json:"cart_items,omitempty"`
+	// item_count is the sum of the count of every cart item.
+	ItemCount int64 `protobuf:"varint,3,opt,name=item_count,json=itemCount,proto3" json:"item_count,omitempty"`
+	// subtotal is the sum of the total of every cart item.
+	Subtotal             *Money   `protobuf:"bytes,4,opt,name=subtotal,proto3" json:"subtotal,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Cart) Reset()         { *m = Cart{} }
+func (m *Cart) String() string { return proto.CompactTextString(m) }
+func (*Cart) ProtoMessage()    {}
+func (*Cart) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{1}
+}
+
+func (m *Cart) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Cart.Unmarshal(m, b)
+}
+func (m *Cart) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Cart.Marshal(b, m, deterministic)
+}
+func (m *Cart) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Cart.Merge(m, src)
+}
+func (m *Cart) XXX_Size() int {
+	return xxx_messageInfo_Cart.Size(m)
+}
+func (m *Cart) XXX_DiscardUnknown() {
+	xxx_messageInfo_Cart.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Cart proto.InternalMessageInfo
+
+func (m *Cart) GetUserId() int64 {
+	if m != nil {
+		return m.UserId
+	}
+	return 0
+}
+
+func (m *Cart) GetCartItems() []*CartItem {
+	if m != nil {
+		return m.CartItems
+	}
+	return nil
+}
+
+func (m *Cart) GetItemCount() int64 {
+	if m != nil {
+		return m.ItemCount
+	}
+	return 0
+}
+
+func (m *Cart) GetSubtotal() *Money {
+	if m != nil {
+		return m.Subtotal
+	}
+	return nil
+}
+
+type AddCartItemRequest struct {
+	ItemId int64 `protobuf:"varint,1,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
+	UserId int64 `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Count  int64 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	// variant_id is required if the item has variants.
+	VariantId            int64    `protobuf:"varint,4,opt,name=variant_id,json=variantId,proto3" json:"variant_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddCartItemRequest) Reset()         { *m = AddCartItemRequest{} }
+func (m *AddCartItemRequest) String() string { return proto.CompactTextString(m) }
+func (*AddCartItemRequest) ProtoMessage()    {}
+func (*AddCartItemRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{2}
+}
+
+func (m *AddCartItemRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddCartItemRequest.Unmarshal(m, b)
+}
+func (m *AddCartItemRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddCartItemRequest.Marshal(b, m, deterministic)
+}
+func (m *AddCartItemRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddCartItemRequest.Merge(m, src)
+}
+func (m *AddCartItemRequest) XXX_Size() int {
+	return xxx_messageInfo_AddCartItemRequest.Size(m)
+}
+func (m *AddCartItemRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddCartItemRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddCartItemRequest proto.InternalMessageInfo
+
+func (m *AddCartItemRequest) GetItemId() int64 {
+	if m != nil {
+		return m.ItemId
+	}
+	return 0
+}
+
+func (m *AddCartItemRequest) GetUserId() int64 {
+	if m != nil {
+		return m.UserId
+	}
+	return 0
+}
+
+func (m *AddCartItemRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *AddCartItemRequest) GetVariantId() int64 {
+	if m != nil {
+		return m.VariantId
+	}
+	return 0
+}
+
+type AddCartItemResponse struct {
+	CartItem             *CartItem `protobuf:"bytes,1,opt,name=cart_item,json=cartItem,proto3" json:"cart_item,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *AddCartItemResponse) Reset()         { *m = AddCartItemResponse{} }
+func (m *AddCartItemResponse) String() string { return proto.CompactTextString(m) }
+func (*AddCartItemResponse) ProtoMessage()    {}
+func (*AddCartItemResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{3}
+}
+
+func (m *AddCartItemResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddCartItemResponse.Unmarshal(m, b)
+}
+func (m *AddCartItemResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddCartItemResponse.Marshal(b, m, deterministic)
+}
+func (m *AddCartItemResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddCartItemResponse.Merge(m, src)
+}
+func (m *AddCartItemResponse) XXX_Size() int {
+	return xxx_messageInfo_AddCartItemResponse.Size(m)
+}
+func (m *AddCartItemResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddCartItemResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddCartItemResponse proto.InternalMessageInfo
+
+func (m *AddCartItemResponse) GetCartItem() *CartItem {
+	if m != nil {
+		return m.CartItem
+	}
+	return nil
+}
+
+type GetCartRequest struct {
+	UserId               int64    `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetCartRequest) Reset()         { *m = GetCartRequest{} }
+func (m *GetCartRequest) String() string { return proto.CompactTextString(m) }
+func (*GetCartRequest) ProtoMessage()    {}
+func (*GetCartRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{4}
+}
+
+func (m *GetCartRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetCartRequest.Unmarshal(m, b)
+}
+func (m *GetCartRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetCartRequest.Marshal(b, m, deterministic)
+}
+func (m *GetCartRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCartRequest.Merge(m, src)
+}
+func (m *GetCartRequest) XXX_Size() int {
+	return xxx_messageInfo_GetCartRequest.Size(m)
+}
+func (m *GetCartRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCartRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetCartRequest proto.InternalMessageInfo
+
+func (m *GetCartRequest) GetUserId() int64 {
+	if m != nil {
+		return m.UserId
+	}
+	return 0
+}
+
+type GetCartResponse struct {
+	Cart                 *Cart    `protobuf:"bytes,1,opt,name=cart,proto3" json:"cart,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetCartResponse) Reset()         { *m = GetCartResponse{} }
+func (m *GetCartResponse) String() string { return proto.CompactTextString(m) }
+func (*GetCartResponse) ProtoMessage()    {}
+func (*GetCartResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{5}
+}
+
+func (m *GetCartResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetCartResponse.Unmarshal(m, b)
+}
+func (m *GetCartResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetCartResponse.Marshal(b, m, deterministic)
+}
+func (m *GetCartResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCartResponse.Merge(m, src)
+}
+func (m *GetCartResponse) XXX_Size() int {
+	return xxx_messageInfo_GetCartResponse.Size(m)
+}
+func (m *GetCartResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCartResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetCartResponse proto.InternalMessageInfo
+
+func (m *GetCartResponse) GetCart() *Cart {
+	if m != nil {
+		return m.Cart
+	}
+	return nil
+}
+
+type PutCartItemRequest struct {
+	Id     int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ItemId int64 `protobuf:"varint,2,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
+	UserId int64 `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Count  int64 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	// variant_id is required if the item has variants.
+	VariantId            int64    `protobuf:"varint,5,opt,name=variant_id,json=variantId,proto3" json:"variant_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PutCartItemRequest) Reset()         { *m = PutCartItemRequest{} }
+func (m *PutCartItemRequest) String() string { return proto.CompactTextString(m) }
+func (*PutCartItemRequest) ProtoMessage()    {}
+func (*PutCartItemRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{6}
+}
+
+func (m *PutCartItemRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PutCartItemRequest.Unmarshal(m, b)
+}
+func (m *PutCartItemRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PutCartItemRequest.Marshal(b, m, deterministic)
+}
+func (m *PutCartItemRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PutCartItemRequest.Merge(m, src)
+}
+func (m *PutCartItemRequest) XXX_Size() int {
+	return xxx_messageInfo_PutCartItemRequest.Size(m)
+}
+func (m *PutCartItemRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PutCartItemRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PutCartItemRequest proto.InternalMessageInfo
+
+func (m *PutCartItemRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *PutCartItemRequest) GetItemId() int64 {
+	if m != nil {
+		return m.ItemId
+	}
+	return 0
+}
+
+func (m *PutCartItemRequest) GetUserId() int64 {
+	if m != nil {
+		return m.UserId
+	}
+	return 0
+}
+
+func (m *PutCartItemRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *PutCartItemRequest) GetVariantId() int64 {
+	if m != nil {
+		return m.VariantId
+	}
+	return 0
+}
+
+type PutCartItemResponse struct {
+	CartItem             *CartItem `protobuf:"bytes,1,opt,name=cart_item,json=cartItem,proto3" json:"cart_item,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *PutCartItemResponse) Reset()         { *m = PutCartItemResponse{} }
+func (m *PutCartItemResponse) String() string { return proto.CompactTextString(m) }
+func (*PutCartItemResponse) ProtoMessage()    {}
+func (*PutCartItemResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{7}
+}
+
+func (m *PutCartItemResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PutCartItemResponse.Unmarshal(m, b)
+}
+func (m *PutCartItemResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PutCartItemResponse.Marshal(b, m, deterministic)
+}
+func (m *PutCartItemResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PutCartItemResponse.Merge(m, src)
+}
+func (m *PutCartItemResponse) XXX_Size() int {
+	return xxx_messageInfo_PutCartItemResponse.Size(m)
+}
+func (m *PutCartItemResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PutCartItemResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PutCartItemResponse proto.InternalMessageInfo
+
+func (m *PutCartItemResponse) GetCartItem() *CartItem {
+	if m != nil {
+		return m.CartItem
+	}
+	return nil
+}
+
+type DeleteCartItemRequest struct {
+	Id                   int64    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteCartItemRequest) Reset()         { *m = DeleteCartItemRequest{} }
+func (m *DeleteCartItemRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteCartItemRequest) ProtoMessage()    {}
+func (*DeleteCartItemRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{8}
+}
+
+func (m *DeleteCartItemRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteCartItemRequest.Unmarshal(m, b)
+}
+func (m *DeleteCartItemRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteCartItemRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteCartItemRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteCartItemRequest.Merge(m, src)
+}
+func (m *DeleteCartItemRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteCartItemRequest.Size(m)
+}
+func (m *DeleteCartItemRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteCartItemRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteCartItemRequest proto.InternalMessageInfo
+
+func (m *DeleteCartItemRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type DeleteCartItemResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteCartItemResponse) Reset()         { *m = DeleteCartItemResponse{} }
+func (m *DeleteCartItemResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteCartItemResponse) ProtoMessage()    {}
+func (*DeleteCartItemResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf731a5c8f9a516f, []int{9}
+}
+
+func (m *DeleteCartItemResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteCartItemResponse.Unmarshal(m, b)
+}
+func (m *DeleteCartItemResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteCartItemResponse.Marshal(b, m, deterministic)
+}
+func (m *DeleteCartItemResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteCartItemResponse.Merge(m, src)
+}
+func (m *DeleteCartItemResponse) XXX_Size() int {
+	return xxx_messageInfo_DeleteCartItemResponse.Size(m)
+}
+func (m *DeleteCartItemResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteCartItemResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteCartItemResponse proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*CartItem)(nil), "shoppingcart.v1.CartItem")
+	proto.RegisterType((*Cart)(nil), "shoppingcart.v1.Cart")
+	proto.RegisterType((*AddCartItemRequest)(nil), "shoppingcart.v1.AddCartItemRequest")
+	proto.RegisterType((*AddCartItemResponse)(nil), "shoppingcart.v1.AddCartItemResponse")
+	proto.RegisterType((*GetCartRequest)(nil), "shoppingcart.v1.GetCartRequest")
+	proto.RegisterType((*GetCartResponse)(nil), "shoppingcart.v1.GetCartResponse")
+	proto.RegisterType((*PutCartItemRequest)(nil), "shoppingcart.v1.PutCartItemRequest")
+	proto.RegisterType((*PutCartItemResponse)(nil), "shoppingcart.v1.PutCartItemResponse")
+	proto.RegisterType((*DeleteCartItemRequest)(nil), "shoppingcart.v1.DeleteCartItemRequest")
+	proto.RegisterType((*DeleteCartItemResponse)(nil), "shoppingcart.v1.DeleteCartItemResponse")
+}
+
+func init() { proto.RegisterFile("cart.proto", fileDescriptor_bf731a5c8f9a516f) }
+
+var fileDescriptor_bf731a5c8f9a516f = []byte{
+	// 509 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x94, 0xdd, 0x6a, 0x13, 0x41,
+	0x14, 0xc7, 0xd9, 0x8f, 0xb4, 0xc9, 0x59, 0x48, 0x61, 0xb4, 0x75, 0x5d, 0x10, 0xc3, 0x5a, 0x6c,
+	0x02, 0x9a, 0xe0, 0x2a, 0x22, 0xe8, 0x8d, 0x56, 0x90, 0x80, 0x05, 0x89, 0x37, 0xc5, 0x9b, 0xb0,
+	0x1f, 0x43, 0x3b, 0xd2, 0xec, 0xac, 0x33, 0xb3, 0x01, 0xf1, 0x11, 0x7c, 0x11, 0x2f, 0x7c, 0x3a,
+	0x9f, 0x40, 0x66, 0x66, 0xbb, 0xee, 0x67, 0x2a, 0xf4, 0x2e, 0x73, 0xf6, 0x3f, 0xe7, 0x7f, 0xfe,
+	0xbf, 0x33, 0x04, 0x20, 0x0e, 0x99, 0x98, 0x67, 0x8c, 0x0a, 0x8a, 0x0e, 0xf8, 0x25, 0xcd, 0x32,
+	0x92, 0x5e, 0xa8, 0xda, 0xf6, 0x99, 0x07, 0x44, 0xe0, 0x8d, 0xfe, 0xe8, 0xff, 0x32, 0x60, 0x78,
+	0x1a, 0x32, 0xb1, 0x14, 0x78, 0x83, 0xc6, 0x60, 0x92, 0xc4, 0x35, 0x26, 0xc6, 0xd4, 0x5a, 0x99,
+	0x24, 0x41, 0x77, 0x61, 0x10, 0xd3, 0x3c, 0x15, 0xae, 0xa9, 0x4a, 0xfa, 0x80, 0x66, 0x60, 0xcb,
+	0x06, 0xae, 0x35, 0x31, 0xa6, 0x4e, 0x70, 0x38, 0x6f, 0xb4, 0x9f, 0xcb, 0x56, 0x2b, 0x25, 0x41,
+	0x4f, 0x60, 0x20, 0xa8, 0x08, 0xaf, 0x5c, 0x5b, 0x69, 0x8f, 0x5a, 0xda, 0x33, 0x9a, 0xe2, 0xef,
+	0x2b, 0x2d, 0x42, 0x0f, 0x00, 0xb6, 0x21, 0x23, 0x61, 0x2a, 0xd6, 0x24, 0x71, 0x07, 0xca, 0x73,
+	0x54, 0x54, 0x96, 0x89, 0xff, 0xdb, 0x00, 0x5b, 0x8e, 0x8a, 0xee, 0xc1, 0x7e, 0xce, 0x31, 0x5b,
+	0x97, 0xb3, 0xee, 0xc9, 0xe3, 0x32, 0x41, 0xaf, 0x74, 0xee, 0xb5, 0xf4, 0xe6, 0xae, 0x39, 0xb1,
+	0xa6, 0x4e, 0x70, 0xbf, 0xe5, 0x79, 0x1d, 0x77, 0x35, 0x8a, 0x8b, 0x5f, 0x5c, 0x5a, 0xcb, 0x4b,
+	0x6b, 0x1d, 0xd7, 0xd2, 0xd6, 0xb2, 0x72, 0xaa, 0x22, 0x07, 0x30, 0xe4, 0x79, 0xf4, 0x3f, 0x51,
+	0x4a, 0x9d, 0xff, 0x03, 0xd0, 0xdb, 0x24, 0x29, 0xcd, 0xf0, 0xb7, 0x1c, 0x73, 0x35, 0xbb, 0x32,
+	0xfa, 0x37, 0xbb, 0x3c, 0x2e, 0x93, 0x6a, 0x28, 0xb3, 0x16, 0xaa, 0x5c, 0x82, 0x55, 0x5d, 0x42,
+	0x9d, 0x95, 0xdd, 0x64, 0x75, 0x06, 0x77, 0x6a, 0xe6, 0x3c, 0xa3, 0x29, 0xc7, 0xe8, 0x25, 0x8c,
+	0x4a, 0x40, 0xca, 0x7f, 0x27, 0x9f, 0xe1, 0x35, 0x1f, 0x7f, 0x06, 0xe3, 0x0f, 0x58, 0xc8, 0x0f,
+	0x95, 0x1c, 0x9d, 0x3b, 0xf0, 0xdf, 0xc0, 0x41, 0x29, 0x2d, 0x5c, 0x67, 0x60, 0xcb, 0x4e, 0xae,
+	0xd1, 0xf3, 0x60, 0x94, 0x58, 0x49, 0xfc, 0x9f, 0x06, 0xa0, 0x4f, 0xb9, 0x68, 0x52, 0x6b, 0x3e,
+	0xcc, 0x0a, 0x45, 0xb3, 0x8f, 0xa2, 0xd5, 0x4d, 0xd1, 0xee, 0xa7, 0x38, 0xe8, 0xa0, 0x58, 0x1b,
+	0xe6, 0x96, 0x14, 0x4f, 0xe0, 0xf0, 0x3d, 0xbe, 0xc2, 0x02, 0xdf, 0x10, 0xcf, 0x77, 0xe1, 0xa8,
+	0x29, 0xd4, 0xd6, 0xc1, 0x1f, 0x13, 0x1c, 0x59, 0xfc, 0x8c, 0xd9, 0x96, 0xc4, 0x18, 0x9d, 0x83,
+	0x53, 0xd9, 0x33, 0x7a, 0xd4, 0x1a, 0xa3, 0xfd, 0x04, 0xbd, 0xe3, 0xdd, 0xa2, 0x22, 0xe4, 0x47,
+	0xd8, 0x2f, 0xf6, 0x88, 0x1e, 0xb6, 0x2e, 0xd4, 0x1f, 0x83, 0x37, 0xe9, 0x17, 0x14, 0xdd, 0xce,
+	0xc1, 0xa9, 0x90, 0xec, 0x98, 0xb3, 0xbd, 0x74, 0xef, 0x78, 0xb7, 0xa8, 0xe8, 0x1c, 0xc2, 0xb8,
+	0xce, 0x0a, 0x3d, 0x6e, 0xdd, 0xeb, 0xa4, 0xee, 0x9d, 0xdc, 0xa8, 0xd3, 0x16, 0xef, 0x5e, 0x7c,
+	0x09, 0x2e, 0x88, 0xb8, 0xcc, 0xa3, 0x79, 0x4c, 0x37, 0x0b, 0xf1, 0x35, 0xc3, 0x6c, 0x51, 0xbd,
+	0xfa, 0x94, 0x63, 0xb6, 0x95, 0x35, 0xbd, 0x94, 0x45, 0x16, 0xbd, 0xce, 0xa2, 0x68, 0x4f, 0xfd,
+	0xc1, 0x3e, 0xff, 0x3b, 0x00, 0x1e, 0x15, 0x62, 0xc6, 0x8b, 0x05, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// CartServiceClient is the client API for CartService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type CartServiceClient interface {
+	// AddCartItem adds an item to a user's cart, summing counts if the item is
+	// already in the cart.
+	AddCartItem(ctx context.Context, in *AddCartItemRequest, opts ...grpc.CallOption) (*AddCartItemResponse, error)
+	// GetCart retrieves a user's cart.
+	GetCart(ctx context.Context, in *GetCartRequest, opts ...grpc.CallOption) (*GetCartResponse, error)
+	// PutCartItem updates a cart item.
+	PutCartItem(ctx context.Context, in *PutCartItemRequest, opts ...grpc.CallOption) (*PutCartItemResponse, error)
+	// DeleteCartItem deletes a cart item.
+	DeleteCartItem(ctx context.Context, in *DeleteCartItemRequest, opts ...grpc.CallOption) (*DeleteCartItemResponse, error)
+}
+
+type cartServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewCartServiceClient(cc *grpc.ClientConn) CartServiceClient {
+	return &cartServiceClient{cc}
+}
+
+func (c *cartServiceClient) AddCartItem(ctx context.Context, in *AddCartItemRequest, opts ...grpc.CallOption) (*AddCartItemResponse, error) {
+	out := new(AddCartItemResponse)
+	err := c.cc.Invoke(ctx, "/shoppingcart.v1.CartService/AddCartItem", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) GetCart(ctx context.Context, in *GetCartRequest, opts ...grpc.CallOption) (*GetCartResponse, error) {
+	out := new(GetCartResponse)
+	err := c.cc.Invoke(ctx, "/shoppingcart.v1.CartService/GetCart", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) PutCartItem(ctx context.Context, in *PutCartItemRequest, opts ...grpc.CallOption) (*PutCartItemResponse, error) {
+	out := new(PutCartItemResponse)
+	err := c.cc.Invoke(ctx, "/shoppingcart.v1.CartService/PutCartItem", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) DeleteCartItem(ctx context.Context, in *DeleteCartItemRequest, opts ...grpc.CallOption) (*DeleteCartItemResponse, error) {
+	out := new(DeleteCartItemResponse)
+	err := c.cc.Invoke(ctx, "/shoppingcart.v1.CartService/DeleteCartItem", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CartServiceServer is the server API for CartService service.
+type CartServiceServer interface {
+	// AddCartItem adds an item to a user's cart, summing counts if the item is
+	// already in the cart.
+	AddCartItem(context.Context, *AddCartItemRequest) (*AddCartItemResponse, error)
+	// GetCart retrieves a user's cart.
+	GetCart(context.Context, *GetCartRequest) (*GetCartResponse, error)
+	// PutCartItem updates a cart item.
+	PutCartItem(context.Context, *PutCartItemRequest) (*PutCartItemResponse, error)
+	// DeleteCartItem deletes a cart item.
+	DeleteCartItem(context.Context, *DeleteCartItemRequest) (*DeleteCartItemResponse, error)
+}
+
+// UnimplementedCartServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedCartServiceServer struct {
+}
+
+func (*UnimplementedCartServiceServer) AddCartItem(ctx context.Context, req *AddCartItemRequest) (*AddCartItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCartItem not implemented")
+}
+func (*UnimplementedCartServiceServer) GetCart(ctx context.Context, req *GetCartRequest) (*GetCartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCart not implemented")
+}
+func (*UnimplementedCartServiceServer) PutCartItem(ctx context.Context, req *PutCartItemRequest) (*PutCartItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutCartItem not implemented")
+}
+func (*UnimplementedCartServiceServer) DeleteCartItem(ctx context.Context, req *DeleteCartItemRequest) (*DeleteCartItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCartItem not implemented")
+}
+
+func RegisterCartServiceServer(s *grpc.Server, srv CartServiceServer) {
+	s.RegisterService(&_CartService_serviceDesc, srv)
+}
+
+func _CartService_AddCartItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCartItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).AddCartItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shoppingcart.v1.CartService/AddCartItem",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).AddCartItem(ctx, req.(*AddCartItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_GetCart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).GetCart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shoppingcart.v1.CartService/GetCart",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).GetCart(ctx, req.(*GetCartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_PutCartItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutCartItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).PutCartItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shoppingcart.v1.CartService/PutCartItem",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).PutCartItem(ctx, req.(*PutCartItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_DeleteCartItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCartItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).DeleteCartItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shoppingcart.v1.CartService/DeleteCartItem",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).DeleteCartItem(ctx, req.(*DeleteCartItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _CartService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "shoppingcart.v1.CartService",
+	HandlerType: (*CartServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddCartItem",
+			Handler:    _CartService_AddCartItem_Handler,
+		},
+		{
+			MethodName: "GetCart",
+			Handler:    _CartService_GetCart_Handler,
+		},
+		{
+			MethodName: "PutCartItem",
+			Handler:    _CartService_PutCartItem_Handler,
+		},
+		{
+			MethodName: "DeleteCartItem",
+			Handler:    _CartService_DeleteCartItem_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cart.proto",
+}
@@ -0,0 +1,443 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: item.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// Money is an amount of a currency in the currency's minor unit, such as
+// cents.
+type Money struct {
+	Amount int64 `protobuf:"varint,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	// currency is the ISO 4217 currency code, such as USD.
+	Currency             string   `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Money) Reset()         { *m = Money{} }
+func (m *Money) String() string { return proto.CompactTextString(m) }
+func (*Money) ProtoMessage()    {}
+func (*Money) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6007f868cf6553df, []int{0}
+}
+
+func (m *Money) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Money.Unmarshal(m, b)
+}
+func (m *Money) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Money.Marshal(b, m, deterministic)
+}
+func (m *Money) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Money.Merge(m, src)
+}
+func (m *Money) XXX_Size() int {
+	return xxx_messageInfo_Money.Size(m)
+}
+func (m *Money) XXX_DiscardUnknown() {
+	xxx_messageInfo_Money.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Money proto.InternalMessageInfo
+
+func (m *Money) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+func (m *Money) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+// Item is an item that may be purchased.
+type Item struct {
+	Id                   int64    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description          string   `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Price                *Money   `protobuf:"bytes,4,opt,name=price,proto3" json:"price,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Item) Reset()         { *m = Item{} }
+func (m *Item) String() string { return proto.CompactTextString(m) }
+func (*Item) ProtoMessage()    {}
+func (*Item) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6007f868cf6553df, []int{1}
+}
+
+func (m *Item) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Item.Unmarshal(m, b)
+}
+func (m *Item) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Item.Marshal(b, m, deterministic)
+}
+func (m *Item) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Item.Merge(m, src)
+}
+func (m *Item) XXX_Size() int {
+	return xxx_messageInfo_Item.Size(m)
+}
+func (m *Item) XXX_DiscardUnknown() {
+	xxx_messageInfo_Item.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Item proto.InternalMessageInfo
+
+func (m *Item) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Item) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Item) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Item) GetPrice() *Money {
+	if m != nil {
+		return m.Price
+	}
+	return nil
+}
+
+type ListItemsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListItemsRequest) Reset()         { *m = ListItemsRequest{} }
+func (m *ListItemsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListItemsRequest) ProtoMessage()    {}
+func (*ListItemsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6007f868cf6553df, []int{2}
+}
+
+func (m *ListItemsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListItemsRequest.Unmarshal(m, b)
+}
+func (m *ListItemsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListItemsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListItemsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListItemsRequest.Merge(m, src)
+}
+func (m *ListItemsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListItemsRequest.Size(m)
+}
+func (m *ListItemsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListItemsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListItemsRequest proto.InternalMessageInfo
+
+type ListItemsResponse struct {
+	Items                []*Item  `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListItemsResponse) Reset()         { *m = ListItemsResponse{} }
+func (m *ListItemsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListItemsResponse) ProtoMessage()    {}
+func (*ListItemsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6007f868cf6553df, []int{3}
+}
+
+func (m *ListItemsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListItemsResponse.Unmarshal(m, b)
+}
+func (m *ListItemsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListItemsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListItemsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListItemsResponse.Merge(m, src)
+}
+func (m *ListItemsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListItemsResponse.Size(m)
+}
+func (m *ListItemsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListItemsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListItemsResponse proto.InternalMessageInfo
+
+func (m *ListItemsResponse) GetItems() []*Item {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+type GetItemRequest struct {
+	Id                   int64    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetItemRequest) Reset()         { *m = GetItemRequest{} }
+func (m *GetItemRequest) String() string { return proto.CompactTextString(m) }
+func (*GetItemRequest) ProtoMessage()    {}
+func (*GetItemRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6007f868cf6553df, []int{4}
+}
+
+func (m *GetItemRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetItemRequest.Unmarshal(m, b)
+}
+func (m *GetItemRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetItemRequest.Marshal(b, m, deterministic)
+}
+func (m *GetItemRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetItemRequest.Merge(m, src)
+}
+func (m *GetItemRequest) XXX_Size() int {
+	return xxx_messageInfo_GetItemRequest.Size(m)
+}
+func (m *GetItemRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetItemRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetItemRequest proto.InternalMessageInfo
+
+func (m *GetItemRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type GetItemResponse struct {
+	Item                 *Item    `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetItemResponse) Reset()         { *m = GetItemResponse{} }
+func (m *GetItemResponse) String() string { return proto.CompactTextString(m) }
+func (*GetItemResponse) ProtoMessage()    {}
+func (*GetItemResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6007f868cf6553df, []int{5}
+}
+
+func (m *GetItemResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetItemResponse.Unmarshal(m, b)
+}
+func (m *GetItemResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetItemResponse.Marshal(b, m, deterministic)
+}
+func (m *GetItemResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetItemResponse.Merge(m, src)
+}
+func (m *GetItemResponse) XXX_Size() int {
+	return xxx_messageInfo_GetItemResponse.Size(m)
+}
+func (m *GetItemResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetItemResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetItemResponse proto.InternalMessageInfo
+
+func (m *GetItemResponse) GetItem() *Item {
+	if m != nil {
+		return m.Item
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Money)(nil), "shoppingcart.v1.Money")
+	proto.RegisterType((*Item)(nil), "shoppingcart.v1.Item")
+	proto.RegisterType((*ListItemsRequest)(nil), "shoppingcart.v1.ListItemsRequest")
+	proto.RegisterType((*ListItemsResponse)(nil), "shoppingcart.v1.ListItemsResponse")
+	proto.RegisterType((*GetItemRequest)(nil), "shoppingcart.v1.GetItemRequest")
+	proto.RegisterType((*GetItemResponse)(nil), "shoppingcart.v1.GetItemResponse")
+}
+
+func init() { proto.RegisterFile("item.proto", fileDescriptor_6007f868cf6553df) }
+
+var fileDescriptor_6007f868cf6553df = []byte{
+	// 336 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0x41, 0x4f, 0xea, 0x40,
+	0x14, 0x85, 0x53, 0x28, 0xbc, 0xc7, 0x6d, 0x02, 0xef, 0x4d, 0xf2, 0x48, 0xc3, 0xe6, 0xd5, 0x59,
+	0xd5, 0xa8, 0x25, 0x56, 0x77, 0xb8, 0x30, 0x6e, 0x8c, 0x09, 0x6e, 0xea, 0xce, 0x1d, 0x1d, 0x6e,
+	0x60, 0x4c, 0x3a, 0x33, 0xce, 0x4c, 0x49, 0xf0, 0x4f, 0xf9, 0x17, 0x4d, 0xa7, 0x13, 0x82, 0x10,
+	0xdc, 0xf5, 0xde, 0x7e, 0xe7, 0xdc, 0x73, 0x9a, 0x02, 0x70, 0x8b, 0x55, 0xa6, 0xb4, 0xb4, 0x92,
+	0x8c, 0xcc, 0x5a, 0x2a, 0xc5, 0xc5, 0x8a, 0x2d, 0xb4, 0xcd, 0x36, 0xd7, 0x74, 0x06, 0xbd, 0x67,
+	0x29, 0x70, 0x4b, 0xc6, 0xd0, 0x5f, 0x54, 0xb2, 0x16, 0x36, 0x0e, 0x92, 0x20, 0xed, 0x16, 0x7e,
+	0x22, 0x13, 0xf8, 0xcd, 0x6a, 0xad, 0x51, 0xb0, 0x6d, 0xdc, 0x49, 0x82, 0x74, 0x50, 0xec, 0x66,
+	0xfa, 0x01, 0xe1, 0x93, 0xc5, 0x8a, 0x0c, 0xa1, 0xc3, 0x97, 0x5e, 0xd7, 0xe1, 0x4b, 0x42, 0x20,
+	0x14, 0x8b, 0x0a, 0x3d, 0xef, 0x9e, 0x49, 0x02, 0xd1, 0x12, 0x0d, 0xd3, 0x5c, 0x59, 0x2e, 0x45,
+	0xdc, 0x75, 0xaf, 0xf6, 0x57, 0xe4, 0x12, 0x7a, 0x4a, 0x73, 0x86, 0x71, 0x98, 0x04, 0x69, 0x94,
+	0x8f, 0xb3, 0x83, 0xac, 0x99, 0x0b, 0x5a, 0xb4, 0x10, 0x25, 0xf0, 0x67, 0xce, 0x8d, 0x6d, 0xee,
+	0x9b, 0x02, 0xdf, 0x6b, 0x34, 0x96, 0xde, 0xc3, 0xdf, 0xbd, 0x9d, 0x51, 0x52, 0x18, 0x24, 0x17,
+	0xd0, 0x6b, 0x3e, 0x80, 0x89, 0x83, 0xa4, 0x9b, 0x46, 0xf9, 0xbf, 0x23, 0xdb, 0x06, 0x2f, 0x5a,
+	0x86, 0x26, 0x30, 0x7c, 0x44, 0x67, 0xe0, 0x3d, 0x0f, 0xbb, 0xd1, 0x3b, 0x18, 0xed, 0x08, 0x7f,
+	0xe1, 0x1c, 0xc2, 0x46, 0xed, 0xa0, 0x93, 0x07, 0x1c, 0x92, 0x7f, 0x06, 0x10, 0x35, 0xe3, 0x0b,
+	0xea, 0x0d, 0x67, 0x48, 0x0a, 0x18, 0xec, 0x12, 0x93, 0xb3, 0x23, 0xe5, 0x61, 0xc3, 0x09, 0xfd,
+	0x09, 0xf1, 0x71, 0xe6, 0xf0, 0xcb, 0x27, 0x24, 0xff, 0x8f, 0xf0, 0xef, 0xed, 0x26, 0xc9, 0x69,
+	0xa0, 0x75, 0x7b, 0xb8, 0x7d, 0xcd, 0x57, 0xdc, 0xae, 0xeb, 0x32, 0x63, 0xb2, 0x9a, 0xda, 0x37,
+	0x85, 0x7a, 0xba, 0xaf, 0xb9, 0x32, 0xa8, 0x37, 0xcd, 0xae, 0x6d, 0x34, 0x55, 0xe5, 0x4c, 0x95,
+	0x65, 0xdf, 0xfd, 0x6e, 0x37, 0x5f, 0x03, 0x00, 0x5d, 0x26, 0x0a, 0x9e, 0x7c, 0x02, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ItemServiceClient is the client API for ItemService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ItemServiceClient interface {
+	// ListItems retrieves all items.
+	ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error)
+	// GetItem retrieves an item.
+	GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*GetItemResponse, error)
+}
+
+type itemServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewItemServiceClient(cc *grpc.ClientConn) ItemServiceClient {
+	return &itemServiceClient{cc}
+}
+
+func (c *itemServiceClient) ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error) {
+	out := new(ListItemsResponse)
+	err := c.cc.Invoke(ctx, "/shoppingcart.v1.ItemService/ListItems", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *itemServiceClient) GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*GetItemResponse, error) {
+	out := new(GetItemResponse)
+	err := c.cc.Invoke(ctx, "/shoppingcart.v1.ItemService/GetItem", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ItemServiceServer is the server API for ItemService service.
+type ItemServiceServer interface {
+	// ListItems retrieves all items.
+	ListItems(context.Context, *ListItemsRequest) (*ListItemsResponse, error)
+	// GetItem retrieves an item.
+	GetItem(context.Context, *GetItemRequest) (*GetItemResponse, error)
+}
+
+// UnimplementedItemServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedItemServiceServer struct {
+}
+
+func (*UnimplementedItemServiceServer) ListItems(ctx context.Context, req *ListItemsRequest) (*ListItemsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListItems not implemented")
+}
+func (*UnimplementedItemServiceServer) GetItem(ctx context.Context, req *GetItemRequest) (*GetItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetItem not implemented")
+}
+
+func RegisterItemServiceServer(s *grpc.Server, srv ItemServiceServer) {
+	s.RegisterService(&_ItemService_serviceDesc, srv)
+}
+
+func _ItemService_ListItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListItemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ItemServiceServer).ListItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shoppingcart.v1.ItemService/ListItems",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ItemServiceServer).ListItems(ctx, req.(*ListItemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ItemService_GetItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ItemServiceServer).GetItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shoppingcart.v1.ItemService/GetItem",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ItemServiceServer).GetItem(ctx, req.(*GetItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ItemService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "shoppingcart.v1.ItemService",
+	HandlerType: (*ItemServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListItems",
+			Handler:    _ItemService_ListItems_Handler,
+		},
+		{
+			MethodName: "GetItem",
+			Handler:    _ItemService_GetItem_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "item.proto",
+}
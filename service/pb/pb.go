@@ -0,0 +1,5 @@
+// Package pb contains the protobuf messages and gRPC services generated from
+// the definitions in the proto directory.
+package pb
+
+//go:generate protoc -I ../../proto --go_out=plugins=grpc,paths=source_relative:. item.proto cart.proto
@@ -0,0 +1,193 @@
+// Package pubsub implements an in-process publish/subscribe hub. Events are
+// published to topics and delivered to the topic's current subscribers. The
+// most recent events of each topic are retained so that a subscriber that
+// reconnects may resume from the last event it received.
+package pubsub
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a message published to a topic.
+type Event struct {
+	// Id identifies the event. Ids increase monotonically across every topic
+	// of a Hub.
+	Id uint64
+
+	// Type names the kind of event, such as "cartItem.added".
+	Type string
+
+	// Data is the event payload. Data is shared by every subscriber, and so
+	// must not be modified.
+	Data interface{}
+}
+
+// Hub routes published events to subscribers. A Hub is safe for concurrent
+// use.
+type Hub struct {
+	size      int
+	retention time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	seq    uint64
+	topics map[string]*topic
+	swept  time.Time
+}
+
+// topic is the state of a single topic.
+type topic struct {
+	// events holds at most Hub.size of the topic's most recent events,
+	// oldest first.
+	events []Event
+
+	// evicted is the id of the most recent event that may have been published
+	// to the topic but is no longer held by events.
+	evicted uint64
+
+	subs      map[*Subscription]struct{}
+	published time.Time
+}
+
+// New returns a Hub that retains the size most recent events of each topic.
+// The events of a topic without subscribers are discarded once no event has
+// been published to it for retention.
+func New(size int, retention time.Duration) *Hub {
+	return &Hub{
+		size:      size,
+		retention: retention,
+		now:       time.Now,
+		topics:    make(map[string]*topic),
+	}
+}
+
+// topic returns the topic named name, creating it if necessary. h.mu must be
+// held.
+func (h *Hub) topic(name string) *topic {
+	h.sweep()
+	t, ok := h.topics[name]
+	if !ok {
+		// Events published before the topic was created are unknown; they
+		// may have been discarded by sweep.
+		t = &topic{
+			evicted:   h.seq,
+			subs:      make(map[*Subscription]struct{}),
+			published: h.now(),
+		}
+		h.topics[name] = t
+	}
+	return t
+}
+
+// sweep discards topics without subscribers that have not been published to
+// for h.retention. Topics are swept at most once per h.retention. h.mu must
+// be held.
+func (h *Hub) sweep() {
+	var now = h.now()
+	if now.Sub(h.swept) < h.retention {
+		return
+	}
+	h.swept = now
+	for name, t := range h.topics {
+		if len(t.subs) == 0 && now.Sub(t.published) >= h.retention {
+			delete(h.topics, name)
+		}
+	}
+}
+
+// Publish publishes an event of type typ with data to topic, and returns the
+// event. Subscribers that are not keeping up with the topic are closed
+// rather than blocking Publish.
+func (h *Hub) Publish(topic, typ string, data interface{}) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var t = h.topic(topic)
+	h.seq++
+	var ev = Event{Id: h.seq, Type: typ, Data: data}
+
+	t.published = h.now()
+	t.events = append(t.events, ev)
+	if len(t.events) > h.size {
+		t.evicted = t.events[0].Id
+		t.events = append(t.events[:0], t.events[1:]...)
+	}
+
+	for sub := range t.subs {
+		select {
+		case sub.events <- ev:
+		default:
+			delete(t.subs, sub)
+			close(sub.events)
+		}
+	}
+	return ev
+}
+
+// Subscribe subscribes to the events published to topic. If lastEventId is
+// not zero, the events published to topic after lastEventId are returned for
+// replay. complete reports whether every such event could be returned; if
+// not, the subscriber has missed events and must recover by other means.
+func (h *Hub) Subscribe(topic string, lastEventId uint64) (sub *Subscription, missed []Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var t = h.topic(topic)
+	sub = &Subscription{
+		Last:   h.seq,
+		hub:    h,
+		topic:  topic,
+		events: make(chan Event, h.size),
+	}
+	t.subs[sub] = struct{}{}
+
+	if lastEventId == 0 {
+		return sub, nil, true
+	}
+	if lastEventId < t.evicted || lastEventId > h.seq {
+		return sub, nil, false
+	}
+	for _, ev := range t.events {
+		if ev.Id > lastEventId {
+			missed = append(missed, ev)
+		}
+	}
+	return sub, missed, true
+}
+
+// Subscription is a subscriber to a single topic of a Hub.
+type Subscription struct {
+	// Last is the id of the most recent event published to the Hub when the
+	// subscription was created.
+	Last uint64
+
+	hub    *Hub
+	topic  string
+	events chan Event
+	once   sync.Once
+}
+
+// Events returns the channel events published to the topic are delivered
+// on. The channel is closed when the subscription is closed, or when the
+// subscriber falls too far behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close unsubscribes s from its topic. Close may be called more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		defer s.hub.mu.Unlock()
+
+		t, ok := s.hub.topics[s.topic]
+		if !ok {
+			return
+		}
+		if _, ok := t.subs[s]; ok {
+			delete(t.subs, s)
+			close(s.events)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishSubscribe(t *testing.T) {
+	var h = New(4, time.Minute)
+
+	var a, _, _ = h.Subscribe("a", 0)
+	defer a.Close()
+	var b, _, _ = h.Subscribe("b", 0)
+	defer b.Close()
+
+	var ev = h.Publish("a", "added", []byte("1"))
+	require.Equal(t, Event{Id: 1, Type: "added", Data: []byte("1")}, ev)
+	require.Equal(t, ev, <-a.Events())
+
+	select {
+	case ev := <-b.Events():
+		t.Fatalf("unexpected event on topic b: %v", ev)
+	default:
+	}
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	tests := []struct {
+		Name        string
+		LastEventId uint64
+		Missed      []uint64
+		Complete    bool
+	}{
+		{Name: "no last event", LastEventId: 0, Complete: true},
+		{Name: "up to date", LastEventId: 7, Complete: true},
+		{Name: "behind", LastEventId: 5, Missed: []uint64{6, 7}, Complete: true},
+		{Name: "oldest retained", LastEventId: 3, Missed: []uint64{4, 5, 6, 7}, Complete: true},
+		{Name: "evicted", LastEventId: 2, Complete: false},
+		{Name: "unknown", LastEventId: 8, Complete: false},
+	}
+
+	var h = New(4, time.Minute)
+	for i := 0; i < 7; i++ {
+		h.Publish("a", "added", nil)
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sub, missed, complete := h.Subscribe("a", test.LastEventId)
+			defer sub.Close()
+
+			require.Equal(t, test.Complete, complete)
+			require.Equal(t, uint64(7), sub.Last)
+
+			var ids []uint64
+			for _, ev := range missed {
+				ids = append(ids, ev.Id)
+			}
+			require.Equal(t, test.Missed, ids)
+		})
+	}
+}
+
+func TestSlowSubscriberClosed(t *testing.T) {
+	var h = New(2, time.Minute)
+	var sub, _, _ = h.Subscribe("a", 0)
+
+	for i := 0; i < 3; i++ {
+		h.Publish("a", "added", nil)
+	}
+
+	var ids []uint64
+	for ev := range sub.Events() {
+		ids = append(ids, ev.Id)
+	}
+	require.Equal(t, []uint64{1, 2}, ids)
+
+	// Closing a subscription the hub already closed is a no-op.
+	sub.Close()
+}
+
+func TestSweep(t *testing.T) {
+	var (
+		now = time.Unix(0, 0)
+		h   = New(4, time.Minute)
+	)
+	h.now = func() time.Time { return now }
+
+	h.Publish("a", "added", nil)
+	var sub, _, _ = h.Subscribe("b", 0)
+	h.Publish("b", "added", nil)
+
+	now = now.Add(time.Minute)
+	h.Publish("c", "added", nil)
+	require.Len(t, h.topics, 2)
+	require.Contains(t, h.topics, "b")
+	require.Contains(t, h.topics, "c")
+
+	// Events that were discarded with a topic cannot be replayed.
+	_, _, complete := h.Subscribe("a", 1)
+	require.False(t, complete)
+
+	sub.Close()
+	now = now.Add(time.Minute)
+	h.Publish("c", "added", nil)
+	require.Len(t, h.topics, 2)
+	require.Contains(t, h.topics, "a")
+	require.Contains(t, h.topics, "c")
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+// decode decodes the JSON request body into v. If the body is not valid JSON,
+// an error wrapping errMalformed is returned.
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errors.Wrap(errMalformed, err.Error())
+	}
+	return nil
+}
+
+// urlParamId parses the URL parameter key of r as a non-zero id. If the
+// parameter is not a valid id, an error wrapping errMalformed is returned.
+func urlParamId(r *http.Request, key string) (int, error) {
+	var param = chi.URLParam(r, key)
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		return 0, errors.Wrapf(errMalformed, "failed to urlParamId\tkey=%s\tparam=%s\terr=%v", key, param, err)
+	}
+	if id == 0 {
+		return 0, errors.Wrapf(errMalformed, "failed to urlParamId\tkey=%s\tparam=%s", key, param)
+	}
+	return id, nil
+}
+
+// queryLimit parses the limit query parameter of r, which defaults to def
+// and is capped at max. If the parameter is not a positive integer, an error
+// wrapping errMalformed is returned.
+func queryLimit(r *http.Request, def, max int) (int, error) {
+	var param = r.URL.Query().Get("limit")
+	if param == "" {
+		return def, nil
+	}
+	limit, err := strconv.Atoi(param)
+	if err != nil || limit < 1 {
+		return 0, errors.Wrapf(errMalformed, "failed to queryLimit\tparam=%s", param)
+	}
+	if limit > max {
+		limit = max
+	}
+	return limit, nil
+}
+
+// queryTime parses the query parameter key of r as an RFC 3339 timestamp. If
+// the parameter is absent, the zero time is returned. If it is not a valid
+// timestamp, an error wrapping errMalformed is returned.
+func queryTime(r *http.Request, key string) (time.Time, error) {
+	var param = r.URL.Query().Get(key)
+	if param == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, param)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(errMalformed, "failed to queryTime\tkey=%s\tparam=%s", key, param)
+	}
+	return t, nil
+}
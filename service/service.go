@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"expvar"
 	"log"
 	"net/http"
 	"os"
@@ -11,11 +13,18 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/tjper/shoppingcart-server/service/blob"
+	"github.com/tjper/shoppingcart-server/service/outbox"
+	"github.com/tjper/shoppingcart-server/service/pubsub"
+	"github.com/tjper/shoppingcart-server/service/webhook"
+
 	"github.com/go-chi/chi"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
 )
 
 // Service defines all service dependencies.
@@ -24,12 +33,32 @@ type Service struct {
 	DB     *sql.DB
 	Zap    *zap.Logger
 	Router chi.Router
+	GRPC   *grpc.Server
+	Health *health.Server
+	Hub    *pubsub.Hub
+	Outbox *outbox.Relay
+
+	// Webhooks dispatches deliveries to the webhooks registered through the
+	// /webhooks endpoints.
+	Webhooks *webhook.Dispatcher
+
+	// Blobs stores the images of items.
+	Blobs blob.Store
+
+	// Metrics counts the work done by background jobs, such as the carts
+	// purged; see GetMetricsHandler.
+	Metrics *expvar.Map
+
+	// cartLocks serializes the cart session operations on each cart.
+	cartLocks *cartLocks
 }
 
 // NewService initializes a new cart Service via option functions.
 func New(v *viper.Viper, options ...ServiceOption) *Service {
 	var svc = &Service{
-		Viper: v,
+		Viper:     v,
+		Metrics:   new(expvar.Map).Init(),
+		cartLocks: newCartLocks(),
 	}
 
 	for _, option := range options {
@@ -67,6 +96,32 @@ func WithDB() ServiceOption {
 	}
 }
 
+// WithHub returns a ServiceOption that initializes the Service.Hub field,
+// through which cart changes are published to event stream clients.
+func WithHub() ServiceOption {
+	return func(svc *Service) {
+		svc.Hub = pubsub.New(
+			svc.Viper.GetInt(EnvVarEventsReplaySize),
+			svc.Viper.GetDuration(EnvVarEventsRetention),
+		)
+	}
+}
+
+// WithBlobStore returns a ServiceOption that initializes the Service.Blobs
+// field with a store of files in the directory specified in viper.
+func WithBlobStore() ServiceOption {
+	return func(svc *Service) {
+		store, err := blob.NewFS(
+			svc.Viper.GetString(EnvVarBlobDir),
+			svc.Viper.GetString(EnvVarBlobBaseURL),
+		)
+		if err != nil {
+			panic(err)
+		}
+		svc.Blobs = store
+	}
+}
+
 // WithZap returns a ServiceOption that initializes the Service.Zap field.
 func WithZap() ServiceOption {
 	return func(svc *Service) {
@@ -89,29 +144,85 @@ func WithZap() ServiceOption {
 	}
 }
 
-// WithRouter returns a ServiceOption that initializes the Service.Router field.
-func WithRouters(routers ...func(chi.Router)) ServiceOption {
-	var r = chi.NewRouter()
-	r.Use(defaultMiddleware()...)
+// WithRouters returns a ServiceOption that initializes the Service.Router
+// field. The routes.V1 and routes.V2 routers are mounted under /v1 and /v2;
+// requests for other paths not served by the routes.Root routers are routed
+// to the version requested by their Accept-Version header.
+func WithRouters(routes Routes) ServiceOption {
 	return func(svc *Service) {
-		for _, router := range routers {
+		var r = chi.NewRouter()
+		r.Use(svc.defaultMiddleware()...)
+		r.Use(svc.versionMiddleware(r))
+
+		for _, router := range routes.Root {
 			r.Group(router)
 		}
+		r.Route("/"+versionV1, func(v1 chi.Router) {
+			v1.Use(svc.deprecationMiddleware(r))
+			for _, router := range routes.V1 {
+				v1.Group(router)
+			}
+		})
+		r.Route("/"+versionV2, func(v2 chi.Router) {
+			for _, router := range routes.V2 {
+				v2.Group(router)
+			}
+		})
 		svc.Router = r
 	}
 }
 
+// Routes returns every router served by the Service, for use with
+// WithRouters.
+func (svc *Service) Routes() Routes {
+	return Routes{
+		Root: []func(chi.Router){
+			svc.OpenAPIRoutes,
+			svc.GraphQLRoutes,
+			svc.MediaRoutes,
+			svc.MetricsRoutes,
+		},
+		V1: []func(chi.Router){
+			svc.CartRoutes,
+			svc.CartMemberRoutes,
+			svc.ItemRoutes,
+			svc.CategoryRoutes,
+			svc.ItemMediaRoutes,
+			svc.ItemPriceRoutes,
+			svc.CatalogRoutes,
+			svc.WebhookRoutes,
+			svc.AdminRoutes,
+		},
+		V2: []func(chi.Router){
+			svc.CartRoutesV2,
+			svc.CartMemberRoutes,
+			svc.ItemRoutesV2,
+			svc.CategoryRoutesV2,
+			svc.ItemMediaRoutes,
+			svc.ItemPriceRoutes,
+			svc.CatalogRoutes,
+			svc.WebhookRoutes,
+			svc.AdminRoutes,
+		},
+	}
+}
+
 // ListenAndServe opens a set of HTTP endpoints as specified by svc.Routes() on
-// the port specified in viper.
+// the port specified in viper. Expired carts are purged in the background
+// while the endpoints are served.
 func (svc *Service) ListenAndServe() {
 	var (
 		srv             http.Server
 		idleConnsClosed = make(chan struct{})
 	)
+	ctx, cancel := context.WithCancel(context.Background())
+	go svc.purgeCarts(ctx)
+
 	go func() {
 		var sigint = make(chan os.Signal, 1)
 		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 		<-sigint
+		cancel()
 
 		if err := srv.Shutdown(context.Background()); err != nil {
 			svc.Zap.Error(errors.Wrap(err, "HTTP server shutdown").Error())
@@ -128,16 +239,50 @@ func (svc *Service) ListenAndServe() {
 	<-idleConnsClosed
 }
 
+// signalContext returns a context that is canceled when the process is
+// signaled to stop.
+func signalContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		var sigint = make(chan os.Signal, 1)
+		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+		<-sigint
+		cancel()
+	}()
+	return ctx
+}
+
 // Close executes necessary cleanup and closing procedures for the Service.
 func (svc *Service) Close() {
 	svc.Zap.Sync()
 }
 
-// Error writes a status code and an optional message to the client. If an
-// internal Server error has occurred, the error is logged.
+// Error writes a status code and an optional message to the client as a JSON
+// ErrorResponse. If no message is passed, the status text of code is used. If
+// an internal Server error has occurred, the error is logged.
 func (svc Service) Error(w http.ResponseWriter, err error, code int, message ...string) {
-	// if code == http.StatusInternalServerError {
-	svc.Zap.Error(err.Error())
-	// }
-	http.Error(w, strings.Join(message, "\n"), code)
+	if err == nil {
+		err = errors.New(http.StatusText(code))
+	}
+	if code >= http.StatusInternalServerError {
+		svc.Zap.Error(err.Error())
+	} else {
+		svc.Zap.Debug(err.Error())
+	}
+
+	var msg = strings.Join(message, "\n")
+	if msg == "" {
+		msg = http.StatusText(code)
+	}
+	svc.writeError(w, err, ErrorBody{Status: code, Message: msg})
+}
+
+// writeError writes body to the client as a JSON ErrorResponse.
+func (svc Service) writeError(w http.ResponseWriter, err error, body ErrorBody) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(body.Status)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Error: body}); err != nil {
+		svc.Zap.Error(errors.Wrap(err, "failed to writeError/Encode").Error())
+	}
 }
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// inTx calls fn within a transaction, which is committed if fn returns nil
+// and rolled back otherwise.
+func (svc *Service) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := svc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to inTx/BeginTx")
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			svc.Zap.Error(errors.Wrap(rbErr, "failed to inTx/Rollback").Error())
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to inTx/Commit")
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// tagName is the struct tag key read by Struct.
+const tagName = "validate"
+
+// Struct validates the exported fields of the struct s, or a pointer to one,
+// against the rules declared in their `validate` struct tags. Fields are
+// reported by their json name. Rules are comma separated:
+//
+//	required        the field must not be the zero value (or a nil pointer)
+//	min=N           numbers must be >= N; strings, slices and maps must have
+//	                a length >= N
+//	max=N           numbers must be <= N; strings, slices and maps must have
+//	                a length <= N
+//	len=N           strings, slices and maps must have a length of exactly N
+//	oneof=a b c     strings and ints must be one of the space separated values
+//	regex=EXPR      strings must match EXPR; regex must be the last rule, as
+//	                EXPR extends to the end of the tag
+//
+// Nested structs, pointers to structs, and slices of structs are validated
+// recursively, with errors reported as "parent.child" and "items[0].child".
+// Struct panics if a tag cannot be parsed, as that is a programming error.
+func Struct(s interface{}) error {
+	var v Validator
+	validateStruct(&v, "", reflect.ValueOf(s))
+	return v.Err()
+}
+
+// rule is a single parsed validate tag rule, such as min=1.
+type rule struct {
+	name  string
+	param string
+}
+
+// fieldRules are the parsed rules of a single struct field.
+type fieldRules struct {
+	index int
+	name  string
+	rules []rule
+}
+
+// typeRules caches the parsed fieldRules of each struct type by
+// reflect.Type.
+var typeRules sync.Map
+
+// regexps caches compiled regex rule expressions by expression.
+var regexps sync.Map
+
+func rulesOf(t reflect.Type) []fieldRules {
+	if cached, ok := typeRules.Load(t); ok {
+		return cached.([]fieldRules)
+	}
+
+	var frs = make([]fieldRules, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		var f = t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		frs = append(frs, fieldRules{
+			index: i,
+			name:  jsonName(f),
+			rules: parseTag(f.Tag.Get(tagName)),
+		})
+	}
+	typeRules.Store(t, frs)
+	return frs
+}
+
+// jsonName returns the name f is encoded as in JSON.
+func jsonName(f reflect.StructField) string {
+	var name = strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
+
+func parseTag(tag string) []rule {
+	var rules []rule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		var r rule
+		if i := strings.Index(part, "="); i >= 0 {
+			r = rule{name: part[:i], param: part[i+1:]}
+		} else {
+			r = rule{name: part}
+		}
+		switch r.name {
+		case "required":
+		case "min", "max", "len":
+			if _, err := strconv.ParseFloat(r.param, 64); err != nil {
+				panic(fmt.Sprintf("validate: invalid %s param %q", r.name, r.param))
+			}
+		case "oneof":
+		case "regex":
+			regexps.LoadOrStore(r.param, regexp.MustCompile(r.param))
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q", r.name))
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func validateStruct(v *Validator, prefix string, val reflect.Value) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return
+	}
+
+	for _, fr := range rulesOf(val.Type()) {
+		var (
+			field = join(prefix, fr.name)
+			fv    = val.Field(fr.index)
+		)
+		if msg := checkRules(fv, fr.rules); msg != "" {
+			v.errs = append(v.errs, FieldError{Field: field, Message: msg})
+			continue
+		}
+		validateNested(v, field, fv)
+	}
+}
+
+// validateNested validates the struct values held by val, if any.
+func validateNested(v *Validator, field string, val reflect.Value) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Struct:
+		validateStruct(v, field, val)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			validateNested(v, fmt.Sprintf("%s[%d]", field, i), val.Index(i))
+		}
+	}
+}
+
+// checkRules returns the failure message of the first rule val fails, or an
+// empty string if val passes every rule.
+func checkRules(val reflect.Value, rules []rule) string {
+	for _, r := range rules {
+		if r.name == "required" {
+			if !val.IsValid() || val.IsZero() {
+				return "is required"
+			}
+			continue
+		}
+
+		// Remaining rules apply to the value pointed to; nil pointers are
+		// left to the required rule.
+		var elem = val
+		for elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				return ""
+			}
+			elem = elem.Elem()
+		}
+		if msg := checkRule(elem, r); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+func checkRule(val reflect.Value, r rule) string {
+	switch r.name {
+	case "min", "max", "len":
+		var (
+			n, _        = strconv.ParseFloat(r.param, 64)
+			size, isLen = sizeOf(val)
+		)
+		if isLen {
+			switch {
+			case r.name == "min" && size < n:
+				return fmt.Sprintf("must have a length of at least %s", r.param)
+			case r.name == "max" && size > n:
+				return fmt.Sprintf("must have a length of at most %s", r.param)
+			case r.name == "len" && size != n:
+				return fmt.Sprintf("must have a length of %s", r.param)
+			}
+			return ""
+		}
+		switch {
+		case r.name == "min" && size < n:
+			return fmt.Sprintf("must be greater than or equal to %s", r.param)
+		case r.name == "max" && size > n:
+			return fmt.Sprintf("must be less than or equal to %s", r.param)
+		}
+	case "oneof":
+		var options = strings.Fields(r.param)
+		var s = fmt.Sprint(val.Interface())
+		for _, option := range options {
+			if s == option {
+				return ""
+			}
+		}
+		return "must be one of [" + strings.Join(options, ", ") + "]"
+	case "regex":
+		var re, _ = regexps.Load(r.param)
+		if val.Kind() == reflect.String && !re.(*regexp.Regexp).MatchString(val.String()) {
+			return "must match " + r.param
+		}
+	}
+	return ""
+}
+
+// sizeOf returns the numeric value of val, or its length if val is a string,
+// slice, array or map. isLen reports whether the length was returned.
+func sizeOf(val reflect.Value) (size float64, isLen bool) {
+	switch val.Kind() {
+	case reflect.String:
+		return float64(len([]rune(val.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(val.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), false
+	}
+	return 0, false
+}
@@ -0,0 +1,191 @@
+// Package validate validates request data and reports every field that
+// failed, rather than only the first.
+//
+// Rules may be checked imperatively with a Validator:
+//
+//	v := new(validate.Validator)
+//	v.Check("count", validate.IntMin(req.Count, 1))
+//	v.Check("name", validate.Required(req.Name), validate.MaxLen(req.Name, 64))
+//	if err := v.Err(); err != nil {
+//		...
+//	}
+//
+// or declared with struct tags and checked with Struct. See Struct for the
+// supported tag rules.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// FieldError describes why a single field failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is the set of FieldErrors collected by a validation pass.
+type Errors []FieldError
+
+// Error implements the error interface.
+func (e Errors) Error() string {
+	var msgs = make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Check validates a single value. On failure, a message describing the
+// failure is returned. On success, an empty string is returned.
+type Check func() string
+
+// Validator collects FieldErrors from a series of checks. The zero value is
+// ready to use.
+type Validator struct {
+	errs Errors
+}
+
+// Check runs checks against field in order and records the first failure.
+// Checks for other fields continue to run, so that all failing fields are
+// reported.
+func (v *Validator) Check(field string, checks ...Check) {
+	for _, check := range checks {
+		if msg := check(); msg != "" {
+			v.errs = append(v.errs, FieldError{Field: field, Message: msg})
+			return
+		}
+	}
+}
+
+// Merge records errs under the field prefix. Merge is used to report the
+// errors of a nested value.
+func (v *Validator) Merge(prefix string, err error) {
+	errs, ok := err.(Errors)
+	if !ok {
+		if err != nil {
+			v.errs = append(v.errs, FieldError{Field: prefix, Message: err.Error()})
+		}
+		return
+	}
+	for _, fe := range errs {
+		v.errs = append(v.errs, FieldError{Field: join(prefix, fe.Field), Message: fe.Message})
+	}
+}
+
+// Err returns the collected Errors, or nil if every check passed.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Required fails if val is the zero value of its type.
+func Required(val interface{}) Check {
+	return func() string {
+		if val == nil || reflect.ValueOf(val).IsZero() {
+			return "is required"
+		}
+		return ""
+	}
+}
+
+// IntMin fails if val is less than min.
+func IntMin(val, min int) Check {
+	return func() string {
+		if val < min {
+			return fmt.Sprintf("must be greater than or equal to %d", min)
+		}
+		return ""
+	}
+}
+
+// IntMax fails if val is greater than max.
+func IntMax(val, max int) Check {
+	return func() string {
+		if val > max {
+			return fmt.Sprintf("must be less than or equal to %d", max)
+		}
+		return ""
+	}
+}
+
+// IntRange fails if val is not within [min, max].
+func IntRange(val, min, max int) Check {
+	return func() string {
+		if val < min || val > max {
+			return fmt.Sprintf("must be between %d and %d", min, max)
+		}
+		return ""
+	}
+}
+
+// FloatRange fails if val is not within [min, max].
+func FloatRange(val, min, max float64) Check {
+	return func() string {
+		if val < min || val > max {
+			return fmt.Sprintf("must be between %v and %v", min, max)
+		}
+		return ""
+	}
+}
+
+// MinLen fails if val has fewer than min characters.
+func MinLen(val string, min int) Check {
+	return func() string {
+		if len([]rune(val)) < min {
+			return fmt.Sprintf("must be at least %d characters", min)
+		}
+		return ""
+	}
+}
+
+// MaxLen fails if val has more than max characters.
+func MaxLen(val string, max int) Check {
+	return func() string {
+		if len([]rune(val)) > max {
+			return fmt.Sprintf("must be at most %d characters", max)
+		}
+		return ""
+	}
+}
+
+// OneOf fails if val is not one of options.
+func OneOf(val string, options ...string) Check {
+	return func() string {
+		for _, option := range options {
+			if val == option {
+				return ""
+			}
+		}
+		return "must be one of [" + strings.Join(options, ", ") + "]"
+	}
+}
+
+// Match fails if val does not match re.
+func Match(val string, re *regexp.Regexp) Check {
+	return func() string {
+		if !re.MatchString(val) {
+			return "must match " + re.String()
+		}
+		return ""
+	}
+}
+
+// join joins a field prefix and a field name with a dot.
+func join(prefix, field string) string {
+	switch {
+	case prefix == "":
+		return field
+	case field == "":
+		return prefix
+	case strings.HasPrefix(field, "["):
+		return prefix + field
+	default:
+		return prefix + "." + field
+	}
+}
@@ -0,0 +1,129 @@
+package validate
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidator(t *testing.T) {
+	var v Validator
+	v.Check("name", Required(""), MaxLen("", 4))
+	v.Check("count", IntMin(0, 1))
+	v.Check("page", IntRange(3, 1, 10))
+	v.Check("kind", OneOf("remove", "add", "set"))
+	v.Check("sku", Match("abc", regexp.MustCompile(`^[A-Z]+$`)))
+
+	require.Equal(t, Errors{
+		{Field: "name", Message: "is required"},
+		{Field: "count", Message: "must be greater than or equal to 1"},
+		{Field: "kind", Message: "must be one of [add, set]"},
+		{Field: "sku", Message: "must match ^[A-Z]+$"},
+	}, v.Err())
+}
+
+func TestValidatorNoErrors(t *testing.T) {
+	var v Validator
+	v.Check("name", Required("photo book"), MinLen("photo book", 1), MaxLen("photo book", 64))
+	require.Nil(t, v.Err())
+}
+
+func TestStruct(t *testing.T) {
+	type (
+		Line struct {
+			ItemId int `json:"itemId" validate:"required"`
+			Count  int `json:"count" validate:"min=1,max=99"`
+		}
+		Request struct {
+			UserId int     `json:"userId" validate:"required"`
+			Name   string  `json:"name" validate:"min=2,max=8"`
+			Code   string  `json:"code" validate:"len=3"`
+			Op     string  `json:"op" validate:"required,oneof=add set remove"`
+			Sku    string  `json:"sku" validate:"regex=^[A-Z]{2,4}-[0-9]+$"`
+			Lines  []Line  `json:"lines" validate:"min=1"`
+			First  *Line   `json:"first"`
+			Note   *string `json:"note" validate:"max=3"`
+			hidden int
+		}
+	)
+
+	tests := []struct {
+		Name     string
+		Request  Request
+		Expected error
+	}{
+		{
+			Name: "Valid",
+			Request: Request{
+				UserId: 1,
+				Name:   "album",
+				Code:   "abc",
+				Op:     "add",
+				Sku:    "AB-12",
+				Lines:  []Line{{ItemId: 1, Count: 1}},
+			},
+			Expected: nil,
+		},
+		{
+			Name: "All fields invalid",
+			Request: Request{
+				Name:  "a",
+				Code:  "ab",
+				Op:    "merge",
+				Sku:   "ab-12",
+				Lines: []Line{{ItemId: 1, Count: 1}, {Count: 100}},
+				First: &Line{ItemId: 1},
+				Note:  func(s string) *string { return &s }("long"),
+			},
+			Expected: Errors{
+				{Field: "userId", Message: "is required"},
+				{Field: "name", Message: "must have a length of at least 2"},
+				{Field: "code", Message: "must have a length of 3"},
+				{Field: "op", Message: "must be one of [add, set, remove]"},
+				{Field: "sku", Message: "must match ^[A-Z]{2,4}-[0-9]+$"},
+				{Field: "lines[1].itemId", Message: "is required"},
+				{Field: "lines[1].count", Message: "must be less than or equal to 99"},
+				{Field: "first.count", Message: "must be greater than or equal to 1"},
+				{Field: "note", Message: "must have a length of at most 3"},
+			},
+		},
+		{
+			Name: "Empty slice",
+			Request: Request{
+				UserId: 1,
+				Name:   "album",
+				Code:   "abc",
+				Op:     "set",
+				Sku:    "AB-1",
+			},
+			Expected: Errors{
+				{Field: "lines", Message: "must have a length of at least 1"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := Struct(&test.Request)
+			if test.Expected == nil {
+				require.Nil(t, err)
+				return
+			}
+			require.Equal(t, test.Expected, err)
+		})
+	}
+}
+
+func TestStructInvalidTag(t *testing.T) {
+	type Request struct {
+		Count int `validate:"min=one"`
+	}
+	require.Panics(t, func() { Struct(Request{}) })
+}
+
+func TestMerge(t *testing.T) {
+	var v Validator
+	v.Merge("lines[0]", Errors{{Field: "count", Message: "is required"}})
+	require.Equal(t, Errors{{Field: "lines[0].count", Message: "is required"}}, v.Err())
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+const (
+	versionV1 = "v1"
+	versionV2 = "v2"
+
+	// defaultVersion is served to clients that do not request a version,
+	// such as clients that predate versioning.
+	defaultVersion = versionV1
+)
+
+// Routes groups the routers served by the Service by API version.
+type Routes struct {
+	// Root routers are served at the root path and are not versioned.
+	Root []func(chi.Router)
+
+	// V1 routers are served under /v1.
+	V1 []func(chi.Router)
+
+	// V2 routers are served under /v2.
+	V2 []func(chi.Router)
+}
+
+// negotiateVersion returns the API version requested by the Accept-Version
+// header value passed. Versions may be specified as "2", "v2", or "2.0". An
+// empty header requests the defaultVersion. If an unsupported version is
+// requested, an error wrapping errUnsupportedVersion is returned.
+func negotiateVersion(header string) (string, error) {
+	var major = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(header)), "v")
+	if i := strings.Index(major, "."); i >= 0 {
+		major = major[:i]
+	}
+	switch major {
+	case "":
+		return defaultVersion, nil
+	case "1":
+		return versionV1, nil
+	case "2":
+		return versionV2, nil
+	}
+	return "", errors.Wrapf(errUnsupportedVersion, "failed to negotiateVersion\theader=%s", header)
+}
+
+// isVersioned reports whether path begins with an API version prefix.
+func isVersioned(path string) bool {
+	for _, version := range []string{versionV1, versionV2} {
+		if path == "/"+version || strings.HasPrefix(path, "/"+version+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// versionMiddleware routes requests for unversioned paths that are not
+// served by root to the API version requested by the Accept-Version header.
+func (svc *Service) versionMiddleware(root chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var path = r.URL.Path
+			if isVersioned(path) || root.Match(chi.NewRouteContext(), r.Method, path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			version, err := negotiateVersion(r.Header.Get("Accept-Version"))
+			if err != nil {
+				svc.HandleError(w, err)
+				return
+			}
+			w.Header().Add("Vary", "Accept-Version")
+			chi.RouteContext(r.Context()).RoutePath = "/" + version + path
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// deprecationMiddleware marks responses of the v1 API as deprecated with the
+// Deprecation and Sunset headers, and links to the v2 equivalent of the
+// requested resource when root serves one.
+func (svc *Service) deprecationMiddleware(root chi.Routes) func(http.Handler) http.Handler {
+	var (
+		deprecation = fmt.Sprintf("@%d", svc.Viper.GetTime(EnvVarV1DeprecatedAt).Unix())
+		sunset      = svc.Viper.GetTime(EnvVarV1SunsetAt).UTC().Format(http.TimeFormat)
+	)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Set("Sunset", sunset)
+
+			var successor = "/" + versionV2 + chi.RouteContext(r.Context()).RoutePath
+			if root.Match(chi.NewRouteContext(), r.Method, successor) {
+				w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		Header   string
+		Expected string
+		Err      bool
+	}{
+		{Header: "", Expected: versionV1},
+		{Header: "1", Expected: versionV1},
+		{Header: "v1", Expected: versionV1},
+		{Header: "2", Expected: versionV2},
+		{Header: "V2", Expected: versionV2},
+		{Header: "2.0", Expected: versionV2},
+		{Header: "3", Err: true},
+		{Header: "latest", Err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Header, func(t *testing.T) {
+			version, err := negotiateVersion(test.Header)
+			if test.Err {
+				require.Equal(t, http.StatusNotAcceptable, statusCode(err))
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, test.Expected, version)
+		})
+	}
+}
+
+func TestVersionRouting(t *testing.T) {
+	var respond = func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body + " " + chi.URLParam(r, "userId")))
+		}
+	}
+
+	var svc = newTestService()
+	WithRouters(Routes{
+		Root: []func(chi.Router){
+			func(r chi.Router) { r.Get("/health", respond("root")) },
+		},
+		V1: []func(chi.Router){
+			func(r chi.Router) {
+				r.Get("/cart/{userId}", respond("v1"))
+				r.Get("/legacy", respond("v1 legacy"))
+			},
+		},
+		V2: []func(chi.Router){
+			func(r chi.Router) { r.Get("/cart/{userId}", respond("v2")) },
+		},
+	})(svc)
+
+	tests := []struct {
+		Name              string
+		Target            string
+		AcceptVersion     string
+		ExpectedStatus    int
+		ExpectedBody      string
+		ExpectedDeprecate bool
+		ExpectedLink      string
+	}{
+		{
+			Name:              "unversioned defaults to v1",
+			Target:            "/cart/7",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedBody:      "v1 7",
+			ExpectedDeprecate: true,
+			ExpectedLink:      `</v2/cart/7>; rel="successor-version"`,
+		},
+		{
+			Name:           "unversioned negotiates v2",
+			Target:         "/cart/7",
+			AcceptVersion:  "2",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "v2 7",
+		},
+		{
+			Name:              "explicit v1 ignores Accept-Version",
+			Target:            "/v1/cart/7",
+			AcceptVersion:     "2",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedBody:      "v1 7",
+			ExpectedDeprecate: true,
+			ExpectedLink:      `</v2/cart/7>; rel="successor-version"`,
+		},
+		{
+			Name:           "explicit v2",
+			Target:         "/v2/cart/7",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "v2 7",
+		},
+		{
+			Name:              "v1 without successor",
+			Target:            "/v1/legacy",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedBody:      "v1 legacy ",
+			ExpectedDeprecate: true,
+		},
+		{
+			Name:           "root is unversioned",
+			Target:         "/health",
+			AcceptVersion:  "2",
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "root ",
+		},
+		{
+			Name:           "unsupported version",
+			Target:         "/cart/7",
+			AcceptVersion:  "9",
+			ExpectedStatus: http.StatusNotAcceptable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(http.MethodGet, test.Target, nil)
+			)
+			if test.AcceptVersion != "" {
+				r.Header.Set("Accept-Version", test.AcceptVersion)
+			}
+			svc.Router.ServeHTTP(w, r)
+
+			require.Equal(t, test.ExpectedStatus, w.Code)
+			if test.ExpectedBody != "" {
+				require.Equal(t, test.ExpectedBody, w.Body.String())
+			}
+			if test.ExpectedDeprecate {
+				require.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+				require.Equal(t, "Tue, 19 Oct 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+			} else {
+				require.Empty(t, w.Header().Get("Deprecation"))
+				require.Empty(t, w.Header().Get("Sunset"))
+			}
+			require.Equal(t, test.ExpectedLink, w.Header().Get("Link"))
+		})
+	}
+}
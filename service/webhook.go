@@ -0,0 +1,59 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/webhook"
+)
+
+// webhookEventTypes are the outbox event types webhooks may subscribe to.
+var webhookEventTypes = []string{
+	cart.EventCartItemAdded,
+	cart.EventCartItemUpdated,
+	cart.EventCartItemRemoved,
+	cart.EventCartCleared,
+	cart.EventAbandonedCart,
+	item.EventItemCreated,
+	item.EventItemUpdated,
+	item.EventItemPriceScheduled,
+	item.EventItemPriceCancelled,
+	item.EventCategoryCreated,
+	item.EventItemCategoriesSet,
+}
+
+// WithWebhooks returns a ServiceOption that initializes the Service.Webhooks
+// field with a dispatcher configured by viper. Deliveries are recorded by the
+// webhooks outbox sink; see WithOutbox. Deliveries are sent to public
+// addresses only, unless viper permits private hosts. WithWebhooks must
+// follow WithDB and WithZap.
+func WithWebhooks() ServiceOption {
+	return func(svc *Service) {
+		var (
+			timeout = svc.Viper.GetDuration(EnvVarWebhookTimeout)
+			client  = webhook.NewClient(timeout)
+		)
+		if svc.Viper.GetBool(EnvVarWebhookAllowPrivateHosts) {
+			client = &http.Client{Timeout: timeout}
+		}
+		svc.Webhooks = webhook.NewDispatcher(
+			webhook.SQLStore{DB: svc.DB},
+			client,
+			webhook.Backoff{
+				Base: svc.Viper.GetDuration(EnvVarWebhookBackoff),
+				Max:  svc.Viper.GetDuration(EnvVarWebhookMaxBackoff),
+			},
+			svc.Viper.GetInt(EnvVarWebhookMaxAttempts),
+			svc.Viper.GetDuration(EnvVarWebhookInterval),
+			svc.Viper.GetInt(EnvVarWebhookBatchSize),
+			svc.Zap,
+		)
+	}
+}
+
+// DispatchWebhooks dispatches webhook deliveries with svc.Webhooks until the
+// process is signaled to stop.
+func (svc *Service) DispatchWebhooks() {
+	svc.Webhooks.Run(signalContext())
+}
@@ -0,0 +1,325 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tjper/shoppingcart-server/service/outbox"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Fanout is an outbox.Sink that records a pending Delivery of each event for
+// every webhook subscribed to the event's type. An event relayed again is
+// not recorded twice.
+type Fanout struct {
+	DB *sql.DB
+}
+
+// Deliver implements outbox.Sink.
+func (f Fanout) Deliver(ctx context.Context, ev outbox.Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return errors.Wrapf(err, "failed to Fanout.Deliver/Marshal\tid=%v", ev.Id)
+	}
+
+	var sql = `
+  INSERT IGNORE INTO webhook_delivery
+    (webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+  SELECT id, ?, ?, ?, ?, UTC_TIMESTAMP(6), UTC_TIMESTAMP(6)
+  FROM webhook
+  WHERE event_types = ''
+        OR FIND_IN_SET(?, event_types) > 0
+  `
+	var args = []interface{}{ev.Id, ev.Type, string(payload), StatusPending, ev.Type}
+	if _, err := f.DB.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to Fanout.Deliver/ExecContext\tsql=%s\tid=%v", sql, ev.Id)
+	}
+	return nil
+}
+
+// Attempt is a due delivery, and the webhook it is delivered to.
+type Attempt struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+// Store is the storage the Dispatcher reads due deliveries from and records
+// their outcome in.
+type Store interface {
+	// Due retrieves at most limit pending deliveries whose next attempt is
+	// due, most overdue first.
+	Due(ctx context.Context, limit int) ([]Attempt, error)
+
+	// MarkDelivered records that the delivery id was accepted with
+	// statusCode.
+	MarkDelivered(ctx context.Context, id int64, statusCode int) error
+
+	// MarkFailed records a failed attempt of the delivery id. statusCode is
+	// zero if no response was received. The delivery is next attempted at
+	// next, unless dead, in which case it is dead-lettered.
+	MarkFailed(ctx context.Context, id int64, statusCode int, cause error, next time.Time, dead bool) error
+}
+
+// SQLStore is a Store backed by the webhook_delivery table.
+type SQLStore struct {
+	DB *sql.DB
+}
+
+// Due implements Store.
+func (s SQLStore) Due(ctx context.Context, limit int) ([]Attempt, error) {
+	var sql = `
+  SELECT
+    webhook_delivery.id,
+    webhook_delivery.webhook_id,
+    webhook_delivery.event_id,
+    webhook_delivery.event_type,
+    webhook_delivery.payload,
+    webhook_delivery.attempts,
+    webhook.url,
+    webhook.secret
+  FROM webhook_delivery
+  INNER JOIN webhook
+  ON webhook_delivery.webhook_id = webhook.id
+  WHERE webhook_delivery.status = ?
+        AND webhook_delivery.next_attempt_at <= UTC_TIMESTAMP(6)
+  ORDER BY webhook_delivery.next_attempt_at, webhook_delivery.id
+  LIMIT ?
+  `
+	var args = []interface{}{StatusPending, limit}
+
+	rows, err := s.DB.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to Due/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	var attempts = make([]Attempt, 0)
+	for rows.Next() {
+		var (
+			a       Attempt
+			payload string
+		)
+		if err := rows.Scan(
+			&a.Id,
+			&a.WebhookId,
+			&a.EventId,
+			&a.EventType,
+			&payload,
+			&a.Attempts,
+			&a.URL,
+			&a.Secret,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to Due/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		a.Payload = json.RawMessage(payload)
+		a.Status = StatusPending
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to Due/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return attempts, nil
+}
+
+// MarkDelivered implements Store.
+func (s SQLStore) MarkDelivered(ctx context.Context, id int64, statusCode int) error {
+	var sql = `
+  UPDATE webhook_delivery
+  SET status = ?,
+      attempts = attempts + 1,
+      last_status_code = ?,
+      last_error = NULL,
+      delivered_at = UTC_TIMESTAMP(6)
+  WHERE id = ?
+  `
+	var args = []interface{}{StatusDelivered, statusCode, id}
+	if _, err := s.DB.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to MarkDelivered/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// MarkFailed implements Store.
+func (s SQLStore) MarkFailed(ctx context.Context, id int64, statusCode int, cause error, next time.Time, dead bool) error {
+	var sql = `
+  UPDATE webhook_delivery
+  SET status = ?,
+      attempts = attempts + 1,
+      last_status_code = NULLIF(?, 0),
+      last_error = ?,
+      next_attempt_at = ?
+  WHERE id = ?
+  `
+	var status = StatusPending
+	if dead {
+		status = StatusDead
+	}
+	var args = []interface{}{status, statusCode, cause.Error(), next.UTC(), id}
+	if _, err := s.DB.ExecContext(ctx, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to MarkFailed/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	return nil
+}
+
+// Backoff computes the delay before retrying a failed delivery. The delay
+// doubles with each failed attempt, starting at Base, up to Max.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns the delay after the attempts-th failed attempt.
+func (b Backoff) Delay(attempts int) time.Duration {
+	var delay = b.Base
+	for i := 1; i < attempts && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+// Dispatcher POSTs the due deliveries in a Store to their webhooks.
+type Dispatcher struct {
+	store       Store
+	client      *http.Client
+	backoff     Backoff
+	maxAttempts int
+	interval    time.Duration
+	batchSize   int
+	logger      *zap.Logger
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// NewDispatcher returns a Dispatcher that POSTs the deliveries in store with
+// client, reading at most batchSize due deliveries at a time. A failed
+// delivery is retried after the delay computed by backoff, and dead-lettered
+// after maxAttempts failed attempts. When no deliveries are due, the
+// Dispatcher waits interval before polling again.
+func NewDispatcher(store Store, client *http.Client, backoff Backoff, maxAttempts int, interval time.Duration, batchSize int, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		client:      client,
+		backoff:     backoff,
+		maxAttempts: maxAttempts,
+		interval:    interval,
+		batchSize:   batchSize,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// Run dispatches deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var timer = time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n, err := d.Dispatch(ctx)
+		if err != nil {
+			d.logger.Error(err.Error())
+		}
+
+		// A full batch suggests more deliveries are due.
+		if err == nil && n == d.batchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(d.interval)
+		}
+	}
+}
+
+// Dispatch attempts a single batch of due deliveries concurrently, and
+// returns the number attempted. The outcome of each attempt is recorded in
+// the Store.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	attempts, err := d.store.Due(ctx, d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, a := range attempts {
+		wg.Add(1)
+		go func(a Attempt) {
+			defer wg.Done()
+			if err := d.attempt(ctx, a); err != nil {
+				d.logger.Error(err.Error())
+			}
+		}(a)
+	}
+	wg.Wait()
+	return len(attempts), nil
+}
+
+// attempt POSTs a to its webhook and records the outcome. Only errors
+// recording the outcome are returned.
+func (d *Dispatcher) attempt(ctx context.Context, a Attempt) error {
+	statusCode, err := d.post(ctx, a)
+	if err == nil {
+		return d.store.MarkDelivered(ctx, a.Id, statusCode)
+	}
+
+	var (
+		attempts = a.Attempts + 1
+		dead     = attempts >= d.maxAttempts
+		next     = d.now().Add(d.backoff.Delay(attempts))
+	)
+	if dead {
+		d.logger.Warn("webhook delivery dead-lettered",
+			zap.Int64("id", a.Id),
+			zap.Int("webhookId", a.WebhookId),
+			zap.Int("attempts", attempts),
+			zap.Error(err),
+		)
+	}
+	return d.store.MarkFailed(ctx, a.Id, statusCode, err, next, dead)
+}
+
+// post POSTs the signed payload of a to its webhook, and returns the
+// response status code. Any response status other than 2xx is an error.
+func (d *Dispatcher) post(ctx context.Context, a Attempt) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, a.URL, bytes.NewReader(a.Payload))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to Dispatcher.post/NewRequest\turl=%s", a.URL)
+	}
+	req = req.WithContext(ctx)
+
+	var timestamp = d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventId, strconv.FormatInt(a.EventId, 10))
+	req.Header.Set(HeaderEventType, a.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(a.Secret, timestamp, a.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to Dispatcher.post/Do\turl=%s\tid=%v", a.URL, a.Id)
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.Errorf("failed to Dispatcher.post\turl=%s\tid=%v\tstatus=%v", a.URL, a.Id, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
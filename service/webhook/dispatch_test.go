@@ -0,0 +1,256 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	deliveries map[int64]*Attempt
+}
+
+func newMemStore(now func() time.Time, attempts ...Attempt) *memStore {
+	var s = &memStore{now: now, deliveries: make(map[int64]*Attempt)}
+	for i := range attempts {
+		attempts[i].Status = StatusPending
+		s.deliveries[attempts[i].Id] = &attempts[i]
+	}
+	return s
+}
+
+func (s *memStore) Due(ctx context.Context, limit int) ([]Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []Attempt
+	for id := int64(1); id <= int64(len(s.deliveries)); id++ {
+		var a = s.deliveries[id]
+		if a.Status == StatusPending && !a.NextAttemptAt.After(s.now()) && len(due) < limit {
+			due = append(due, *a)
+		}
+	}
+	return due, nil
+}
+
+func (s *memStore) MarkDelivered(ctx context.Context, id int64, statusCode int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var a = s.deliveries[id]
+	a.Status = StatusDelivered
+	a.Attempts++
+	a.LastStatusCode = statusCode
+	return nil
+}
+
+func (s *memStore) MarkFailed(ctx context.Context, id int64, statusCode int, cause error, next time.Time, dead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var a = s.deliveries[id]
+	a.Attempts++
+	a.LastStatusCode = statusCode
+	a.LastError = cause.Error()
+	a.NextAttemptAt = next
+	if dead {
+		a.Status = StatusDead
+	}
+	return nil
+}
+
+func (s *memStore) get(id int64) Attempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.deliveries[id]
+}
+
+// receiver is an httptest server that verifies the signature of each
+// delivery, and responds with the next of its statuses.
+type receiver struct {
+	*httptest.Server
+	t        *testing.T
+	mu       sync.Mutex
+	statuses []int
+	eventIds []string
+}
+
+func newReceiver(t *testing.T, secret string, statuses ...int) *receiver {
+	var rcv = &receiver{t: t, statuses: statuses}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.Nil(t, err)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		require.Nil(t, err)
+		require.True(t, Verify(secret, r.Header.Get(HeaderSignature), timestamp, body))
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "CartItemAdded", r.Header.Get(HeaderEventType))
+
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+		rcv.eventIds = append(rcv.eventIds, r.Header.Get(HeaderEventId))
+		var status = http.StatusOK
+		if len(rcv.statuses) > 0 {
+			status, rcv.statuses = rcv.statuses[0], rcv.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	return rcv
+}
+
+func attemptTo(rcv *receiver, id int64, secret string) Attempt {
+	return Attempt{
+		Delivery: Delivery{
+			Id:        id,
+			WebhookId: 1,
+			EventId:   id * 10,
+			EventType: "CartItemAdded",
+			Payload:   json.RawMessage(`{"id":` + strconv.FormatInt(id*10, 10) + `}`),
+		},
+		URL:    rcv.URL,
+		Secret: secret,
+	}
+}
+
+func TestSign(t *testing.T) {
+	var body = []byte(`{"id":1}`)
+	var signature = Sign("secret", 1577934245, body)
+	require.Equal(t, "sha256=", signature[:7])
+	require.Len(t, signature, 7+64)
+
+	require.True(t, Verify("secret", signature, 1577934245, body))
+	require.False(t, Verify("other", signature, 1577934245, body))
+	require.False(t, Verify("secret", signature, 1577934246, body))
+	require.False(t, Verify("secret", signature, 1577934245, []byte(`{"id":2}`)))
+}
+
+func TestBackoff(t *testing.T) {
+	var backoff = Backoff{Base: time.Second, Max: 10 * time.Second}
+	for attempts, expected := range []time.Duration{
+		time.Second, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	} {
+		require.Equal(t, expected, backoff.Delay(attempts), "attempts=%d", attempts)
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	var (
+		rcv   = newReceiver(t, "secret")
+		now   = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		clock = func() time.Time { return now }
+		store = newMemStore(clock, attemptTo(rcv, 1, "secret"), attemptTo(rcv, 2, "secret"))
+		d     = NewDispatcher(store, rcv.Client(), Backoff{Base: time.Second, Max: time.Minute}, 3, time.Minute, 10, zap.NewNop())
+	)
+	defer rcv.Close()
+	d.now = clock
+
+	n, err := d.Dispatch(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 2, n)
+	require.ElementsMatch(t, []string{"10", "20"}, rcv.eventIds)
+
+	for _, id := range []int64{1, 2} {
+		var a = store.get(id)
+		require.Equal(t, StatusDelivered, a.Status)
+		require.Equal(t, 1, a.Attempts)
+		require.Equal(t, http.StatusOK, a.LastStatusCode)
+	}
+
+	n, err = d.Dispatch(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 0, n)
+}
+
+func TestDispatchRetry(t *testing.T) {
+	var (
+		rcv   = newReceiver(t, "secret", http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusAccepted)
+		now   = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		clock = func() time.Time { return now }
+		store = newMemStore(clock, attemptTo(rcv, 1, "secret"))
+		d     = NewDispatcher(store, rcv.Client(), Backoff{Base: time.Second, Max: time.Minute}, 5, time.Minute, 10, zap.NewNop())
+	)
+	defer rcv.Close()
+	d.now = clock
+
+	// Each failed attempt doubles the delay before the next.
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second} {
+		n, err := d.Dispatch(context.Background())
+		require.Nil(t, err)
+		require.Equal(t, 1, n)
+
+		var a = store.get(1)
+		require.Equal(t, StatusPending, a.Status)
+		require.Equal(t, now.Add(delay), a.NextAttemptAt)
+		require.NotEmpty(t, a.LastError)
+
+		// The delivery is not attempted before it is due.
+		n, err = d.Dispatch(context.Background())
+		require.Nil(t, err)
+		require.Equal(t, 0, n)
+		now = now.Add(delay)
+	}
+
+	n, err := d.Dispatch(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 1, n)
+
+	var a = store.get(1)
+	require.Equal(t, StatusDelivered, a.Status)
+	require.Equal(t, 3, a.Attempts)
+	require.Equal(t, http.StatusAccepted, a.LastStatusCode)
+	require.Equal(t, []string{"10", "10", "10"}, rcv.eventIds)
+}
+
+func TestDispatchDeadLetter(t *testing.T) {
+	var (
+		rcv   = newReceiver(t, "secret", http.StatusGone, http.StatusGone, http.StatusGone)
+		now   = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		clock = func() time.Time { return now }
+		store = newMemStore(clock, attemptTo(rcv, 1, "secret"))
+		d     = NewDispatcher(store, rcv.Client(), Backoff{Base: time.Second, Max: time.Minute}, 2, time.Minute, 10, zap.NewNop())
+	)
+	defer rcv.Close()
+	d.now = clock
+
+	for i := 0; i < 3; i++ {
+		_, err := d.Dispatch(context.Background())
+		require.Nil(t, err)
+		now = now.Add(time.Hour)
+	}
+
+	// Dead deliveries are not retried.
+	var a = store.get(1)
+	require.Equal(t, StatusDead, a.Status)
+	require.Equal(t, 2, a.Attempts)
+	require.Equal(t, http.StatusGone, a.LastStatusCode)
+	require.Equal(t, []string{"10", "10"}, rcv.eventIds)
+}
+
+func TestDispatchUnreachable(t *testing.T) {
+	var (
+		rcv   = newReceiver(t, "secret")
+		now   = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		clock = func() time.Time { return now }
+		store = newMemStore(clock, attemptTo(rcv, 1, "secret"))
+		d     = NewDispatcher(store, rcv.Client(), Backoff{Base: time.Second, Max: time.Minute}, 3, time.Minute, 10, zap.NewNop())
+	)
+	d.now = clock
+	rcv.Close()
+
+	_, err := d.Dispatch(context.Background())
+	require.Nil(t, err)
+
+	var a = store.get(1)
+	require.Equal(t, StatusPending, a.Status)
+	require.Equal(t, 0, a.LastStatusCode)
+	require.NotEmpty(t, a.LastError)
+}
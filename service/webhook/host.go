@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrPrivateHost indicates a webhook URL's host resolves to an address that
+// is not public, such as a loopback, private or link-local address, which
+// deliveries may not be sent to lest they reach internal services.
+var ErrPrivateHost = errors.New("webhook: host is not public")
+
+// nonPublicNets are the address ranges deliveries may not be sent to.
+var nonPublicNets = parseCIDRs(
+	"0.0.0.0/8",      // "this" network; 0.0.0.0 reaches the local host
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local, including cloud metadata services
+	"172.16.0.0/12",  // private
+	"192.168.0.0/16", // private
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	var nets = make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// PublicIP reports whether deliveries may be sent to ip: whether it is not a
+// loopback, private, link-local or unspecified address. IPv4-mapped IPv6
+// addresses are judged by the IPv4 address they map.
+func PublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves host, a host name or IP address, with resolver, and
+// returns an error wrapping ErrPrivateHost unless every address it resolves
+// to is public. A host that does not resolve is not public either.
+func CheckHost(ctx context.Context, resolver *net.Resolver, host string) error {
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.Wrapf(ErrPrivateHost, "failed to CheckHost/LookupIPAddr\thost=%s\terr=%v", host, err)
+	}
+	for _, addr := range addrs {
+		if !PublicIP(addr.IP) {
+			return errors.Wrapf(ErrPrivateHost, "failed to CheckHost\thost=%s\taddr=%s", host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// NewClient returns an http.Client that delivers to webhooks, giving each
+// request timeout to complete. The client connects to public addresses
+// only, checked as each connection is made, so that neither a host
+// resolving to a different address after its webhook was registered nor a
+// redirect reaches an internal service. Proxies are not used.
+func NewClient(timeout time.Duration) *http.Client {
+	var dialer = &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return errors.Wrapf(err, "failed to NewClient/SplitHostPort\taddress=%s", address)
+			}
+			if ip := net.ParseIP(host); ip == nil || !PublicIP(ip) {
+				return errors.Wrapf(ErrPrivateHost, "failed to NewClient/Dial\taddress=%s", address)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		Name     string
+		IP       string
+		Expected bool
+	}{
+		{Name: "public IPv4", IP: "93.184.216.34", Expected: true},
+		{Name: "public IPv6", IP: "2606:2800:220:1:248:1893:25c8:1946", Expected: true},
+		{Name: "loopback", IP: "127.0.0.1"},
+		{Name: "loopback range", IP: "127.1.2.3"},
+		{Name: "unspecified", IP: "0.0.0.0"},
+		{Name: "private 10/8", IP: "10.0.0.1"},
+		{Name: "private 172.16/12", IP: "172.31.255.255"},
+		{Name: "172.32 is public", IP: "172.32.0.1", Expected: true},
+		{Name: "private 192.168/16", IP: "192.168.1.1"},
+		{Name: "carrier-grade NAT", IP: "100.64.0.1"},
+		{Name: "link-local", IP: "169.254.169.254"},
+		{Name: "IPv6 loopback", IP: "::1"},
+		{Name: "IPv6 unspecified", IP: "::"},
+		{Name: "IPv6 unique local", IP: "fd00::1"},
+		{Name: "IPv6 link-local", IP: "fe80::1"},
+		{Name: "IPv4-mapped loopback", IP: "::ffff:127.0.0.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			require.Equal(t, test.Expected, PublicIP(net.ParseIP(test.IP)))
+		})
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		Name string
+		Host string
+		Err  error
+	}{
+		{Name: "public address", Host: "93.184.216.34"},
+		{Name: "loopback address", Host: "127.0.0.1", Err: ErrPrivateHost},
+		{Name: "localhost", Host: "localhost", Err: ErrPrivateHost},
+		{Name: "unresolvable", Host: "webhook.invalid", Err: ErrPrivateHost},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var err = CheckHost(context.Background(), net.DefaultResolver, test.Host)
+			require.Equal(t, test.Err, errors.Cause(err))
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	var received bool
+	var ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer ts.Close()
+
+	// The test server listens on a loopback address.
+	resp, err := NewClient(time.Second).Get(ts.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), ErrPrivateHost.Error())
+	require.False(t, received)
+}
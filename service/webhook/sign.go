@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers of a delivery request.
+const (
+	// HeaderEventId carries the id of the outbox event delivered. An event
+	// may be delivered more than once, so receivers should discard events
+	// whose id they have already processed.
+	HeaderEventId = "Webhook-Event-Id"
+
+	// HeaderEventType carries the type of the outbox event delivered.
+	HeaderEventType = "Webhook-Event-Type"
+
+	// HeaderTimestamp carries the Unix time the request was signed at.
+	// Receivers should reject requests signed too long ago, to prevent
+	// replay.
+	HeaderTimestamp = "Webhook-Timestamp"
+
+	// HeaderSignature carries the signature of the request, as computed by
+	// Sign.
+	HeaderSignature = "Webhook-Signature"
+)
+
+// Sign returns the signature of a request with body, signed at timestamp with
+// secret: "sha256=" followed by the hex encoded HMAC-SHA256 of the timestamp
+// in decimal, a period, and the body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	var mac = hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of a request with body,
+// signed at timestamp with secret.
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
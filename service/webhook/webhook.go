@@ -0,0 +1,311 @@
+// Package webhook delivers domain events to partner-registered HTTP
+// endpoints. A Fanout, relayed outbox events, records a Delivery of each
+// event for every Webhook subscribed to its type; a Dispatcher then POSTs
+// due deliveries, signed with the webhook's secret, and retries failed
+// deliveries with exponential backoff until they are delivered or exhaust
+// their attempts and are dead-lettered.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound indicates the requested webhook resource does not exist.
+var ErrNotFound = errors.New("webhook: not found")
+
+type Execer interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+type QueryRower interface {
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+type Queryer interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}
+
+// Webhook is a subscription of an HTTP endpoint to domain events.
+type Webhook struct {
+	Id  int    `json:"id"`
+	URL string `json:"url"`
+
+	// Secret is the key deliveries are signed with. It is never encoded, so
+	// that it is disclosed only when the webhook is created.
+	Secret string `json:"-"`
+
+	// EventTypes are the event types delivered to the webhook. If empty,
+	// every event is delivered.
+	EventTypes []string  `json:"eventTypes"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// NewSecret returns a random secret suitable for signing deliveries.
+func NewSecret() (string, error) {
+	var b = make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to NewSecret/Read")
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Create inserts wh into the db, and sets its Id and CreatedAt.
+func Create(ctx context.Context, db Execer, wh *Webhook) error {
+	var sql = `
+  INSERT INTO webhook (url, secret, event_types, created_at)
+  VALUES (?, ?, ?, ?)
+  `
+	var (
+		createdAt = time.Now().UTC().Truncate(time.Microsecond)
+		args      = []interface{}{wh.URL, wh.Secret, strings.Join(wh.EventTypes, ","), createdAt}
+	)
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to Create/ExecContext\tsql=%s\turl=%s", sql, wh.URL)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return errors.Wrapf(err, "failed to Create/LastInsertId\tsql=%s\turl=%s", sql, wh.URL)
+	}
+	wh.Id = int(id)
+	wh.CreatedAt = createdAt
+	return nil
+}
+
+// Webhooks retrieves every webhook from the db, oldest first.
+func Webhooks(ctx context.Context, db Queryer) ([]Webhook, error) {
+	var sql = `
+  SELECT
+    id,
+    url,
+    secret,
+    event_types,
+    created_at
+  FROM webhook
+  ORDER BY id
+  `
+
+	rows, err := db.QueryContext(ctx, sql)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to Webhooks/QueryContext\tsql=%s", sql)
+	}
+	defer rows.Close()
+
+	var webhooks = make([]Webhook, 0)
+	for rows.Next() {
+		var (
+			wh         Webhook
+			eventTypes string
+			createdAt  mysql.NullTime
+		)
+		if err := rows.Scan(&wh.Id, &wh.URL, &wh.Secret, &eventTypes, &createdAt); err != nil {
+			return nil, errors.Wrapf(err, "failed to Webhooks/Scan\tsql=%s", sql)
+		}
+		wh.EventTypes = splitEventTypes(eventTypes)
+		wh.CreatedAt = createdAt.Time
+		webhooks = append(webhooks, wh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to Webhooks/Err\tsql=%s", sql)
+	}
+	return webhooks, nil
+}
+
+// Delete removes the webhook id, and its deliveries, from the db. If the
+// webhook does not exist, an error wrapping ErrNotFound is returned.
+func Delete(ctx context.Context, db Execer, id int) error {
+	var sql = `
+  DELETE FROM webhook
+  WHERE id = ?
+  `
+	res, err := db.ExecContext(ctx, sql, id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to Delete/ExecContext\tsql=%s\tid=%v", sql, id)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to Delete/RowsAffected\tsql=%s\tid=%v", sql, id)
+	}
+	if n == 0 {
+		return errors.Wrapf(ErrNotFound, "failed to Delete\tid=%v", id)
+	}
+	return nil
+}
+
+// Exists reports whether the webhook id exists in the db.
+func Exists(ctx context.Context, db QueryRower, id int) (bool, error) {
+	var sql = `
+  SELECT EXISTS (
+    SELECT 1
+    FROM webhook
+    WHERE id = ?
+  )
+  `
+	var exists bool
+	if err := db.QueryRowContext(ctx, sql, id).Scan(&exists); err != nil {
+		return false, errors.Wrapf(err, "failed to Exists\tsql=%s\tid=%v", sql, id)
+	}
+	return exists, nil
+}
+
+// splitEventTypes parses the comma separated event_types column.
+func splitEventTypes(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// Statuses of a Delivery.
+const (
+	// StatusPending deliveries are awaiting their next attempt.
+	StatusPending = "pending"
+
+	// StatusDelivered deliveries were accepted by the webhook.
+	StatusDelivered = "delivered"
+
+	// StatusDead deliveries exhausted their attempts without being accepted.
+	// Dead deliveries are not retried unless redelivered.
+	StatusDead = "dead"
+)
+
+// Delivery is the delivery of an outbox event to a webhook.
+type Delivery struct {
+	Id        int64  `json:"id"`
+	WebhookId int    `json:"webhookId"`
+	EventId   int64  `json:"eventId"`
+	EventType string `json:"eventType"`
+
+	// Payload is the request body POSTed to the webhook.
+	Payload json.RawMessage `json:"payload"`
+
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+
+	// NextAttemptAt is when a pending delivery is next attempted.
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+
+	// LastStatusCode and LastError describe the outcome of the most recent
+	// attempt. LastStatusCode is zero if no response was received.
+	LastStatusCode int    `json:"lastStatusCode,omitempty"`
+	LastError      string `json:"lastError,omitempty"`
+
+	CreatedAt   time.Time  `json:"createdAt"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// Deliveries retrieves at most limit deliveries from the db, newest first.
+// Only deliveries to webhookId are retrieved, unless webhookId is zero; and
+// only deliveries with status, unless status is empty.
+func Deliveries(ctx context.Context, db Queryer, webhookId int, status string, limit int) ([]Delivery, error) {
+	var sql = `
+  SELECT
+    id,
+    webhook_id,
+    event_id,
+    event_type,
+    payload,
+    status,
+    attempts,
+    next_attempt_at,
+    last_status_code,
+    last_error,
+    created_at,
+    delivered_at
+  FROM webhook_delivery
+  WHERE (? = 0 OR webhook_id = ?)
+        AND (? = '' OR status = ?)
+  ORDER BY id DESC
+  LIMIT ?
+  `
+	var args = []interface{}{webhookId, webhookId, status, status, limit}
+
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to Deliveries/QueryContext\tsql=%s\targs=%v", sql, args)
+	}
+	defer rows.Close()
+
+	var deliveries = make([]Delivery, 0)
+	for rows.Next() {
+		var (
+			d              Delivery
+			payload        string
+			nextAttemptAt  mysql.NullTime
+			lastStatusCode *int
+			lastError      *string
+			createdAt      mysql.NullTime
+			deliveredAt    mysql.NullTime
+		)
+		if err := rows.Scan(
+			&d.Id,
+			&d.WebhookId,
+			&d.EventId,
+			&d.EventType,
+			&payload,
+			&d.Status,
+			&d.Attempts,
+			&nextAttemptAt,
+			&lastStatusCode,
+			&lastError,
+			&createdAt,
+			&deliveredAt,
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to Deliveries/Scan\tsql=%s\targs=%v", sql, args)
+		}
+		d.Payload = json.RawMessage(payload)
+		d.NextAttemptAt = nextAttemptAt.Time
+		if lastStatusCode != nil {
+			d.LastStatusCode = *lastStatusCode
+		}
+		if lastError != nil {
+			d.LastError = *lastError
+		}
+		d.CreatedAt = createdAt.Time
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to Deliveries/Err\tsql=%s\targs=%v", sql, args)
+	}
+	return deliveries, nil
+}
+
+// Redeliver schedules the delivery id to webhookId to be attempted again
+// immediately, with a fresh allowance of attempts, whatever its status. If
+// the delivery does not exist, an error wrapping ErrNotFound is returned.
+func Redeliver(ctx context.Context, db Execer, webhookId int, id int64) error {
+	var sql = `
+  UPDATE webhook_delivery
+  SET status = ?,
+      attempts = 0,
+      next_attempt_at = UTC_TIMESTAMP(6),
+      delivered_at = NULL
+  WHERE id = ?
+        AND webhook_id = ?
+  `
+	var args = []interface{}{StatusPending, id, webhookId}
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to Redeliver/ExecContext\tsql=%s\targs=%v", sql, args)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to Redeliver/RowsAffected\tsql=%s\targs=%v", sql, args)
+	}
+	if n == 0 {
+		return errors.Wrapf(ErrNotFound, "failed to Redeliver\targs=%v", args)
+	}
+	return nil
+}
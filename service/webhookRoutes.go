@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/tjper/shoppingcart-server/service/validate"
+	"github.com/tjper/shoppingcart-server/service/webhook"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultDeliveriesLimit and maxDeliveriesLimit bound the number of
+	// webhook deliveries listed per request.
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// WebhookRoutes defines the REST endpoints that manage webhooks, and inspect
+// and retry their deliveries. Deliveries are POSTed with the headers
+// documented in package webhook, and signed with the secret returned when
+// the webhook is created. Deliveries carry the events of every user, so the
+// endpoints are restricted to admins.
+func (svc *Service) WebhookRoutes(r chi.Router) {
+	r.Use(svc.requireAdmin)
+	r.Post("/webhooks", svc.PostWebhookHandler())
+	r.Get("/webhooks", svc.GetWebhooksHandler())
+	r.Get("/webhooks/dead-letters", svc.GetWebhookDeadLettersHandler())
+	r.Delete("/webhooks/{id}", svc.DeleteWebhookHandler())
+	r.Get("/webhooks/{id}/deliveries", svc.GetWebhookDeliveriesHandler())
+	r.Post("/webhooks/{id}/deliveries/{deliveryId}/redeliver", svc.RedeliverWebhookHandler())
+}
+
+// PostWebhookHandler registers a webhook.
+func (svc *Service) PostWebhookHandler() http.HandlerFunc {
+	type (
+		Request struct {
+			URL        string   `json:"url" validate:"required,max=2048,regex=^https?://\\S+$"`
+			EventTypes []string `json:"eventTypes"`
+
+			// Secret is generated if empty.
+			Secret string `json:"secret"`
+		}
+		Webhook struct {
+			webhook.Webhook
+			Secret string `json:"secret"`
+		}
+		Response struct {
+			Webhook Webhook `json:"webhook"`
+		}
+	)
+	return describe(operation{
+		Id:       "PostWebhookHandler",
+		Summary:  "Register a webhook for events of the given types, or of every type if none are given. The URL's host must resolve to public addresses only. The secret deliveries are signed with is only returned here.",
+		Request:  Request{},
+		Response: Response{},
+		Status:   http.StatusCreated,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = r.Context()
+			req Request
+		)
+		if err := decode(r, &req); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var v = new(validate.Validator)
+		v.Merge("", validate.Struct(req))
+		for i, typ := range req.EventTypes {
+			v.Check(fmt.Sprintf("eventTypes[%d]", i), validate.OneOf(typ, webhookEventTypes...))
+		}
+		if req.Secret != "" {
+			v.Check("secret", validate.MinLen(req.Secret, 16), validate.MaxLen(req.Secret, 128))
+		}
+		if err := v.Err(); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		// The URL's host is resolved only once the URL is known to be
+		// well-formed.
+		if !svc.Viper.GetBool(EnvVarWebhookAllowPrivateHosts) {
+			v.Check("url", publicHost(ctx, req.URL))
+			if err := v.Err(); err != nil {
+				svc.HandleError(w, err)
+				return
+			}
+		}
+
+		if req.Secret == "" {
+			secret, err := webhook.NewSecret()
+			if err != nil {
+				svc.HandleError(w, err)
+				return
+			}
+			req.Secret = secret
+		}
+		if req.EventTypes == nil {
+			req.EventTypes = []string{}
+		}
+
+		var wh = webhook.Webhook{
+			URL:        req.URL,
+			Secret:     req.Secret,
+			EventTypes: req.EventTypes,
+		}
+		if err := webhook.Create(ctx, svc.DB, &wh); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		var resp = Response{
+			Webhook: Webhook{Webhook: wh, Secret: wh.Secret},
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// publicHost fails unless the host of the well-formed rawurl resolves to
+// public addresses only, so that deliveries do not reach internal services.
+// Deliveries are checked again as they are made; see webhook.NewClient.
+func publicHost(ctx context.Context, rawurl string) validate.Check {
+	return func() string {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return "must be a valid URL"
+		}
+		if err := webhook.CheckHost(ctx, net.DefaultResolver, u.Hostname()); err != nil {
+			return "must resolve to public addresses only"
+		}
+		return ""
+	}
+}
+
+// GetWebhooksHandler retrieves every webhook.
+func (svc *Service) GetWebhooksHandler() http.HandlerFunc {
+	type Response struct {
+		Webhooks []webhook.Webhook `json:"webhooks"`
+	}
+	return describe(operation{
+		Id:       "GetWebhooksHandler",
+		Summary:  "Retrieve all webhooks.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		webhooks, err := webhook.Webhooks(ctx, svc.DB)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Webhooks: webhooks,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// DeleteWebhookHandler removes a webhook and its delivery history.
+func (svc *Service) DeleteWebhookHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "DeleteWebhookHandler",
+		Summary: "Remove a webhook and its delivery history.",
+		Status:  http.StatusNoContent,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		if err := webhook.Delete(ctx, svc.DB, id); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// GetWebhookDeliveriesHandler retrieves the delivery history of a webhook.
+func (svc *Service) GetWebhookDeliveriesHandler() http.HandlerFunc {
+	type Response struct {
+		Deliveries []webhook.Delivery `json:"deliveries"`
+	}
+	return describe(operation{
+		Id:       "GetWebhookDeliveriesHandler",
+		Summary:  "Retrieve a webhook's deliveries, newest first. The status query parameter filters by pending, delivered or dead; the limit query parameter bounds the number retrieved.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		limit, err := queryLimit(r, defaultDeliveriesLimit, maxDeliveriesLimit)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		var status = r.URL.Query().Get("status")
+		if status != "" {
+			var v = new(validate.Validator)
+			v.Check("status", validate.OneOf(status, webhook.StatusPending, webhook.StatusDelivered, webhook.StatusDead))
+			if err := v.Err(); err != nil {
+				svc.HandleError(w, err)
+				return
+			}
+		}
+
+		exists, err := webhook.Exists(ctx, svc.DB, id)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		if !exists {
+			svc.HandleError(w, errors.Wrapf(webhook.ErrNotFound, "failed to GetWebhookDeliveries\tid=%v", id))
+			return
+		}
+
+		deliveries, err := webhook.Deliveries(ctx, svc.DB, id, status, limit)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Deliveries: deliveries,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// GetWebhookDeadLettersHandler retrieves the deliveries of every webhook that
+// exhausted their attempts.
+func (svc *Service) GetWebhookDeadLettersHandler() http.HandlerFunc {
+	type Response struct {
+		Deliveries []webhook.Delivery `json:"deliveries"`
+	}
+	return describe(operation{
+		Id:       "GetWebhookDeadLettersHandler",
+		Summary:  "Retrieve the deliveries of all webhooks that exhausted their attempts, newest first. The limit query parameter bounds the number retrieved.",
+		Response: Response{},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		limit, err := queryLimit(r, defaultDeliveriesLimit, maxDeliveriesLimit)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		deliveries, err := webhook.Deliveries(ctx, svc.DB, 0, webhook.StatusDead, limit)
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+
+		var resp = Response{
+			Deliveries: deliveries,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			svc.HandleError(w, err)
+		}
+	})
+}
+
+// RedeliverWebhookHandler schedules a delivery to be attempted again.
+func (svc *Service) RedeliverWebhookHandler() http.HandlerFunc {
+	return describe(operation{
+		Id:      "RedeliverWebhookHandler",
+		Summary: "Attempt a delivery again immediately, with a fresh allowance of attempts, whether it was delivered, dead-lettered or is pending.",
+		Status:  http.StatusAccepted,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+
+		id, err := urlParamId(r, "id")
+		if err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		var param = chi.URLParam(r, "deliveryId")
+		deliveryId, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || deliveryId == 0 {
+			svc.HandleError(w, errors.Wrapf(errMalformed, "failed to RedeliverWebhook\tdeliveryId=%s", param))
+			return
+		}
+
+		if err := webhook.Redeliver(ctx, svc.DB, id, deliveryId); err != nil {
+			svc.HandleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRoutesInvalid(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		Body   string
+		Status int
+		Fields []string
+	}{
+		{Name: "malformed", Method: http.MethodPost, Path: "/v1/webhooks", Body: `{`, Status: http.StatusBadRequest},
+		{Name: "missing url", Method: http.MethodPost, Path: "/v1/webhooks", Body: `{}`, Status: http.StatusUnprocessableEntity, Fields: []string{"url"}},
+		{
+			Name:   "invalid fields",
+			Method: http.MethodPost,
+			Path:   "/v2/webhooks",
+			Body:   `{"url": "ftp://example.com", "eventTypes": ["CartItemAdded", "ItemDeleted"], "secret": "short"}`,
+			Status: http.StatusUnprocessableEntity,
+			Fields: []string{"url", "eventTypes[1]", "secret"},
+		},
+		{
+			Name:   "catalog event types",
+			Method: http.MethodPost,
+			Path:   "/v1/webhooks",
+			Body:   `{"url": "ftp://example.com", "eventTypes": ["ItemCreated", "ItemPriceScheduled", "CategoryCreated"]}`,
+			Status: http.StatusUnprocessableEntity,
+			Fields: []string{"url"},
+		},
+		{
+			Name:   "loopback url",
+			Method: http.MethodPost,
+			Path:   "/v1/webhooks",
+			Body:   `{"url": "http://127.0.0.1:8080/hook"}`,
+			Status: http.StatusUnprocessableEntity,
+			Fields: []string{"url"},
+		},
+		{
+			Name:   "link-local url",
+			Method: http.MethodPost,
+			Path:   "/v1/webhooks",
+			Body:   `{"url": "http://169.254.169.254/latest/meta-data"}`,
+			Status: http.StatusUnprocessableEntity,
+			Fields: []string{"url"},
+		},
+		{
+			Name:   "private url",
+			Method: http.MethodPost,
+			Path:   "/v2/webhooks",
+			Body:   `{"url": "https://[fd00::1]/hook"}`,
+			Status: http.StatusUnprocessableEntity,
+			Fields: []string{"url"},
+		},
+		{Name: "delete malformed id", Method: http.MethodDelete, Path: "/v1/webhooks/abc", Status: http.StatusBadRequest},
+		{Name: "deliveries malformed limit", Method: http.MethodGet, Path: "/v1/webhooks/1/deliveries?limit=0", Status: http.StatusBadRequest},
+		{Name: "deliveries invalid status", Method: http.MethodGet, Path: "/v1/webhooks/1/deliveries?status=lost", Status: http.StatusUnprocessableEntity, Fields: []string{"status"}},
+		{Name: "dead letters malformed limit", Method: http.MethodGet, Path: "/v1/webhooks/dead-letters?limit=abc", Status: http.StatusBadRequest},
+		{Name: "redeliver malformed id", Method: http.MethodPost, Path: "/v2/webhooks/1/deliveries/abc/redeliver", Status: http.StatusBadRequest},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = asAdmin(httptest.NewRequest(test.Method, test.Path, strings.NewReader(test.Body)))
+			)
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+
+			var resp ErrorResponse
+			require.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			var fields []string
+			for _, fe := range resp.Error.Fields {
+				fields = append(fields, fe.Field)
+			}
+			require.Equal(t, test.Fields, fields)
+		})
+	}
+}
+
+func TestWebhookRoutesAdmin(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		UserId string
+		Roles  string
+		Status int
+	}{
+		{Name: "register unauthenticated", Method: http.MethodPost, Path: "/v1/webhooks", Status: http.StatusUnauthorized},
+		{Name: "register not admin", Method: http.MethodPost, Path: "/v1/webhooks", UserId: "2", Status: http.StatusForbidden},
+		{Name: "list other roles", Method: http.MethodGet, Path: "/v2/webhooks", UserId: "2", Roles: "support, auditor", Status: http.StatusForbidden},
+		{Name: "dead letters unauthenticated", Method: http.MethodGet, Path: "/v1/webhooks/dead-letters", Roles: roleAdmin, Status: http.StatusUnauthorized},
+		{Name: "delete not admin", Method: http.MethodDelete, Path: "/v1/webhooks/1", UserId: "2", Status: http.StatusForbidden},
+		{Name: "deliveries not admin", Method: http.MethodGet, Path: "/v1/webhooks/1/deliveries", UserId: "2", Status: http.StatusForbidden},
+		{Name: "redeliver not admin", Method: http.MethodPost, Path: "/v2/webhooks/1/deliveries/1/redeliver", UserId: "2", Status: http.StatusForbidden},
+	}
+
+	var svc = newTestService()
+	WithRouters(svc.Routes())(svc)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(test.Method, test.Path, strings.NewReader(`{"url": "https://example.com/hook"}`))
+			)
+			if test.UserId != "" {
+				r.Header.Set(headerUserId, test.UserId)
+			}
+			if test.Roles != "" {
+				r.Header.Set(headerUserRoles, test.Roles)
+			}
+			svc.Router.ServeHTTP(w, r)
+			require.Equal(t, test.Status, w.Code)
+		})
+	}
+}
+
+func TestQueryLimit(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Query    string
+		Expected int
+		Err      bool
+	}{
+		{Name: "default", Expected: 50},
+		{Name: "given", Query: "?limit=5", Expected: 5},
+		{Name: "capped", Query: "?limit=5000", Expected: 500},
+		{Name: "zero", Query: "?limit=0", Err: true},
+		{Name: "negative", Query: "?limit=-1", Err: true},
+		{Name: "non-numeric", Query: "?limit=ten", Err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var r = httptest.NewRequest(http.MethodGet, "/webhooks/dead-letters"+test.Query, nil)
+			limit, err := queryLimit(r, defaultDeliveriesLimit, maxDeliveriesLimit)
+			if test.Err {
+				require.Equal(t, http.StatusBadRequest, statusCode(err))
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, test.Expected, limit)
+		})
+	}
+}
@@ -2,8 +2,13 @@ package testing
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
@@ -27,7 +32,13 @@ func newInject(t *testing.T) *inject {
 	dbport, remove := newDb(t)
 	i.Closers = append(i.Closers, remove)
 
-	i.Svc = newService(dbport)
+	blobDir, err := ioutil.TempDir("", "blobs")
+	assert.Nil(t, err)
+	i.Closers = append(i.Closers, func(t *testing.T) {
+		assert.Nil(t, os.RemoveAll(blobDir))
+	})
+
+	i.Svc = newService(dbport, blobDir)
 
 	return i
 }
@@ -39,23 +50,44 @@ func (i inject) Close(t *testing.T) {
 	}
 }
 
-// newService returns an initialized service.
-func newService(dbport string) *service.Service {
+// newService returns an initialized service, storing blobs in blobDir.
+func newService(dbport, blobDir string) *service.Service {
 	var connStr = fmt.Sprintf(
-		"admin:password@tcp(localhost:%s)/shoppingcart-db?tls=false&timeout=30s",
+		"admin:password@tcp(localhost:%s)/shoppingcart-db?tls=false&timeout=30s&multiStatements=true",
 		dbport)
 
 	var v = service.ViperDefaults(viper.New())
 	v.Set(service.EnvVarDbConnStr, connStr)
+	v.Set(service.EnvVarBlobDir, blobDir)
 
-	var svc = service.New(v, service.WithDB(), service.WithZap())
-	service.WithRouters(
-		svc.CartRoutes,
-		svc.ItemRoutes,
-	)(svc)
+	var svc = service.New(v, service.WithDB(), service.WithZap(), service.WithHub(), service.WithBlobStore())
+	service.WithRouters(svc.Routes())(svc)
+	migrate(svc.DB)
 	return svc
 }
 
+// migrationsDir holds the SQL migrations applied on top of the
+// shoppingcart-db image's schema, in file name order.
+const migrationsDir = "../db/migrations"
+
+// migrate applies the SQL migrations in migrationsDir to db.
+func migrate(db *sql.DB) {
+	paths, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	if err != nil {
+		panic(err)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		stmts, err := ioutil.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		if _, err := db.Exec(string(stmts)); err != nil {
+			panic(fmt.Sprintf("failed to migrate\tpath=%s\terr=%v", path, err))
+		}
+	}
+}
+
 // newDb creates and starts a shoppingcart-db docker container and returns a
 // cleanup function to be called to stop and remove said container as the 2nd
 // return value. The first return value is the host's port for the db instance.
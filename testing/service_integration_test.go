@@ -3,24 +3,63 @@
 package testing
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"image"
+	imagepng "image/png"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	"github.com/tjper/shoppingcart-server/service"
 	"github.com/tjper/shoppingcart-server/service/cart"
+	"github.com/tjper/shoppingcart-server/service/catalog"
+	"github.com/tjper/shoppingcart-server/service/idempotency"
+	"github.com/tjper/shoppingcart-server/service/item"
+	"github.com/tjper/shoppingcart-server/service/outbox"
+	"github.com/tjper/shoppingcart-server/service/webhook"
 	testutil "github.com/tjper/testing"
+	"go.uber.org/zap"
 )
 
 var golden = flag.Bool("golden", false, "overwrite the existing golden files")
 
+// asAdmin serves the admin and webhook requests of h as if the gateway had
+// authenticated them as those of an admin.
+func asAdmin(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/admin/") || strings.Contains(r.URL.Path, "/webhooks") {
+			r.Header.Set("X-User-Id", "1")
+			r.Header.Set("X-User-Roles", "admin")
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// createCartItem inserts rel into the db at the item's current price and
+// returns its id.
+func createCartItem(t *testing.T, i *inject, rel cart.UserCartItemRel) int {
+	found, err := item.FindItem(context.Background(), i.Svc.DB, rel.ItemId)
+	require.Nil(t, err)
+	id, err := cart.CreateUserCartItemRel(context.Background(), i.Svc.DB, rel, found.Price)
+	require.Nil(t, err)
+	return id
+}
+
 func TestGetItems(t *testing.T) {
 	t.Parallel()
 	i := newInject(t)
@@ -51,7 +90,7 @@ func TestPostCartItem(t *testing.T) {
 	var i = newInject(t)
 	defer i.Close(t)
 
-	var ts = httptest.NewServer(i.Svc.PostCartItemHandler())
+	var ts = httptest.NewServer(i.Svc.AddCartItemHandler())
 	defer ts.Close()
 
 	tests := []struct {
@@ -122,11 +161,7 @@ func TestGetCart(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
 			for _, rel := range test.Rels {
-				_, err := cart.CreateUserCartItemRel(
-					context.Background(),
-					i.Svc.DB,
-					rel)
-				require.Nil(t, err)
+				createCartItem(t, i, rel)
 			}
 
 			var (
@@ -172,11 +207,7 @@ func TestPutCartItem(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			id, err := cart.CreateUserCartItemRel(
-				context.Background(),
-				i.Svc.DB,
-				test.Rel)
-			require.Nil(t, err)
+			var id = createCartItem(t, i, test.Rel)
 
 			var (
 				idStr = strconv.Itoa(id)
@@ -220,8 +251,7 @@ func TestDeleteItem(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			id, err := cart.CreateUserCartItemRel(context.Background(), i.Svc.DB, test.Rel)
-			require.Nil(t, err)
+			var id = createCartItem(t, i, test.Rel)
 
 			var (
 				idStr = strconv.Itoa(id)
@@ -238,3 +268,1534 @@ func TestDeleteItem(t *testing.T) {
 		})
 	}
 }
+
+func TestCartItemNotFound(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	tests := []struct {
+		Name    string
+		Method  string
+		Handler http.HandlerFunc
+		Body    io.Reader
+	}{
+		{
+			Name:    "PUT",
+			Method:  http.MethodPut,
+			Handler: i.Svc.PutCartItemHandler(),
+			Body:    strings.NewReader(`{"itemId": 1, "userId": 1, "count": 6}`),
+		},
+		{
+			Name:    "DELETE",
+			Method:  http.MethodDelete,
+			Handler: i.Svc.DeleteCartItemHandler(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			const idStr = "999999"
+			var (
+				w = httptest.NewRecorder()
+				r = httptest.NewRequest(test.Method, "/cart/item/"+idStr, test.Body)
+			)
+
+			ctx := chi.NewRouteContext()
+			ctx.URLParams.Add("id", idStr)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, ctx))
+
+			test.Handler(w, r)
+			require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestPostCartItemInvalidItem(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(i.Svc.AddCartItemHandler())
+	defer ts.Close()
+
+	resp, err := http.Post(
+		ts.URL,
+		"application/json",
+		strings.NewReader(`{"itemId": 999999, "userId": 1, "count": 1}`))
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+func TestCartSession(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	type message struct {
+		Type     string `json:"type"`
+		Id       string `json:"id"`
+		Revision uint64 `json:"revision"`
+		Cart     struct {
+			CartItems []cart.CartItem `json:"cartItems"`
+		} `json:"cart"`
+		CartItem cart.CartItem `json:"cartItem"`
+	}
+	var url = "ws" + strings.TrimPrefix(ts.URL, "http") + "/v1/cart/2/ws"
+	var dial = func() *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-User-Id": {"2"}})
+		require.Nil(t, err)
+
+		var msg message
+		require.Nil(t, conn.ReadJSON(&msg))
+		require.Equal(t, "state", msg.Type)
+		return conn
+	}
+	var a, b = dial(), dial()
+	defer a.Close()
+	defer b.Close()
+
+	require.Nil(t, a.WriteJSON(map[string]interface{}{"id": "1", "op": "add", "itemId": 1, "count": 2}))
+
+	// Both participants are sent the resulting state; the participant that
+	// made the change is also sent an ack.
+	for _, conn := range []*websocket.Conn{a, b} {
+		for {
+			var msg message
+			require.Nil(t, conn.ReadJSON(&msg))
+			if msg.Type == "ack" {
+				require.Equal(t, "1", msg.Id)
+				require.Equal(t, 2, msg.CartItem.Count)
+				continue
+			}
+			require.Equal(t, "state", msg.Type)
+			require.Len(t, msg.Cart.CartItems, 1)
+			require.Equal(t, 2, msg.Cart.CartItems[0].Count)
+			break
+		}
+	}
+
+	// A session may not be opened on another user's cart.
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"X-User-Id": {"3"}})
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestOutbox(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(i.Svc.AddCartItemHandler())
+	defer ts.Close()
+
+	for _, body := range []string{
+		`{"itemId": 1, "userId": 1, "count": 1}`,
+		// Rejected changes record no events.
+		`{"itemId": 999999, "userId": 1, "count": 1}`,
+		`{"itemId": 1, "userId": 1, "count": 2}`,
+	} {
+		resp, err := http.Post(ts.URL, "application/json", strings.NewReader(body))
+		require.Nil(t, err)
+		resp.Body.Close()
+	}
+
+	var events []outbox.Event
+	var sink = outbox.SinkFunc(func(ctx context.Context, ev outbox.Event) error {
+		events = append(events, ev)
+		return nil
+	})
+	var relay = outbox.NewRelay(outbox.SQLStore{DB: i.Svc.DB}, []outbox.Sink{sink}, 10, time.Second, 10, zap.NewNop())
+
+	n, err := relay.Relay(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, cart.EventCartItemAdded, events[0].Type)
+	require.Equal(t, cart.EventCartItemUpdated, events[1].Type)
+
+	var payload cart.CartItemEvent
+	require.Nil(t, json.Unmarshal(events[1].Payload, &payload))
+	require.Equal(t, 1, payload.UserId)
+	require.Equal(t, 3, payload.CartItem.Count)
+
+	// Delivered events are not relayed again.
+	n, err = relay.Relay(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 0, n)
+
+	// Delivered events are purged once past their retention.
+	n, err = i.Svc.PurgeOutbox(context.Background(), time.Hour)
+	require.Nil(t, err)
+	require.Equal(t, 0, n)
+	n, err = i.Svc.PurgeOutbox(context.Background(), 0)
+	require.Nil(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestWebhooks(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	// The receiver accepts deliveries until failing is set.
+	var (
+		received = make(chan string, 10)
+		failing  int32
+		secret   = "0123456789abcdef"
+	)
+	var receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.Nil(t, err)
+		timestamp, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		require.Nil(t, err)
+		require.True(t, webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), timestamp, body))
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received <- r.Header.Get(webhook.HeaderEventType)
+	}))
+	defer receiver.Close()
+
+	// The receiver listens on a loopback address, which webhooks may only
+	// be registered with if private hosts are allowed.
+	var body = `{"url": "` + receiver.URL + `", "eventTypes": ["CartItemAdded"], "secret": "` + secret + `"}`
+	resp, err := http.Post(ts.URL+"/v1/webhooks", "application/json", strings.NewReader(body))
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	i.Svc.Viper.Set(service.EnvVarWebhookAllowPrivateHosts, true)
+	resp, err = http.Post(ts.URL+"/v1/webhooks", "application/json", strings.NewReader(body))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		Webhook struct {
+			Id     int    `json:"id"`
+			Secret string `json:"secret"`
+		} `json:"webhook"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Equal(t, secret, created.Webhook.Secret)
+
+	var (
+		relay = outbox.NewRelay(outbox.SQLStore{DB: i.Svc.DB}, []outbox.Sink{webhook.Fanout{DB: i.Svc.DB}}, 10, time.Second, 10, zap.NewNop())
+		d     = webhook.NewDispatcher(webhook.SQLStore{DB: i.Svc.DB}, receiver.Client(), webhook.Backoff{}, 1, time.Second, 10, zap.NewNop())
+		ctx   = context.Background()
+	)
+	var addCartItem = func() {
+		resp, err := http.Post(ts.URL+"/v1/cart/item", "application/json", strings.NewReader(`{"itemId": 1, "userId": 1, "count": 1}`))
+		require.Nil(t, err)
+		resp.Body.Close()
+		_, err = relay.Relay(ctx)
+		require.Nil(t, err)
+	}
+
+	// Only subscribed event types are delivered: the second add is an update.
+	addCartItem()
+	addCartItem()
+	n, err := d.Dispatch(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, cart.EventCartItemAdded, <-received)
+
+	var deliveries struct {
+		Deliveries []webhook.Delivery `json:"deliveries"`
+	}
+	var getDeliveries = func(path string) {
+		resp, err := http.Get(ts.URL + path)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&deliveries))
+	}
+	var deliveriesPath = "/v1/webhooks/" + strconv.Itoa(created.Webhook.Id) + "/deliveries"
+	getDeliveries(deliveriesPath)
+	require.Len(t, deliveries.Deliveries, 1)
+	require.Equal(t, webhook.StatusDelivered, deliveries.Deliveries[0].Status)
+	require.Equal(t, http.StatusOK, deliveries.Deliveries[0].LastStatusCode)
+
+	// A delivery that exhausts its attempts is dead-lettered until it is
+	// redelivered.
+	atomic.StoreInt32(&failing, 1)
+	resp, err = http.Post(ts.URL+"/v1/cart/item", "application/json", strings.NewReader(`{"itemId": 2, "userId": 1, "count": 1}`))
+	require.Nil(t, err)
+	resp.Body.Close()
+	_, err = relay.Relay(ctx)
+	require.Nil(t, err)
+	n, err = d.Dispatch(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 1, n)
+
+	getDeliveries("/v1/webhooks/dead-letters")
+	require.Len(t, deliveries.Deliveries, 1)
+	var dead = deliveries.Deliveries[0]
+	require.Equal(t, webhook.StatusDead, dead.Status)
+	require.Equal(t, http.StatusServiceUnavailable, dead.LastStatusCode)
+
+	atomic.StoreInt32(&failing, 0)
+	resp, err = http.Post(ts.URL+deliveriesPath+"/"+strconv.FormatInt(dead.Id, 10)+"/redeliver", "application/json", nil)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	n, err = d.Dispatch(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, cart.EventCartItemAdded, <-received)
+
+	getDeliveries(deliveriesPath + "?status=delivered")
+	require.Len(t, deliveries.Deliveries, 2)
+
+	// Deleting a webhook removes its delivery history.
+	req, err := http.NewRequest(http.MethodDelete, ts.URL+"/v1/webhooks/"+strconv.Itoa(created.Webhook.Id), nil)
+	require.Nil(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Get(ts.URL + deliveriesPath)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAbandonedCarts(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var (
+		ctx     = context.Background()
+		addItem = func(userId int) {
+			resp, err := http.Post(ts.URL+"/v1/cart/item", "application/json",
+				strings.NewReader(`{"itemId": 1, "userId": `+strconv.Itoa(userId)+`, "count": 1}`))
+			require.Nil(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+		}
+		idleFor = func(userId int, d time.Duration) {
+			_, err := i.Svc.DB.Exec(
+				"UPDATE cart_activity SET modified_at = UTC_TIMESTAMP(6) - INTERVAL ? SECOND WHERE user_id = ?",
+				int(d.Seconds()), userId)
+			require.Nil(t, err)
+		}
+	)
+
+	addItem(1)
+	addItem(2)
+	idleFor(1, 48*time.Hour)
+
+	// Each idle period is reported once.
+	reported, err := i.Svc.DetectAbandonedCarts(ctx, 24*time.Hour)
+	require.Nil(t, err)
+	require.Len(t, reported, 1)
+	require.Equal(t, 1, reported[0].UserId)
+
+	reported, err = i.Svc.DetectAbandonedCarts(ctx, 24*time.Hour)
+	require.Nil(t, err)
+	require.Len(t, reported, 0)
+
+	// Modifying a cart begins a new idle period.
+	addItem(1)
+	idleFor(1, 48*time.Hour)
+	reported, err = i.Svc.DetectAbandonedCarts(ctx, 24*time.Hour)
+	require.Nil(t, err)
+	require.Len(t, reported, 1)
+
+	var events []outbox.Event
+	var sink = outbox.SinkFunc(func(ctx context.Context, ev outbox.Event) error {
+		if ev.Type == cart.EventAbandonedCart {
+			events = append(events, ev)
+		}
+		return nil
+	})
+	_, err = outbox.NewRelay(outbox.SQLStore{DB: i.Svc.DB}, []outbox.Sink{sink}, 10, time.Second, 100, zap.NewNop()).Relay(ctx)
+	require.Nil(t, err)
+	require.Len(t, events, 2)
+	var payload cart.AbandonedCartEvent
+	require.Nil(t, json.Unmarshal(events[1].Payload, &payload))
+	require.Equal(t, 1, payload.UserId)
+	require.Equal(t, 2, payload.CartItems[0].Count)
+
+	idleFor(2, 48*time.Hour)
+	resp, err := http.Get(ts.URL + "/v2/admin/carts/abandoned?since=24h&limit=1")
+	require.Nil(t, err)
+	var page struct {
+		Carts     []cart.IdleCart `json:"carts"`
+		NextAfter int             `json:"nextAfter"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&page))
+	resp.Body.Close()
+	require.Len(t, page.Carts, 1)
+	require.Equal(t, 1, page.Carts[0].UserId)
+	require.NotNil(t, page.Carts[0].AbandonedAt)
+	require.Equal(t, 1, page.NextAfter)
+
+	resp, err = http.Get(ts.URL + "/v2/admin/carts/abandoned?since=24h&limit=1&after=1")
+	require.Nil(t, err)
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&page))
+	resp.Body.Close()
+	require.Len(t, page.Carts, 1)
+	require.Equal(t, 2, page.Carts[0].UserId)
+	require.Nil(t, page.Carts[0].AbandonedAt)
+}
+
+func TestPurgeExpiredCarts(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	// Purge a line per transaction, so that carts are purged in batches.
+	i.Svc.Viper.Set(service.EnvVarCartPurgeBatchSize, 1)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var ctx = context.Background()
+	for _, body := range []string{
+		`{"itemId": 1, "userId": 1, "count": 1}`,
+		`{"itemId": 2, "userId": 1, "count": 1}`,
+		`{"itemId": 1, "userId": 2, "count": 1}`,
+	} {
+		resp, err := http.Post(ts.URL+"/v1/cart/item", "application/json", strings.NewReader(body))
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	// Guest carts expire sooner than user carts.
+	var r, _ = http.NewRequest(http.MethodPost, ts.URL+"/v1/cart/item", strings.NewReader(`{"itemId": 1, "userId": 3, "count": 1}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-User-Id", "3")
+	r.Header.Set("X-User-Roles", "guest")
+	resp, err := http.DefaultClient.Do(r)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	_, err = i.Svc.DB.Exec("UPDATE cart_activity SET modified_at = UTC_TIMESTAMP(6) - INTERVAL 31 DAY WHERE user_id = 1")
+	require.Nil(t, err)
+	_, err = i.Svc.DB.Exec("UPDATE cart_activity SET modified_at = UTC_TIMESTAMP(6) - INTERVAL 8 DAY WHERE user_id IN (2, 3)")
+	require.Nil(t, err)
+
+	carts, lines, err := i.Svc.PurgeExpiredCarts(ctx, 30*24*time.Hour, 7*24*time.Hour)
+	require.Nil(t, err)
+	require.Equal(t, 2, carts)
+	require.Equal(t, 3, lines)
+
+	cartItems, err := cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 0)
+	cartItems, err = cart.CartItems(ctx, i.Svc.DB, 2)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 1)
+	cartItems, err = cart.CartItems(ctx, i.Svc.DB, 3)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 0)
+
+	var cleared []cart.CartClearedEvent
+	var sink = outbox.SinkFunc(func(ctx context.Context, ev outbox.Event) error {
+		if ev.Type == cart.EventCartCleared {
+			var payload cart.CartClearedEvent
+			require.Nil(t, json.Unmarshal(ev.Payload, &payload))
+			cleared = append(cleared, payload)
+		}
+		return nil
+	})
+	_, err = outbox.NewRelay(outbox.SQLStore{DB: i.Svc.DB}, []outbox.Sink{sink}, 10, time.Second, 100, zap.NewNop()).Relay(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []cart.CartClearedEvent{{UserId: 1, Reason: cart.ClearedExpired}, {UserId: 3, Reason: cart.ClearedExpired}}, cleared)
+
+	// Purged carts are not purged again.
+	carts, lines, err = i.Svc.PurgeExpiredCarts(ctx, 30*24*time.Hour, 7*24*time.Hour)
+	require.Nil(t, err)
+	require.Equal(t, 0, carts)
+	require.Equal(t, 0, lines)
+}
+
+func TestCartHistory(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var do = func(method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.Nil(t, err)
+		req.Header.Set("X-User-Id", "1")
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		return resp
+	}
+
+	var resp = do(http.MethodPost, "/v1/cart/item", `{"itemId": 1, "userId": 1, "count": 2}`)
+	var added struct {
+		CartItem cart.CartItem `json:"cartItem"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&added))
+	resp.Body.Close()
+	var path = "/v1/cart/item/" + strconv.Itoa(added.CartItem.Id)
+	do(http.MethodPut, path, `{"itemId": 1, "userId": 1, "count": 5}`).Body.Close()
+	do(http.MethodDelete, path, "").Body.Close()
+
+	var history struct {
+		Entries []cart.AuditEntry `json:"entries"`
+	}
+	resp = do(http.MethodGet, "/v1/admin/cart/1/history", "")
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&history))
+	resp.Body.Close()
+	require.Len(t, history.Entries, 3)
+
+	var actions []string
+	for _, e := range history.Entries {
+		actions = append(actions, e.Action)
+		require.Equal(t, 1, e.ActorUserId)
+		require.Equal(t, "http", e.Source)
+		require.Equal(t, "203.0.113.0", e.RemoteAddr)
+		require.NotEmpty(t, e.RequestId)
+	}
+	require.Equal(t, []string{cart.AuditAdd, cart.AuditUpdate, cart.AuditRemove}, actions)
+	require.Equal(t, 2, *history.Entries[1].OldCount)
+	require.Equal(t, 5, *history.Entries[1].NewCount)
+
+	// Entries are filtered by time range.
+	var updatedAt = history.Entries[1].CreatedAt.Format(time.RFC3339Nano)
+	resp = do(http.MethodGet, "/v1/admin/cart/1/history?from="+updatedAt+"&to="+history.Entries[2].CreatedAt.Format(time.RFC3339Nano), "")
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&history))
+	resp.Body.Close()
+	require.Len(t, history.Entries, 1)
+	require.Equal(t, cart.AuditUpdate, history.Entries[0].Action)
+
+	var snapshot struct {
+		Lines []cart.AuditLine `json:"lines"`
+	}
+	resp = do(http.MethodGet, "/v1/admin/cart/1/snapshot?at="+updatedAt, "")
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&snapshot))
+	resp.Body.Close()
+	require.Equal(t, []cart.AuditLine{{CartItemId: added.CartItem.Id, ItemId: 1, Count: 5}}, snapshot.Lines)
+
+	resp = do(http.MethodGet, "/v1/admin/cart/1/snapshot", "")
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&snapshot))
+	resp.Body.Close()
+	require.Len(t, snapshot.Lines, 0)
+}
+
+func TestRestoreCartItem(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var do = func(method, path string) *http.Response {
+		r, err := http.NewRequest(method, ts.URL+path, nil)
+		require.Nil(t, err)
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err)
+		return resp
+	}
+
+	var ctx = context.Background()
+	var id = createCartItem(t, i, cart.UserCartItemRel{ItemId: 1, UserId: 1, Count: 2})
+	var path = "/v1/cart/item/" + strconv.Itoa(id)
+
+	// Deleted cart items are excluded from the cart.
+	resp := do(http.MethodDelete, path)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	cartItems, err := cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 0)
+	_, err = cart.FindCartItem(ctx, i.Svc.DB, id)
+	require.Equal(t, cart.ErrNotFound, errors.Cause(err))
+
+	resp = do(http.MethodPost, path+"/restore")
+	var restored struct {
+		CartItem cart.CartItem `json:"cartItem"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&restored))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, id, restored.CartItem.Id)
+	require.Equal(t, 2, restored.CartItem.Count)
+	cartItems, err = cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 1)
+
+	// Cart items that are not deleted cannot be restored.
+	resp = do(http.MethodPost, path+"/restore")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Deletions outside the undo window cannot be undone.
+	resp = do(http.MethodDelete, path)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = i.Svc.DB.Exec("UPDATE cart SET deleted_at = UTC_TIMESTAMP(6) - INTERVAL 1 HOUR WHERE id = ?", id)
+	require.Nil(t, err)
+	resp = do(http.MethodPost, path+"/restore")
+	resp.Body.Close()
+	require.Equal(t, http.StatusGone, resp.StatusCode)
+
+	n, err := i.Svc.PurgeTombstones(ctx, i.Svc.Viper.GetDuration(service.EnvVarCartUndoWindow))
+	require.Nil(t, err)
+	require.Equal(t, 1, n)
+	resp = do(http.MethodPost, path+"/restore")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCartETags(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var do = func(method, path, body string, header http.Header) *http.Response {
+		r, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.Nil(t, err)
+		for key := range header {
+			r.Header.Set(key, header.Get(key))
+		}
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err)
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/v1/cart/item", `{"itemId": 1, "userId": 1, "count": 1}`, nil)
+	var added struct {
+		CartItem cart.CartItem `json:"cartItem"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&added))
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var (
+		itemETag = resp.Header.Get("ETag")
+		path     = "/v1/cart/item/" + strconv.Itoa(added.CartItem.Id)
+	)
+	require.Equal(t, `"1"`, itemETag)
+
+	resp = do(http.MethodGet, "/v1/cart/1", "", nil)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var cartETag = resp.Header.Get("ETag")
+	require.NotEmpty(t, cartETag)
+
+	// Unchanged carts are not retransmitted.
+	resp = do(http.MethodGet, "/v1/cart/1", "", http.Header{"If-None-Match": {cartETag}})
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp = do(http.MethodPut, path, `{"itemId": 1, "userId": 1, "count": 3}`, http.Header{"If-Match": {itemETag}})
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+	// Modifications conditioned on a stale version are rejected.
+	resp = do(http.MethodPut, path, `{"itemId": 1, "userId": 1, "count": 5}`, http.Header{"If-Match": {itemETag}})
+	resp.Body.Close()
+	require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	resp = do(http.MethodDelete, path, "", http.Header{"If-Match": {itemETag}})
+	resp.Body.Close()
+	require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	cartItem, err := cart.FindCartItem(context.Background(), i.Svc.DB, added.CartItem.Id)
+	require.Nil(t, err)
+	require.Equal(t, 3, cartItem.Count)
+
+	resp = do(http.MethodGet, "/v1/cart/1", "", http.Header{"If-None-Match": {cartETag}})
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEqual(t, cartETag, resp.Header.Get("ETag"))
+
+	resp = do(http.MethodDelete, path, "", http.Header{"If-Match": {`"2"`}})
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var post = func(key, body string) (*http.Response, []byte) {
+		r, err := http.NewRequest(http.MethodPost, ts.URL+"/v1/cart/item", strings.NewReader(body))
+		require.Nil(t, err)
+		r.Header.Set("Idempotency-Key", key)
+		r.Header.Set("X-User-Id", "1")
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp, b
+	}
+
+	const body = `{"itemId": 1, "userId": 1, "count": 2}`
+	first, firstBody := post("add-1", body)
+	require.Equal(t, http.StatusCreated, first.StatusCode)
+	require.Empty(t, first.Header.Get("Idempotent-Replayed"))
+
+	// Retries are answered with the first response, without repeating it.
+	retry, retryBody := post("add-1", body)
+	require.Equal(t, http.StatusCreated, retry.StatusCode)
+	require.Equal(t, "true", retry.Header.Get("Idempotent-Replayed"))
+	require.Equal(t, first.Header.Get("ETag"), retry.Header.Get("ETag"))
+	require.Equal(t, firstBody, retryBody)
+
+	cartItems, err := cart.CartItems(context.Background(), i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 1)
+	require.Equal(t, 2, cartItems[0].Count)
+
+	// Keys may not be reused for different requests.
+	resp, _ := post("add-1", `{"itemId": 1, "userId": 1, "count": 3}`)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	// Concurrent duplicates of a request in progress are rejected once the
+	// wait passes.
+	i.Svc.Viper.Set(service.EnvVarIdempotencyWait, "200ms")
+	_, err = i.Svc.DB.Exec(`
+  INSERT INTO idempotency_key (scope, idem_key, request_hash, created_at, expires_at)
+  VALUES (1, 'add-2', ?, UTC_TIMESTAMP(6), UTC_TIMESTAMP(6) + INTERVAL 1 MINUTE)
+  `, idempotency.Hash(http.MethodPost, "/v1/cart/item", []byte(body)))
+	require.Nil(t, err)
+	resp, _ = post("add-2", body)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Expired keys may be claimed again.
+	_, err = i.Svc.DB.Exec("UPDATE idempotency_key SET expires_at = UTC_TIMESTAMP(6) - INTERVAL 1 SECOND")
+	require.Nil(t, err)
+	resp, _ = post("add-1", body)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("Idempotent-Replayed"))
+
+	n, err := i.Svc.PurgeIdempotencyKeys(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestCartBatch(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	type Response struct {
+		Applied bool `json:"applied"`
+		Results []struct {
+			Id       string         `json:"id"`
+			Status   int            `json:"status"`
+			CartItem *cart.CartItem `json:"cartItem"`
+		} `json:"results"`
+	}
+	var batch = func(body string) (int, Response) {
+		resp, err := http.Post(ts.URL+"/v1/cart/1/batch", "application/json", strings.NewReader(body))
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		var r Response
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&r))
+		return resp.StatusCode, r
+	}
+
+	var ctx = context.Background()
+	status, resp := batch(`{"operations": [
+    {"id": "a", "op": "add", "itemId": 1, "count": 1},
+    {"id": "b", "op": "add", "itemId": 2, "count": 2}
+  ]}`)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, resp.Applied)
+	require.Len(t, resp.Results, 2)
+	var lineId = resp.Results[0].CartItem.Id
+
+	// Atomic batches are rolled back entirely if an operation fails.
+	status, resp = batch(fmt.Sprintf(`{"operations": [
+    {"id": "a", "op": "set", "cartItemId": %d, "count": 5},
+    {"id": "b", "op": "add", "itemId": 999999, "count": 1}
+  ]}`, lineId))
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.False(t, resp.Applied)
+	require.Equal(t, http.StatusFailedDependency, resp.Results[0].Status)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Results[1].Status)
+	cartItem, err := cart.FindCartItem(ctx, i.Svc.DB, lineId)
+	require.Nil(t, err)
+	require.Equal(t, 1, cartItem.Count)
+
+	// Best-effort batches apply the operations that succeed.
+	status, resp = batch(fmt.Sprintf(`{"mode": "bestEffort", "operations": [
+    {"id": "a", "op": "set", "cartItemId": %d, "count": 5},
+    {"id": "b", "op": "remove", "cartItemId": 999999},
+    {"id": "c", "op": "add", "itemId": 3, "count": 1}
+  ]}`, lineId))
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, resp.Applied)
+	require.Equal(t, http.StatusOK, resp.Results[0].Status)
+	require.Equal(t, http.StatusNotFound, resp.Results[1].Status)
+	require.Equal(t, http.StatusOK, resp.Results[2].Status)
+
+	cartItems, err := cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 3)
+	cartItem, err = cart.FindCartItem(ctx, i.Svc.DB, lineId)
+	require.Nil(t, err)
+	require.Equal(t, 5, cartItem.Count)
+}
+
+func TestPatchCartItemAndClearCart(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var do = func(method, path, body string) *http.Response {
+		r, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.Nil(t, err)
+		r.Header.Set("Content-Type", "application/merge-patch+json")
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err)
+		return resp
+	}
+
+	var ctx = context.Background()
+	var (
+		first  = createCartItem(t, i, cart.UserCartItemRel{ItemId: 1, UserId: 1, Count: 1})
+		second = createCartItem(t, i, cart.UserCartItemRel{ItemId: 2, UserId: 1, Count: 1})
+	)
+
+	// Only the fields given are changed.
+	resp := do(http.MethodPatch, "/v1/cart/item/"+strconv.Itoa(first), `{"count": 4}`)
+	var patched struct {
+		CartItem cart.CartItem `json:"cartItem"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&patched))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 4, patched.CartItem.Count)
+	require.Equal(t, 1, patched.CartItem.Item.Id)
+
+	resp = do(http.MethodPatch, "/v1/cart/item/"+strconv.Itoa(first), `{"count": null}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	// A count of 0 removes the cart item.
+	resp = do(http.MethodPatch, "/v2/cart/item/"+strconv.Itoa(second), `{"count": 0}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	cartItems, err := cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 1)
+
+	resp = do(http.MethodDelete, "/v1/cart/1", "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	cartItems, err = cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 0)
+
+	var cleared []cart.CartClearedEvent
+	var sink = outbox.SinkFunc(func(ctx context.Context, ev outbox.Event) error {
+		if ev.Type == cart.EventCartCleared {
+			var payload cart.CartClearedEvent
+			require.Nil(t, json.Unmarshal(ev.Payload, &payload))
+			cleared = append(cleared, payload)
+		}
+		return nil
+	})
+	_, err = outbox.NewRelay(outbox.SQLStore{DB: i.Svc.DB}, []outbox.Sink{sink}, 10, time.Second, 100, zap.NewNop()).Relay(ctx)
+	require.Nil(t, err)
+	require.Equal(t, []cart.CartClearedEvent{{UserId: 1, Reason: cart.ClearedUser}}, cleared)
+
+	entries, err := cart.AuditHistory(ctx, i.Svc.DB, 1, time.Time{}, time.Time{}, 0, 100)
+	require.Nil(t, err)
+	require.Equal(t, cart.AuditClear, entries[len(entries)-1].Action)
+}
+
+func TestQuantityLimits(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+	i.Svc.Viper.Set(service.EnvVarCartMaxLines, 2)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var ctx = context.Background()
+	_, err := i.Svc.DB.ExecContext(ctx, "UPDATE item SET max_per_order = 6, step = 2 WHERE id = 1")
+	require.Nil(t, err)
+
+	var add = func(itemId, count int) *http.Response {
+		body := fmt.Sprintf(`{"userId": 1, "itemId": %d, "count": %d}`, itemId, count)
+		resp, err := http.Post(ts.URL+"/v1/cart/item", "application/json", strings.NewReader(body))
+		require.Nil(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	require.Equal(t, http.StatusUnprocessableEntity, add(1, 3).StatusCode)
+	require.Equal(t, http.StatusCreated, add(1, 4).StatusCode)
+	// Merging into the existing line sums the counts before checking.
+	require.Equal(t, http.StatusUnprocessableEntity, add(1, 4).StatusCode)
+	require.Equal(t, http.StatusCreated, add(1, 2).StatusCode)
+
+	require.Equal(t, http.StatusCreated, add(2, 1).StatusCode)
+	require.Equal(t, http.StatusUnprocessableEntity, add(3, 1).StatusCode)
+
+	cartItems, err := cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 2)
+
+	// Restored lines are checked as new lines are.
+	var lines = make(map[int]int)
+	for _, ci := range cartItems {
+		lines[ci.Item.Id] = ci.Id
+	}
+	var do = func(method string, id int, suffix string) int {
+		r, err := http.NewRequest(method, ts.URL+"/v1/cart/item/"+strconv.Itoa(id)+suffix, nil)
+		require.Nil(t, err)
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, do(http.MethodDelete, lines[2], ""))
+	require.Equal(t, http.StatusCreated, add(3, 1).StatusCode)
+	require.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, lines[2], "/restore"))
+
+	require.Equal(t, http.StatusOK, do(http.MethodDelete, lines[1], ""))
+	require.Equal(t, http.StatusOK, do(http.MethodPost, lines[2], "/restore"))
+	_, err = i.Svc.DB.ExecContext(ctx, "UPDATE item SET max_per_order = 4 WHERE id = 1")
+	require.Nil(t, err)
+	cartItems, err = cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	for _, ci := range cartItems {
+		if ci.Item.Id == 3 {
+			require.Equal(t, http.StatusOK, do(http.MethodDelete, ci.Id, ""))
+		}
+	}
+	require.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, lines[1], "/restore"))
+}
+
+func TestItemVariants(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var ctx = context.Background()
+	_, err := i.Svc.DB.ExecContext(ctx, `
+    INSERT INTO item_variant (id, item_id, sku, attributes, price, stock)
+    VALUES (1, 1, 'ITEM1-S', '{"size": "S"}', NULL, 5),
+           (2, 1, 'ITEM1-XL', '{"size": "XL"}', 99.50, 2),
+           (3, 2, 'ITEM2-ONE', '{"size": "one"}', NULL, 5)
+  `)
+	require.Nil(t, err)
+
+	resp, err := http.Get(ts.URL + "/v1/items")
+	require.Nil(t, err)
+	var got struct {
+		Items []item.Item `json:"items"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&got))
+	resp.Body.Close()
+	require.Equal(t, 1, got.Items[0].Id)
+	require.Len(t, got.Items[0].Variants, 2)
+	require.Equal(t, "ITEM1-XL", got.Items[0].Variants[1].SKU)
+	require.Equal(t, map[string]string{"size": "XL"}, got.Items[0].Variants[1].Attributes)
+
+	var add = func(itemId, variantId, count int) *http.Response {
+		body := fmt.Sprintf(`{"userId": 1, "itemId": %d, "variantId": %d, "count": %d}`, itemId, variantId, count)
+		resp, err := http.Post(ts.URL+"/v1/cart/item", "application/json", strings.NewReader(body))
+		require.Nil(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// Items with variants are added by variant, of the same item, in stock.
+	require.Equal(t, http.StatusUnprocessableEntity, add(1, 0, 1).StatusCode)
+	require.Equal(t, http.StatusUnprocessableEntity, add(1, 3, 1).StatusCode)
+	require.Equal(t, http.StatusUnprocessableEntity, add(1, 2, 3).StatusCode)
+
+	// Each variant is a separate line, and lines of a variant are merged.
+	require.Equal(t, http.StatusCreated, add(1, 1, 1).StatusCode)
+	require.Equal(t, http.StatusCreated, add(1, 1, 1).StatusCode)
+	require.Equal(t, http.StatusCreated, add(1, 2, 2).StatusCode)
+
+	cartItems, err := cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Len(t, cartItems, 2)
+	var counts = make(map[string]int)
+	for _, ci := range cartItems {
+		require.NotNil(t, ci.Variant)
+		counts[ci.Variant.SKU] = ci.Count
+		if ci.Variant.Id == 2 {
+			require.Equal(t, 99.5, ci.Item.Price)
+		}
+	}
+	require.Equal(t, map[string]int{"ITEM1-S": 2, "ITEM1-XL": 2}, counts)
+
+	// Variant lines are updated through GraphQL as through REST.
+	for _, ci := range cartItems {
+		if ci.Variant.Id != 2 {
+			continue
+		}
+		body := fmt.Sprintf(`{"query": "mutation { updateCartItem(id: %d, userId: 1, itemId: 1, variantId: 2, count: 1) { count } }"}`, ci.Id)
+		resp, err := http.Post(ts.URL+"/graphql", "application/json", strings.NewReader(body))
+		require.Nil(t, err)
+		var updated struct {
+			Data struct {
+				UpdateCartItem struct {
+					Count int `json:"count"`
+				} `json:"updateCartItem"`
+			} `json:"data"`
+			Errors []interface{} `json:"errors"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&updated))
+		resp.Body.Close()
+		require.Empty(t, updated.Errors)
+		require.Equal(t, 1, updated.Data.UpdateCartItem.Count)
+	}
+
+	// The db holds a cart to one live line per item and variant, however
+	// many tombstones of it remain.
+	_, err = cart.CreateUserCartItemRel(ctx, i.Svc.DB, cart.UserCartItemRel{UserId: 1, ItemId: 1, VariantId: 1, Count: 1}, 1)
+	require.Equal(t, cart.ErrConflict, errors.Cause(err))
+	for _, ci := range cartItems {
+		if ci.Variant.Id == 1 {
+			require.Nil(t, cart.DeleteCartItem(ctx, i.Svc.DB, ci.Id))
+		}
+	}
+	_, err = cart.CreateUserCartItemRel(ctx, i.Svc.DB, cart.UserCartItemRel{UserId: 1, ItemId: 1, VariantId: 1, Count: 1}, 1)
+	require.Nil(t, err)
+}
+
+func TestCategories(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var do = func(method, path, body string) *http.Response {
+		r, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.Nil(t, err)
+		r.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err)
+		return resp
+	}
+	var create = func(body string) int {
+		resp := do(http.MethodPost, "/v1/admin/categories", body)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created struct {
+			Category item.Category `json:"category"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&created))
+		return created.Category.Id
+	}
+
+	var apparel = create(`{"slug": "apparel", "name": "Apparel"}`)
+	var shirts = create(fmt.Sprintf(`{"slug": "shirts", "name": "Shirts", "parentId": %d}`, apparel))
+	create(`{"slug": "home", "name": "Home", "sortOrder": 1}`)
+
+	resp := do(http.MethodPost, "/v1/admin/categories", `{"slug": "shirts", "name": "Shirts"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp = do(http.MethodPost, "/v1/admin/categories", `{"slug": "Not A Slug", "name": "Bad"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/v1/categories", "")
+	var tree struct {
+		Categories []item.Category `json:"categories"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&tree))
+	resp.Body.Close()
+	require.Len(t, tree.Categories, 2)
+	require.Equal(t, "apparel", tree.Categories[0].Slug)
+	require.Equal(t, "shirts", tree.Categories[0].Children[0].Slug)
+
+	for _, itemId := range []int{1, 2, 3} {
+		resp = do(http.MethodPut, fmt.Sprintf("/v1/admin/items/%d/categories", itemId), fmt.Sprintf(`{"categoryIds": [%d]}`, shirts))
+		resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+	resp = do(http.MethodPut, "/v1/admin/items/1/categories", `{"categoryIds": [999]}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	// Items of subcategories are listed, and paged by id.
+	var page struct {
+		Items     []item.Item `json:"items"`
+		NextAfter int         `json:"nextAfter"`
+	}
+	resp = do(http.MethodGet, "/v1/categories/apparel/items?limit=2", "")
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&page))
+	resp.Body.Close()
+	require.Len(t, page.Items, 2)
+	require.Equal(t, 2, page.NextAfter)
+	require.Equal(t, []item.Breadcrumb{{
+		{Id: apparel, Slug: "apparel", Name: "Apparel"},
+		{Id: shirts, Slug: "shirts", Name: "Shirts"},
+	}}, page.Items[0].Breadcrumbs)
+
+	resp = do(http.MethodGet, "/v1/categories/apparel/items?limit=2&after=2", "")
+	page.Items, page.NextAfter = nil, 0
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&page))
+	resp.Body.Close()
+	require.Len(t, page.Items, 1)
+	require.Equal(t, 0, page.NextAfter)
+
+	resp = do(http.MethodGet, "/v1/categories/nope/items", "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestItemMedia(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var upload = func(file []byte, altText string) *http.Response {
+		var (
+			body bytes.Buffer
+			mw   = multipart.NewWriter(&body)
+		)
+		fw, err := mw.CreateFormFile("file", "photo.png")
+		require.Nil(t, err)
+		_, err = fw.Write(file)
+		require.Nil(t, err)
+		require.Nil(t, mw.WriteField("altText", altText))
+		require.Nil(t, mw.Close())
+		resp, err := http.Post(ts.URL+"/v1/admin/items/1/media", mw.FormDataContentType(), &body)
+		require.Nil(t, err)
+		return resp
+	}
+
+	var png bytes.Buffer
+	require.Nil(t, imagepng.Encode(&png, image.NewNRGBA(image.Rect(0, 0, 400, 200))))
+
+	resp := upload([]byte("not an image"), "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp = upload(png.Bytes(), "A photo")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		Media item.Media `json:"media"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	var m = created.Media
+	require.Equal(t, "A photo", m.AltText)
+	require.Equal(t, "image/png", m.ContentType)
+	require.Equal(t, 400, m.Width)
+	require.Len(t, m.Thumbnails, 3)
+	require.Equal(t, []int{128, 64}, []int{m.Thumbnails[0].Width, m.Thumbnails[0].Height})
+	require.Equal(t, []int{400, 200}, []int{m.Thumbnails[2].Width, m.Thumbnails[2].Height})
+
+	for _, url := range []string{m.URL, m.Thumbnails[0].URL} {
+		resp, err := http.Get(ts.URL + url)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	}
+
+	// Media are included in item and cart responses.
+	resp, err := http.Get(ts.URL + "/v2/items")
+	require.Nil(t, err)
+	var got struct {
+		Items []struct {
+			Id    int          `json:"id"`
+			Media []item.Media `json:"media"`
+		} `json:"items"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&got))
+	resp.Body.Close()
+	require.Equal(t, 1, got.Items[0].Id)
+	require.Len(t, got.Items[0].Media, 1)
+	require.Equal(t, m.URL, got.Items[0].Media[0].URL)
+
+	createCartItem(t, i, cart.UserCartItemRel{ItemId: 1, UserId: 1, Count: 1})
+	resp, err = http.Get(ts.URL + "/v1/cart/1")
+	require.Nil(t, err)
+	var c struct {
+		CartItems []cart.CartItem `json:"cartItems"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&c))
+	resp.Body.Close()
+	require.Len(t, c.CartItems, 1)
+	require.Len(t, c.CartItems[0].Item.Media, 1)
+
+	var do = func(method, path, body string) *http.Response {
+		r, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.Nil(t, err)
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err)
+		return resp
+	}
+	var path = fmt.Sprintf("/v1/admin/items/1/media/%d", m.Id)
+	resp = do(http.MethodPut, path, `{"altText": "Front", "sortOrder": 2}`)
+	var updated struct {
+		Media item.Media `json:"media"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&updated))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Front", updated.Media.AltText)
+	require.Equal(t, 2, updated.Media.SortOrder)
+
+	resp = do(http.MethodDelete, fmt.Sprintf("/v1/admin/items/2/media/%d", m.Id), "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = do(http.MethodDelete, path, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Get(ts.URL + m.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCatalogImportExport(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var export = func(format string) []string {
+		resp, err := http.Get(ts.URL + "/v1/admin/items/export?format=" + format)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		b, err := ioutil.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return strings.Split(strings.TrimSpace(string(b)), "\n")
+	}
+	var importFile = func(query, contentType, body string) catalog.Report {
+		resp, err := http.Post(ts.URL+"/v1/admin/items/import"+query, contentType, strings.NewReader(body))
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result struct {
+			Report catalog.Report `json:"report"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result.Report
+	}
+
+	items, err := item.Items(context.Background(), i.Svc.DB)
+	require.Nil(t, err)
+	first, err := item.FindItem(context.Background(), i.Svc.DB, 1)
+	require.Nil(t, err)
+	var csvLines = export(catalog.CSV)
+	require.Equal(t, "id,name,description,price,minPerOrder,maxPerOrder,step", csvLines[0])
+	require.Len(t, csvLines, len(items)+1)
+	require.Len(t, export(catalog.JSONLines), len(items))
+
+	// Re-importing an export changes nothing.
+	var report = importFile("", "text/csv", strings.Join(csvLines, "\n"))
+	require.Equal(t, len(items), report.Unchanged)
+	require.Empty(t, report.Changes)
+
+	var file = strings.Join([]string{
+		fmt.Sprintf(`{"id": 1, "name": %q, "description": %q, "price": 99.5}`, first.Name, first.Description),
+		`{"name": "Imported", "price": 3, "maxPerOrder": 5}`,
+		`{"name": "", "price": -1}`,
+		`{"id": 1, "name": "Duplicate"}`,
+	}, "\n")
+	report = importFile("?dryRun=true", "application/x-ndjson", file)
+	require.True(t, report.DryRun)
+	require.Equal(t, 1, report.Created)
+	require.Equal(t, 1, report.Updated)
+	require.Equal(t, 2, report.Failed)
+	require.Equal(t, []int{3, 4}, []int{report.Errors[0].Line, report.Errors[1].Line})
+	require.Equal(t, catalog.FieldChange{Old: first.Price, New: 99.5}, report.Changes[0].Fields["price"])
+	require.Len(t, export(catalog.JSONLines), len(items))
+
+	report = importFile("?format=jsonl", "", file)
+	require.False(t, report.DryRun)
+	require.Equal(t, 1, report.Created)
+	require.NotZero(t, report.Changes[1].Id)
+
+	found, err := item.FindItem(context.Background(), i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Equal(t, 99.5, found.Price)
+	found, err = item.FindItem(context.Background(), i.Svc.DB, report.Changes[1].Id)
+	require.Nil(t, err)
+	require.Equal(t, "Imported", found.Name)
+	require.Equal(t, 5, found.MaxPerOrder)
+	require.Len(t, export(catalog.CSV), len(items)+2)
+
+	// The items written, and only they, are recorded in the outbox.
+	var events = make(map[string][]int)
+	var sink = outbox.SinkFunc(func(ctx context.Context, ev outbox.Event) error {
+		if ev.Type == item.EventItemCreated || ev.Type == item.EventItemUpdated {
+			var payload item.ItemEvent
+			require.Nil(t, json.Unmarshal(ev.Payload, &payload))
+			require.Equal(t, ev.AggregateId, payload.Item.Id)
+			events[ev.Type] = append(events[ev.Type], payload.Item.Id)
+		}
+		return nil
+	})
+	_, err = outbox.NewRelay(outbox.SQLStore{DB: i.Svc.DB}, []outbox.Sink{sink}, 10, time.Second, 100, zap.NewNop()).Relay(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, map[string][]int{
+		item.EventItemCreated: {report.Changes[1].Id},
+		item.EventItemUpdated: {1},
+	}, events)
+
+	resp, err := http.Post(ts.URL+"/v1/admin/items/import", "application/json", strings.NewReader(file))
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Only admins may import.
+	var w = httptest.NewRecorder()
+	var r = httptest.NewRequest(http.MethodPost, "/v1/admin/items/import?format=jsonl", strings.NewReader(`{"name": "Unauthorized", "price": 1}`))
+	r.Header.Set("X-User-Id", "2")
+	i.Svc.Router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Len(t, export(catalog.CSV), len(items)+2)
+}
+
+func TestScheduledPrices(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var do = func(method, path, body string) *http.Response {
+		r, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.Nil(t, err)
+		r.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err)
+		return resp
+	}
+	var schedule = func(body string) item.ScheduledPrice {
+		resp := do(http.MethodPost, "/v1/admin/items/1/prices", body)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created struct {
+			ScheduledPrice item.ScheduledPrice `json:"scheduledPrice"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&created))
+		return created.ScheduledPrice
+	}
+
+	var ctx = context.Background()
+	before, err := item.FindItem(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Nil(t, before.RegularPrice)
+	var beforeSale = time.Now()
+
+	// The cart shows the price in effect now, and the snapshot of the cart
+	// keeps the price that applied when the line was added.
+	resp := do(http.MethodPost, "/v1/cart/item", `{"userId": 1, "itemId": 1, "count": 1}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var addedAt = time.Now()
+
+	var sale = schedule(`{"price": 0.5}`)
+	var future = schedule(fmt.Sprintf(`{"price": 0.25, "validFrom": %q}`, time.Now().Add(24*time.Hour).Format(time.RFC3339)))
+
+	found, err := item.FindItem(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Equal(t, 0.5, found.Price)
+	require.Equal(t, before.Price, *found.RegularPrice)
+	cartItems, err := cart.CartItems(ctx, i.Svc.DB, 1)
+	require.Nil(t, err)
+	require.Equal(t, 0.5, cartItems[0].Item.Price)
+
+	type snapshotLine struct {
+		cart.AuditLine
+		UnitPrice *float64 `json:"unitPrice"`
+	}
+	var snapshotAt = func(at time.Time) []snapshotLine {
+		resp := do(http.MethodGet, "/v1/admin/cart/1/snapshot?at="+at.UTC().Format(time.RFC3339Nano), "")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var snapshot struct {
+			Lines []snapshotLine `json:"lines"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&snapshot))
+		return snapshot.Lines
+	}
+	var lines = snapshotAt(time.Now())
+	require.Equal(t, before.Price, *lines[0].Price)
+	require.Equal(t, 0.5, *lines[0].UnitPrice)
+
+	// A snapshot is priced at its time, whatever the price now.
+	lines = snapshotAt(addedAt)
+	require.Len(t, lines, 1)
+	require.Equal(t, before.Price, *lines[0].UnitPrice)
+
+	// Lines of items with variants have no unit price, as the prices of
+	// variants are not recorded over time.
+	_, err = i.Svc.DB.ExecContext(ctx, `
+    INSERT INTO item_variant (id, item_id, sku, attributes, price, stock)
+    VALUES (1, 2, 'ITEM2-XL', '{"size": "XL"}', 99.50, 5)
+  `)
+	require.Nil(t, err)
+	resp = do(http.MethodPost, "/v1/cart/item", `{"userId": 1, "itemId": 2, "variantId": 1, "count": 1}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	lines = snapshotAt(time.Now())
+	require.Len(t, lines, 2)
+	require.Equal(t, 99.5, *lines[1].Price)
+	require.Nil(t, lines[1].UnitPrice)
+
+	// Prices resolve by time.
+	var priceAt = func(at time.Time) float64 {
+		resp := do(http.MethodGet, "/v1/items/1/price?at="+at.UTC().Format(time.RFC3339Nano), "")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var resolved struct {
+			Price float64 `json:"price"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&resolved))
+		return resolved.Price
+	}
+	require.Equal(t, before.Price, priceAt(beforeSale))
+	require.Equal(t, 0.5, priceAt(time.Now()))
+	require.Equal(t, 0.25, priceAt(time.Now().Add(48*time.Hour)))
+
+	resp = do(http.MethodGet, "/v1/items/1/prices", "")
+	var history struct {
+		BasePrices      []item.BasePrice      `json:"basePrices"`
+		ScheduledPrices []item.ScheduledPrice `json:"scheduledPrices"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&history))
+	resp.Body.Close()
+	require.Len(t, history.BasePrices, 1)
+	require.Equal(t, []int{sale.Id, future.Id}, []int{history.ScheduledPrices[0].Id, history.ScheduledPrices[1].Id})
+
+	// Only prices that have not taken effect may be cancelled.
+	resp = do(http.MethodDelete, fmt.Sprintf("/v1/admin/items/1/prices/%d", sale.Id), "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp = do(http.MethodDelete, fmt.Sprintf("/v1/admin/items/1/prices/%d", future.Id), "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = do(http.MethodDelete, fmt.Sprintf("/v1/admin/items/1/prices/%d", future.Id), "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = do(http.MethodPost, "/v1/admin/items/999999/prices", `{"price": 1}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+func TestPriceChangeNotifications(t *testing.T) {
+	t.Parallel()
+	var i = newInject(t)
+	defer i.Close(t)
+
+	var ts = httptest.NewServer(asAdmin(i.Svc.Router))
+	defer ts.Close()
+
+	var do = func(method, path, body string, header http.Header) *http.Response {
+		r, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.Nil(t, err)
+		r.Header = header
+		r.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err)
+		return resp
+	}
+	var getCart = func() ([]cart.CartItem, string) {
+		resp := do(http.MethodGet, "/v1/cart/1", "", http.Header{})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body struct {
+			CartItems []cart.CartItem `json:"cartItems"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body.CartItems, resp.Header.Get("ETag")
+	}
+
+	for _, itemId := range []int{1, 2} {
+		resp := do(http.MethodPost, "/v1/cart/item", fmt.Sprintf(`{"userId": 1, "itemId": %d, "count": 1}`, itemId), http.Header{})
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	cartItems, etag := getCart()
+	require.Len(t, cartItems, 2)
+	require.Nil(t, cartItems[0].PriceChange)
+	var old = cartItems[0].Item.Price
+
+	// A price taking effect changes the cart without modifying it.
+	resp := do(http.MethodPost, "/v1/admin/items/1/prices", `{"price": 0.75}`, http.Header{})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp = do(http.MethodGet, "/v1/cart/1", "", http.Header{"If-None-Match": {etag}})
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cartItems, _ = getCart()
+	require.Equal(t, &cart.PriceChange{Old: old, New: 0.75}, cartItems[0].PriceChange)
+	require.Nil(t, cartItems[1].PriceChange)
+
+	resp = do(http.MethodGet, "/v2/cart/1", "", http.Header{})
+	var v2 struct {
+		Cart struct {
+			CartItems []struct {
+				PriceChange *struct {
+					New struct {
+						Amount int64 `json:"amount"`
+					} `json:"new"`
+				} `json:"priceChange"`
+			} `json:"cartItems"`
+		} `json:"cart"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v2))
+	resp.Body.Close()
+	require.NotNil(t, v2.Cart.CartItems[0].PriceChange)
+
+	resp = do(http.MethodPost, "/v1/cart/1/price-changes/acknowledge", "", http.Header{})
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	cartItems, _ = getCart()
+	require.Nil(t, cartItems[0].PriceChange)
+	require.Equal(t, 0.75, cartItems[0].Item.Price)
+	require.Equal(t, 2, cartItems[0].Version)
+	require.Equal(t, 1, cartItems[1].Version)
+}